@@ -50,7 +50,7 @@ var addHeaderCmd = &cobra.Command{
 			}
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -0,0 +1,324 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+)
+
+// barCmd represents the bar command
+var barCmd = &cobra.Command{
+	Use:   "bar",
+	Short: "bar chart",
+	Long: `bar chart
+
+Bars are drawn for categories in the data field (-f/--data-field).
+Bar heights are the numbers of records of each category, or aggregated
+values of the value field (-y/--value-field), see -a/--aggregate.
+
+With a group field (-g/--group-field), bars of groups are placed side by side,
+or stacked with --stack.
+
+Notes:
+
+  1. Output file can be set by flag -o/--out-file.
+  2. File format is determined by the out file suffix.
+     Supported formats: eps, jpg|jpeg, pdf, png, svg, and tif|tiff
+  3. If flag -o/--out-file not set (default), image is written to stdout,
+     you can display the image by pipping to "display" command of Imagemagic
+     or just redirect to file.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		plotConfig := getPlotConfigs(cmd)
+
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		skipNA := getFlagBool(cmd, "skip-na")
+		naValues := getFlagStringSlice(cmd, "na-values")
+		if skipNA && len(naValues) == 0 {
+			log.Errorf("the value of --na-values should not be empty when using --skip-na")
+		}
+		naMap := make(map[string]interface{}, len(naValues))
+		for _, na := range naValues {
+			naMap[strings.ToLower(na)] = struct{}{}
+		}
+
+		valueFieldStr := getFlagString(cmd, "value-field")
+		if strings.Contains(valueFieldStr, ",") {
+			checkError(fmt.Errorf("only one field allowed for flag -y/--value-field"))
+		}
+		op := getFlagString(cmd, "aggregate")
+		if _, ok := summaryOpFunc(op); !ok {
+			checkError(fmt.Errorf(`invalid operation: %s. run "csvtk summary --help" for help`, op))
+		}
+
+		horiz := getFlagBool(cmd, "horiz")
+		stack := getFlagBool(cmd, "stack")
+		showLabels := getFlagBool(cmd, "labels")
+		labelFormat := fmt.Sprintf("%%.%df", getFlagNonNegativeInt(cmd, "decimal-width"))
+		sortBy := strings.ToLower(getFlagString(cmd, "sort"))
+		switch sortBy {
+		case "", "key", "value":
+		default:
+			checkError(fmt.Errorf("invalid value of flag --sort: %s, available: key, value", sortBy))
+		}
+		reverse := getFlagBool(cmd, "reverse")
+		w := vg.Length(getFlagNonNegativeFloat64(cmd, "bar-width"))
+		colorIndex := getFlagPositiveInt(cmd, "color-index")
+		if colorIndex > 7 {
+			checkError(fmt.Errorf("unsupported color index"))
+		}
+
+		// fields: category, group, value
+		fieldStr := plotConfig.fieldStr
+		plotConfig.ignoreScales("bar", plotConfig.xaxis, plotConfig.yaxis)
+		hasGroup := plotConfig.groupFieldStr != ""
+		hasValue := valueFieldStr != ""
+		if hasValue {
+			fieldStr += "," + valueFieldStr
+		}
+
+		file := files[0]
+		headerRow, _, data, _, _, err := parseCSVfile(cmd, config, file, fieldStr, false, false)
+		checkError(err)
+
+		categories := newCategoryOrder()
+		groups := newCategoryOrder()
+		values := make(map[[2]string][]string) // category, group -> values
+		counts := make(map[[2]string]float64)
+		var key [2]string
+		var v string
+		for _, d := range data {
+			key[0] = d[0]
+			if hasGroup {
+				key[1] = d[1]
+			}
+			if hasValue {
+				v = d[len(d)-1]
+				if skipNA {
+					if _, ok := naMap[strings.ToLower(v)]; ok {
+						continue
+					}
+				}
+				values[key] = append(values[key], v)
+			} else {
+				counts[key]++
+			}
+			categories.Add(key[0])
+			groups.Add(key[1])
+		}
+		if hasValue {
+			for key, vals := range values {
+				s, err := aggregateValues(op, vals, false, "%v")
+				checkError(err)
+				counts[key], err = strconv.ParseFloat(s, 64)
+				checkError(err)
+			}
+		}
+
+		// order of categories
+		cats := categories.Keys()
+		switch sortBy {
+		case "key":
+			sort.Strings(cats)
+		case "value":
+			totals := make(map[string]float64, len(cats))
+			for key, c := range counts {
+				totals[key[0]] += c
+			}
+			sort.SliceStable(cats, func(i, j int) bool { return totals[cats[i]] > totals[cats[j]] })
+		}
+		if reverse {
+			for i, j := 0, len(cats)-1; i < j; i, j = i+1, j-1 {
+				cats[i], cats[j] = cats[j], cats[i]
+			}
+		}
+		if horiz { // the first category at the top
+			for i, j := 0, len(cats)-1; i < j; i, j = i+1, j-1 {
+				cats[i], cats[j] = cats[j], cats[i]
+			}
+		}
+
+		grps := groups.Keys()
+		nBars := len(grps)
+		if stack {
+			nBars = 1
+		}
+		if w == 0 {
+			size := plotConfig.width
+			if horiz {
+				size = plotConfig.height
+			}
+			w = vg.Points(float64(size*vg.Inch) / float64(len(cats)) / float64(nBars) / 1.6)
+		}
+
+		p := plotConfig.newPlot()
+
+		var prev *plotter.BarChart
+		cums := make(plotter.Values, len(cats)) // for labels of stacked bars
+		for i, g := range grps {
+			vs := make(plotter.Values, len(cats))
+			for j, c := range cats {
+				vs[j] = counts[[2]string{c, g}]
+			}
+
+			bars, err := plotter.NewBarChart(vs, w)
+			checkError(err)
+			bars.Horizontal = horiz
+			bars.LineStyle.Width = vg.Length(0)
+			bars.Color = plotConfig.color(colorIndex - 1 + i)
+			if stack {
+				if prev != nil {
+					bars.StackOn(prev)
+				}
+				prev = bars
+			} else {
+				bars.Offset = vg.Length(float64(i)-float64(nBars-1)/2) * w
+			}
+			p.Add(bars)
+			if hasGroup {
+				p.Legend.Add(g, bars)
+			}
+
+			if showLabels {
+				xys := make(plotter.XYs, len(cats))
+				labels := make([]string, len(cats))
+				for j := range cats {
+					pos := vs[j]
+					if stack {
+						pos = cums[j] + vs[j]/2
+						cums[j] += vs[j]
+					}
+					if horiz {
+						xys[j] = plotter.XY{X: pos, Y: float64(j)}
+					} else {
+						xys[j] = plotter.XY{X: float64(j), Y: pos}
+					}
+					if _, ok := counts[[2]string{cats[j], g}]; ok {
+						labels[j] = fmt.Sprintf(labelFormat, vs[j])
+					}
+				}
+				l, err := plotter.NewLabels(plotter.XYLabels{XYs: xys, Labels: labels})
+				checkError(err)
+				for k := range l.TextStyle {
+					l.TextStyle[k].Font.Size = plotConfig.tickLabelSize * 0.8
+					switch {
+					case stack:
+						l.TextStyle[k].XAlign = text.XCenter
+						l.TextStyle[k].YAlign = text.YCenter
+					case horiz:
+						l.TextStyle[k].YAlign = text.YCenter
+						l.Offset = vg.Point{X: vg.Points(2), Y: bars.Offset}
+					default:
+						l.TextStyle[k].XAlign = text.XCenter
+						l.Offset = vg.Point{X: bars.Offset, Y: vg.Points(2)}
+					}
+				}
+				p.Add(l)
+			}
+		}
+		if showLabels { // leave space for labels
+			if horiz {
+				p.X.Max *= 1.08
+			} else {
+				p.Y.Max *= 1.08
+			}
+		}
+		p.Legend.Top = getFlagBool(cmd, "legend-top")
+		p.Legend.Left = getFlagBool(cmd, "legend-left")
+
+		var catLabel, valueLabel string
+		if len(headerRow) > 0 {
+			catLabel = headerRow[0]
+		} else {
+			catLabel = "Categories"
+		}
+		if hasValue {
+			if len(headerRow) > 0 {
+				valueLabel = headerRow[len(headerRow)-1]
+			} else {
+				valueLabel = "Values"
+			}
+		} else {
+			valueLabel = "Count"
+		}
+		if !horiz {
+			p.NominalX(cats...)
+			if plotConfig.xlab == "" {
+				plotConfig.xlab = catLabel
+			}
+			if plotConfig.ylab == "" {
+				plotConfig.ylab = valueLabel
+			}
+		} else {
+			p.NominalY(cats...)
+			if plotConfig.xlab == "" {
+				plotConfig.xlab = valueLabel
+			}
+			if plotConfig.ylab == "" {
+				plotConfig.ylab = catLabel
+			}
+		}
+
+		plotConfig.applyStyle(p)
+		plotConfig.applyLimits(p)
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
+		}
+
+		// Save image
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
+	},
+}
+
+func init() {
+	plotCmd.AddCommand(barCmd)
+
+	barCmd.Flags().StringP("value-field", "y", "", `column index or column name of values, numbers of records are counted if not given`)
+	barCmd.Flags().StringP("aggregate", "a", "sum", `operation of "csvtk summary" to aggregate values of the same category and group, e.g., sum, mean, median, max`)
+	barCmd.Flags().BoolP("stack", "", false, "stack bars of groups")
+	barCmd.Flags().BoolP("horiz", "", false, "horizontal bar chart")
+	barCmd.Flags().StringP("sort", "", "", `sort categories by "key", or "value" (descending total values), default: order of appearance`)
+	barCmd.Flags().BoolP("reverse", "", false, "reverse the order of categories")
+	barCmd.Flags().BoolP("labels", "", false, "show values on bars")
+	barCmd.Flags().IntP("decimal-width", "w", 0, "limit floats of value labels to N decimal points")
+	barCmd.Flags().Float64P("bar-width", "", 0, "bar width, 0 for automatic")
+	barCmd.Flags().IntP("color-index", "", 1, `color index of the first group, 1-7`)
+	barCmd.Flags().BoolP("legend-top", "", false, "locate legend along the top edge of the plot")
+	barCmd.Flags().BoolP("legend-left", "", false, "locate legend along the left edge of the plot")
+}
@@ -22,7 +22,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"runtime"
 	"sort"
 	"strconv"
@@ -72,8 +71,14 @@ Notes:
 			naMap[strings.ToLower(na)] = struct{}{}
 		}
 
+		facets := getPlotFacets(cmd)
+		fieldStr := plotConfig.fieldStr
+		if facets.Enabled() {
+			fieldStr += "," + facets.field
+		}
+
 		file := files[0]
-		headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, plotConfig.fieldStr, false, false)
+		headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, fieldStr, false, false)
 
 		if err != nil {
 			// if err == xopen.ErrNoContent {
@@ -83,18 +88,34 @@ Notes:
 			checkError(err)
 		}
 
+		headerRow, rowFacets := facets.Split(headerRow, data)
+
 		// =======================================
 
 		horiz := getFlagBool(cmd, "horiz")
 		w := vg.Length(getFlagNonNegativeFloat64(cmd, "box-width"))
 
-		groups := make(map[string]plotter.Values)
+		// values are on Y axis, or X axis for horizontal boxes
+		valueAxis := plotConfig.yaxis
+		if horiz {
+			valueAxis = plotConfig.xaxis
+			plotConfig.ignoreScales("box", plotConfig.yaxis)
+			plotConfig.yaxis = &plotAxisConfig{name: "Y"}
+		} else {
+			plotConfig.ignoreScales("box", plotConfig.xaxis)
+			plotConfig.xaxis = &plotAxisConfig{name: "X"}
+		}
+
+		groups := make([]map[string]plotter.Values, facets.N())
+		for i := range groups {
+			groups[i] = make(map[string]plotter.Values)
+		}
 		groupOrderMap := make(map[string]int)
 		var f float64
 		var ok bool
 		var order int
 		var groupName string
-		for _, d := range data {
+		for r, d := range data {
 			if skipNA {
 				if _, ok = naMap[strings.ToLower(d[0])]; ok {
 					continue
@@ -109,6 +130,9 @@ Notes:
 					checkError(fmt.Errorf("fail to parse data: %s at column: %d. please choose the right column by flag -f (--data-field)", d[0], fields[0]))
 				}
 			}
+			if f, ok = valueAxis.Value(f); !ok {
+				continue
+			}
 			if len(d) > 1 {
 				groupName = d[1]
 			} else {
@@ -118,10 +142,7 @@ Notes:
 					groupName = ""
 				}
 			}
-			if _, ok = groups[groupName]; !ok {
-				groups[groupName] = make(plotter.Values, 0)
-			}
-			groups[groupName] = append(groups[groupName], f)
+			groups[rowFacets[r]][groupName] = append(groups[rowFacets[r]][groupName], f)
 
 			if _, ok = groupOrderMap[groupName]; !ok {
 				groupOrderMap[groupName] = order
@@ -129,7 +150,7 @@ Notes:
 			}
 		}
 
-		p := plot.New()
+		valueAxis.WarnRemoved()
 
 		var groupOrders []stringutil.StringCount
 		for g := range groupOrderMap {
@@ -137,40 +158,57 @@ Notes:
 		}
 		sort.Sort(stringutil.StringCountList(groupOrders))
 
-		if !horiz {
-			if w == 0 {
-				w = vg.Points(float64(plotConfig.width*vg.Inch) / float64(len(groupOrders)) / 2.5)
+		if w == 0 {
+			size := plotConfig.width
+			if horiz {
+				size = plotConfig.height
 			}
-		} else {
-			if w == 0 {
-				w = vg.Points(float64(plotConfig.height*vg.Inch) / float64(len(groupOrders)) / 2.5)
+			if facets.Enabled() {
+				_, cols := facets.Grid(len(groups))
+				size /= vg.Length(cols)
 			}
+			w = vg.Points(float64(size*vg.Inch) / float64(len(groupOrders)) / 2.5)
 		}
 
 		groupNames := make([]string, len(groupOrders))
 		for i, group := range groupOrders {
 			groupNames[i] = group.Key
-			b, err := plotter.NewBoxPlot(w, float64(i), groups[group.Key])
-			checkError(err)
-			if horiz {
-				b.Horizontal = true
-			}
-			p.Add(b)
 		}
 
-		if !horiz {
-			p.NominalX(groupNames...)
-			// p.HideX()
-		} else {
-			p.NominalY(groupNames...)
-			// p.HideY()
+		plots := make([]*plot.Plot, len(groups))
+		for k := range groups {
+			if len(groups[k]) == 0 {
+				continue
+			}
+			p := plotConfig.newPlot()
+			for i, group := range groupOrders {
+				if len(groups[k][group.Key]) == 0 {
+					continue
+				}
+				b, err := plotter.NewBoxPlot(w, float64(i), groups[k][group.Key])
+				checkError(err)
+				if horiz {
+					b.Horizontal = true
+				}
+				p.Add(b)
+			}
+
+			if !horiz {
+				p.NominalX(groupNames...)
+				// p.HideX()
+			} else {
+				p.NominalY(groupNames...)
+				// p.HideY()
+			}
+			plots[k] = p
 		}
+
 		if !horiz {
 			if plotConfig.ylab == "" {
 				if len(headerRow) > 0 {
-					plotConfig.ylab = headerRow[0]
+					plotConfig.ylab = valueAxis.Label(headerRow[0])
 				} else {
-					plotConfig.ylab = "Values"
+					plotConfig.ylab = valueAxis.Label("Values")
 				}
 			}
 			if plotConfig.xlab == "" {
@@ -182,25 +220,18 @@ Notes:
 			}
 		} else {
 			if plotConfig.xlab == "" {
-				plotConfig.xlab = "Values"
+				plotConfig.xlab = valueAxis.Label("Values")
 			}
 			if plotConfig.ylab == "" && plotConfig.groupFieldStr != "" && len(headerRow) > 0 {
 				plotConfig.ylab = headerRow[0]
 			}
 		}
 
-		p.Title.Text = plotConfig.title
-		p.Title.TextStyle.Font.Size = plotConfig.titleSize
-		p.X.Label.Text = plotConfig.xlab
-		p.Y.Label.Text = plotConfig.ylab
-		p.X.Label.TextStyle.Font.Size = plotConfig.labelSize
-		p.Y.Label.TextStyle.Font.Size = plotConfig.labelSize
-		p.X.Width = plotConfig.axisWidth
-		p.Y.Width = plotConfig.axisWidth
-		p.X.Tick.Width = plotConfig.tickWidth
-		p.Y.Tick.Width = plotConfig.tickWidth
-		p.X.Tick.Label.Font.Size = plotConfig.tickLabelSize
-		p.Y.Tick.Label.Font.Size = plotConfig.tickLabelSize
+		for _, p := range plots {
+			if p != nil {
+				plotConfig.applyStyle(p)
+			}
+		}
 
 		if plotConfig.xminStr != "" {
 			log.Warning("flag --x-min ignored for command box")
@@ -215,19 +246,24 @@ Notes:
 			log.Warning("flag --y-max ignored for command box")
 		}
 
-		// Save image
-		if isStdin(config.OutFile) {
-			fh, err := p.WriterTo(plotConfig.width*vg.Inch,
-				plotConfig.height*vg.Inch,
-				plotConfig.format)
-			checkError(err)
-			_, err = fh.WriteTo(os.Stdout)
-			checkError(err)
-		} else {
-			checkError(p.Save(plotConfig.width*vg.Inch,
-				plotConfig.height*vg.Inch,
-				config.OutFile))
+		if facets.Enabled() {
+			checkError(facets.Save(plots, plotConfig, config.OutFile))
+			return
 		}
+
+		p := plots[0]
+		if p == nil {
+			p = plotConfig.newPlot()
+		}
+		plotConfig.applyScales(p)
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
+		}
+
+		// Save image
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
 	},
 }
 
@@ -236,4 +272,8 @@ func init() {
 
 	boxCmd.Flags().Float64P("box-width", "", 0, "box width")
 	boxCmd.Flags().BoolP("horiz", "", false, "horize box plot")
+
+	boxCmd.Flags().StringP("facet", "", "", `column index or column name of facets, for plotting a sub-plot for each value`)
+	boxCmd.Flags().IntP("facet-cols", "", 0, `number of columns of sub-plots, 0 for automatic`)
+	boxCmd.Flags().StringP("facet-scales", "", "fixed", `axis ranges of sub-plots: fixed, free, free_x, or free_y`)
 }
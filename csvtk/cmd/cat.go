@@ -49,13 +49,9 @@ var catCmd = &cobra.Command{
 		flagFreq := getFlagInt(cmd, "print-freq")
 		flagTotal := getFlagInt(cmd, "total")
 
-		of := os.Stdout
+		of, err := wopenByConfig(config, outFile)
+		checkError(err)
 		defer of.Close()
-		if outFile != "-" {
-			var err error
-			of, err = os.Create(outFile)
-			checkError(err)
-		}
 		writer := bufio.NewWriterSize(of, flagBuff)
 		defer writer.Flush()
 
@@ -0,0 +1,324 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+)
+
+// column types
+const (
+	colTypeBool        = "bool"
+	colTypeInt         = "int"
+	colTypeFloat       = "float"
+	colTypeDate        = "date"
+	colTypeDatetime    = "datetime"
+	colTypeCategorical = "categorical"
+	colTypeString      = "string"
+)
+
+// colTypeClasses groups column types, used by type selectors in field syntax
+var colTypeClasses = map[string][]string{
+	"numeric":     {colTypeInt, colTypeFloat},
+	"number":      {colTypeInt, colTypeFloat},
+	"num":         {colTypeInt, colTypeFloat},
+	"int":         {colTypeInt},
+	"integer":     {colTypeInt},
+	"float":       {colTypeFloat},
+	"bool":        {colTypeBool},
+	"boolean":     {colTypeBool},
+	"date":        {colTypeDate, colTypeDatetime},
+	"datetime":    {colTypeDatetime},
+	"categorical": {colTypeCategorical},
+	"string":      {colTypeString, colTypeCategorical},
+	"str":         {colTypeString, colTypeCategorical},
+	"text":        {colTypeString, colTypeCategorical},
+}
+
+// default NA values, case ignored
+var defaultNAValues = []string{"", "NA", "N/A"}
+
+// defaultMaxCategories is the maximum number of distinct values of a categorical column
+const defaultMaxCategories = 20
+
+// maxExactDistinct is the number of distinct values counted exactly before
+// switching to HyperLogLog
+const maxExactDistinct = 1 << 16
+
+var reInteger = regexp.MustCompile(`^[\-\+]?\d[\d,]*$`)
+
+func naValuesMap(naValues []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(naValues))
+	for _, na := range naValues {
+		m[strings.ToLower(na)] = struct{}{}
+	}
+	return m
+}
+
+func isNA(s string, naMap map[string]struct{}) bool {
+	_, ok := naMap[strings.ToLower(s)]
+	return ok
+}
+
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
+
+// parseNumber parses a number, thousands separators are allowed.
+func parseNumber(s string) (float64, bool) {
+	if !reDigitals.MatchString(s) {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(removeComma(s), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// columnProfile infers the type of a column and collects some statistics,
+// values are added one by one, so it can be used for streaming data.
+type columnProfile struct {
+	Name string
+
+	naMap         map[string]struct{}
+	maxCategories int
+
+	N  int64 // number of values, including NA
+	NA int64 // number of NA values
+
+	// type candidates
+	isBool, isInt, isFloat, isTime, hasClock bool
+
+	layout        string // layout of date/datetime
+	mixedLayouts  bool
+	minNum        float64
+	maxNum        float64
+	minNumStr     string
+	maxNumStr     string
+	minTime       time.Time
+	maxTime       time.Time
+	minTimeStr    string
+	maxTimeStr    string
+	minStr        string
+	maxStr        string
+	hasNumOrTime  bool
+	distinct      *distinctCounter
+	nonNAObserved bool
+}
+
+func newColumnProfile(name string, naMap map[string]struct{}, maxCategories int) *columnProfile {
+	return &columnProfile{
+		Name:          name,
+		naMap:         naMap,
+		maxCategories: maxCategories,
+		isBool:        true,
+		isInt:         true,
+		isFloat:       true,
+		isTime:        true,
+		distinct:      newDistinctCounter(maxExactDistinct),
+	}
+}
+
+// Add adds a value.
+func (p *columnProfile) Add(v string) {
+	p.N++
+	if isNA(v, p.naMap) {
+		p.NA++
+		return
+	}
+
+	p.distinct.Add(v)
+	if !p.nonNAObserved {
+		p.nonNAObserved = true
+		p.minStr, p.maxStr = v, v
+	} else if v < p.minStr {
+		p.minStr = v
+	} else if v > p.maxStr {
+		p.maxStr = v
+	}
+
+	if p.isBool {
+		if _, ok := parseBool(v); !ok {
+			p.isBool = false
+		}
+	}
+
+	if p.isInt && !reInteger.MatchString(v) {
+		p.isInt = false
+	}
+	if p.isFloat || p.isInt {
+		if x, ok := parseNumber(v); ok {
+			if !p.hasNumOrTime {
+				p.minNum, p.maxNum, p.minNumStr, p.maxNumStr = x, x, v, v
+			} else if x < p.minNum {
+				p.minNum, p.minNumStr = x, v
+			} else if x > p.maxNum {
+				p.maxNum, p.maxNumStr = x, v
+			}
+			p.hasNumOrTime = true
+			p.isTime = false // numbers like 20240101 are not treated as dates
+			return
+		}
+		p.isInt, p.isFloat = false, false
+	}
+
+	if p.isTime {
+		layout, err := dateparse.ParseFormat(v)
+		if err != nil {
+			p.isTime = false
+			return
+		}
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			if t, err = dateparse.ParseAny(v); err != nil {
+				p.isTime = false
+				return
+			}
+		}
+		if p.layout == "" {
+			p.layout = layout
+		} else if layout != p.layout {
+			p.mixedLayouts = true
+		}
+		if strings.Contains(layout, ":") ||
+			t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 || t.Nanosecond() != 0 {
+			p.hasClock = true
+		}
+		if p.minTimeStr == "" {
+			p.minTime, p.maxTime, p.minTimeStr, p.maxTimeStr = t, t, v, v
+		} else if t.Before(p.minTime) {
+			p.minTime, p.minTimeStr = t, v
+		} else if t.After(p.maxTime) {
+			p.maxTime, p.maxTimeStr = t, v
+		}
+	}
+}
+
+// Type returns the inferred type. A column with only NA values is a string column.
+func (p *columnProfile) Type() string {
+	if !p.nonNAObserved {
+		return colTypeString
+	}
+	switch {
+	case p.isBool:
+		return colTypeBool
+	case p.isInt:
+		return colTypeInt
+	case p.isFloat:
+		return colTypeFloat
+	case p.isTime:
+		if p.hasClock {
+			return colTypeDatetime
+		}
+		return colTypeDate
+	}
+	n, exact := p.distinct.Count()
+	if exact && int(n) <= p.maxCategories && n*2 <= uint64(p.N-p.NA) {
+		return colTypeCategorical
+	}
+	return colTypeString
+}
+
+// Format returns the Go time layout of a date/datetime column,
+// "mixed" is returned if multiple layouts are detected.
+func (p *columnProfile) Format() string {
+	switch p.Type() {
+	case colTypeDate, colTypeDatetime:
+		if p.mixedLayouts {
+			return "mixed"
+		}
+		return p.layout
+	}
+	return ""
+}
+
+// Min returns the minimum value in the original format.
+func (p *columnProfile) Min() string {
+	switch p.Type() {
+	case colTypeInt, colTypeFloat:
+		return p.minNumStr
+	case colTypeDate, colTypeDatetime:
+		return p.minTimeStr
+	case colTypeBool:
+		return ""
+	}
+	return p.minStr
+}
+
+// Max returns the maximum value in the original format.
+func (p *columnProfile) Max() string {
+	switch p.Type() {
+	case colTypeInt, colTypeFloat:
+		return p.maxNumStr
+	case colTypeDate, colTypeDatetime:
+		return p.maxTimeStr
+	case colTypeBool:
+		return ""
+	}
+	return p.maxStr
+}
+
+// Distinct returns the number of distinct values and whether it's exact.
+func (p *columnProfile) Distinct() (uint64, bool) {
+	return p.distinct.Count()
+}
+
+// inferColumnTypes infers the types of all columns from the given rows.
+func inferColumnTypes(ncols int, rows [][]string, naMap map[string]struct{}) []string {
+	profiles := make([]*columnProfile, ncols)
+	for i := range profiles {
+		profiles[i] = newColumnProfile("", naMap, defaultMaxCategories)
+	}
+	for _, row := range rows {
+		for i, v := range row {
+			if i < ncols {
+				profiles[i].Add(v)
+			}
+		}
+	}
+	types := make([]string, ncols)
+	for i, p := range profiles {
+		types[i] = p.Type()
+	}
+	return types
+}
+
+// colTypeMatches checks if a column type belongs to the type class.
+func colTypeMatches(class string, t string) bool {
+	for _, t2 := range colTypeClasses[class] {
+		if t2 == t {
+			return true
+		}
+	}
+	return false
+}
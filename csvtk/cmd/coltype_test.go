@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestColumnProfile(t *testing.T) {
+	cases := []struct {
+		values   []string
+		typ      string
+		format   string
+		min, max string
+	}{
+		{[]string{"1", "-2", "1,000", "NA"}, colTypeInt, "", "-2", "1,000"},
+		{[]string{"1", "2.5", "1e-3", ""}, colTypeFloat, "", "1e-3", "2.5"},
+		{[]string{"true", "False", "TRUE"}, colTypeBool, "", "", ""},
+		{[]string{"2024-01-02", "2023-12-31", "N/A"}, colTypeDate, "2006-01-02", "2023-12-31", "2024-01-02"},
+		{[]string{"2024-01-02 10:00:00", "2023-12-31 08:30:00"}, colTypeDatetime, "2006-01-02 15:04:05", "2023-12-31 08:30:00", "2024-01-02 10:00:00"},
+		{[]string{"a", "b", "a", "b", "a"}, colTypeCategorical, "", "a", "b"},
+		{[]string{"a", "b", "c"}, colTypeString, "", "a", "c"},
+		{[]string{"1", "a", "2024-01-02"}, colTypeString, "", "1", "a"},
+		{[]string{"NA", ""}, colTypeString, "", "", ""},
+	}
+	naMap := naValuesMap(defaultNAValues)
+	for i, c := range cases {
+		p := newColumnProfile("", naMap, defaultMaxCategories)
+		for _, v := range c.values {
+			p.Add(v)
+		}
+		if p.Type() != c.typ || p.Format() != c.format || p.Min() != c.min || p.Max() != c.max {
+			t.Errorf("case %d: expected %s/%s/%s/%s, returned %s/%s/%s/%s", i,
+				c.typ, c.format, c.min, c.max, p.Type(), p.Format(), p.Min(), p.Max())
+		}
+	}
+}
+
+func TestDistinctCounter(t *testing.T) {
+	c := newDistinctCounter(100)
+	for i := 0; i < 100000; i++ {
+		c.Add(strconv.Itoa(i))
+	}
+	n, exact := c.Count()
+	if exact {
+		t.Errorf("estimation expected")
+	}
+	if n < 97000 || n > 103000 {
+		t.Errorf("bad estimation: %d", n)
+	}
+}
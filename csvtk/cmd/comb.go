@@ -54,7 +54,7 @@ var combCmd = &cobra.Command{
 		number0 := getFlagNonNegativeInt(cmd, "number")
 		ignoreCase := getFlagBool(cmd, "ignore-case")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -0,0 +1,364 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	gzip "github.com/klauspost/pgzip"
+	"github.com/ulikunitz/xz"
+)
+
+// compression formats of output files, and the suffixes
+var compressFormats = []string{"gzip", "zstd", "xz", "bz2", "none"}
+
+var compressSuffixes = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+	"xz":   ".xz",
+	"bz2":  ".bz2",
+}
+
+// ranges of compression levels
+var compressLevels = map[string][2]int{
+	"gzip": {1, 9},
+	"zstd": {1, 22},
+	"xz":   {0, 9},
+	"bz2":  {1, 9},
+}
+
+// dictionary sizes of xz presets
+var xzDictCaps = []int{256 << 10, 1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20, 8 << 20, 16 << 20, 32 << 20, 64 << 20}
+
+// compressFormatOfFile returns the compression format of an output file,
+// which is decided by the flag --out-compress or the suffix of the file.
+func compressFormatOfFile(config Config, file string) string {
+	if config.OutCompress != "" {
+		return config.OutCompress
+	}
+	file = strings.ToLower(file)
+	for format, suffix := range compressSuffixes {
+		if strings.HasSuffix(file, suffix) {
+			return format
+		}
+	}
+	return "none"
+}
+
+// trimCompressSuffix removes the suffix of compression format from a file name.
+func trimCompressSuffix(file string) string {
+	f := strings.ToLower(file)
+	for _, suffix := range compressSuffixes {
+		if strings.HasSuffix(f, suffix) {
+			return file[:len(file)-len(suffix)]
+		}
+	}
+	return file
+}
+
+// outWriter is a buffered writer of plain or compressed output,
+// like xopen.Writer, but the compression format, level and the number of
+// threads are decided by the global flags.
+type outWriter struct {
+	*bufio.Writer
+	fh *os.File
+	cw io.WriteCloser // compressor
+}
+
+// wopenByConfig opens a file for writing, "-" for stdout.
+func wopenByConfig(config Config, file string) (*outWriter, error) {
+	return wopenFileByConfig(config, file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// wopenFileByConfig opens a file for writing with the given flag and permission.
+// If the file is compressed and opened for appending, a new compressed stream
+// is appended, which is supported by all the formats.
+func wopenFileByConfig(config Config, file string, flag int, perm os.FileMode) (*outWriter, error) {
+	format := compressFormatOfFile(config, file)
+	level, err := compressLevel(format, config.CompressLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var fh *os.File
+	if isStdin(file) {
+		fh = os.Stdout
+	} else {
+		dir := filepath.Dir(file)
+		fi, err := os.Stat(dir)
+		if err == nil && !fi.IsDir() {
+			return nil, fmt.Errorf("can not write file into a non-directory path: %s", dir)
+		}
+		if os.IsNotExist(err) {
+			os.MkdirAll(dir, 0755)
+		}
+		fh, err = os.OpenFile(file, flag, perm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cw, err := newCompressor(fh, format, level, config.NumCPUs)
+	if err != nil {
+		if fh != os.Stdout {
+			fh.Close()
+		}
+		return nil, err
+	}
+
+	w := &outWriter{fh: fh, cw: cw}
+	if cw == nil {
+		w.Writer = bufio.NewWriterSize(fh, 65536)
+	} else {
+		w.Writer = bufio.NewWriterSize(cw, 65536)
+	}
+	return w, nil
+}
+
+// compressLevel checks the compression level of a format,
+// -1 is returned for the default level.
+func compressLevel(format string, level int) (int, error) {
+	r, ok := compressLevels[format]
+	if !ok || level == -1 {
+		return -1, nil
+	}
+	if level < r[0] || level > r[1] {
+		return -1, fmt.Errorf("invalid compression level for %s: %d, available range: %d-%d", format, level, r[0], r[1])
+	}
+	return level, nil
+}
+
+// newCompressor returns a compressor, nil for plain output.
+// gzip and zstd are compressed with multiple threads by the libraries,
+// while xz and bz2 are compressed in blocks in parallel.
+func newCompressor(w io.Writer, format string, level int, threads int) (io.WriteCloser, error) {
+	if threads < 1 {
+		threads = 1
+	}
+	switch format {
+	case "gzip":
+		if level == -1 {
+			level = gzip.DefaultCompression
+		}
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		if err = gw.SetConcurrency(1<<20, threads); err != nil {
+			return nil, err
+		}
+		return gw, nil
+	case "zstd":
+		elevel := zstd.SpeedDefault
+		if level != -1 {
+			elevel = zstd.EncoderLevelFromZstd(level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(elevel), zstd.WithEncoderConcurrency(threads))
+	case "xz":
+		if level == -1 {
+			level = 6
+		}
+		dictCap := xzDictCaps[level]
+		newXz := func(w io.Writer) (io.WriteCloser, error) {
+			return xz.WriterConfig{DictCap: dictCap}.NewWriter(w)
+		}
+		if threads == 1 {
+			return newXz(w)
+		}
+		blockSize := dictCap
+		if blockSize < 1<<20 {
+			blockSize = 1 << 20
+		}
+		return newBlockCompressor(w, newXz, blockSize, threads), nil
+	case "bz2":
+		if level == -1 {
+			level = bzip2.DefaultCompression
+		}
+		newBz2 := func(w io.Writer) (io.WriteCloser, error) {
+			return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: level})
+		}
+		if threads == 1 {
+			return newBz2(w)
+		}
+		return newBlockCompressor(w, newBz2, level*100000*4, threads), nil
+	}
+	return nil, nil
+}
+
+// Flush writes buffered data to the file.
+func (w *outWriter) Flush() error {
+	if err := w.Writer.Flush(); err != nil {
+		return err
+	}
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close flushes data and closes the file, stdout is not closed.
+func (w *outWriter) Close() error {
+	if err := w.Writer.Flush(); err != nil {
+		return err
+	}
+	if w.cw != nil {
+		if err := w.cw.Close(); err != nil {
+			return err
+		}
+	}
+	if w.fh == os.Stdout {
+		return nil
+	}
+	return w.fh.Close()
+}
+
+// blockCompressor splits data into blocks, and compresses them into
+// independent streams in parallel, which are concatenated in order.
+type blockCompressor struct {
+	w         io.Writer
+	newWriter func(io.Writer) (io.WriteCloser, error)
+	blockSize int
+	threads   int
+
+	buf     []byte
+	pending []chan compressedBlock // blocks being compressed, in order
+	streams int                    // number of compressed streams
+	err     error
+}
+
+type compressedBlock struct {
+	data []byte
+	err  error
+}
+
+func newBlockCompressor(w io.Writer, newWriter func(io.Writer) (io.WriteCloser, error), blockSize int, threads int) *blockCompressor {
+	return &blockCompressor{
+		w:         w,
+		newWriter: newWriter,
+		blockSize: blockSize,
+		threads:   threads,
+		buf:       make([]byte, 0, blockSize),
+	}
+}
+
+func (c *blockCompressor) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	var n, m int
+	for len(p) > 0 {
+		m = c.blockSize - len(c.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		c.buf = append(c.buf, p[:m]...)
+		p = p[m:]
+		n += m
+		if len(c.buf) == c.blockSize {
+			if err := c.compress(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// compress starts compressing the buffered block,
+// and writes finished blocks if too many are pending.
+func (c *blockCompressor) compress() error {
+	if len(c.buf) > 0 {
+		c.streams++
+		ch := make(chan compressedBlock, 1)
+		c.pending = append(c.pending, ch)
+		go func(data []byte) {
+			var out bytes.Buffer
+			cw, err := c.newWriter(&out)
+			if err == nil {
+				if _, err = cw.Write(data); err == nil {
+					err = cw.Close()
+				}
+			}
+			ch <- compressedBlock{data: out.Bytes(), err: err}
+		}(c.buf)
+		c.buf = make([]byte, 0, c.blockSize)
+	}
+
+	for len(c.pending) >= c.threads {
+		if err := c.writeBlock(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *blockCompressor) writeBlock() error {
+	b := <-c.pending[0]
+	c.pending = c.pending[1:]
+	if b.err != nil {
+		c.err = b.err
+		return b.err
+	}
+	if _, err := c.w.Write(b.data); err != nil {
+		c.err = err
+		return err
+	}
+	return nil
+}
+
+// Flush compresses and writes all buffered data.
+func (c *blockCompressor) Flush() error {
+	if c.err != nil {
+		return c.err
+	}
+	if err := c.compress(); err != nil {
+		return err
+	}
+	for len(c.pending) > 0 {
+		if err := c.writeBlock(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes data, the underlying writer is not closed.
+// An empty stream is written if there's no data at all.
+func (c *blockCompressor) Close() error {
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	if c.streams == 0 {
+		cw, err := c.newWriter(c.w)
+		if err != nil {
+			return err
+		}
+		return cw.Close()
+	}
+	return nil
+}
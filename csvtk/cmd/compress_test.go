@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/ulikunitz/xz"
+)
+
+func TestBlockCompressor(t *testing.T) {
+	var data bytes.Buffer
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i, i*i)
+	}
+
+	newReaders := map[string]func(io.Reader) (io.Reader, error){
+		"xz": func(r io.Reader) (io.Reader, error) { return xz.NewReader(r) },
+		"bz2": func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r, &bzip2.ReaderConfig{})
+		},
+	}
+	newWriters := map[string]func(io.Writer) (io.WriteCloser, error){
+		"xz": func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+		"bz2": func(w io.Writer) (io.WriteCloser, error) {
+			return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: 1})
+		},
+	}
+
+	for format, newWriter := range newWriters {
+		var out bytes.Buffer
+		c := newBlockCompressor(&out, newWriter, 4096, 3)
+
+		// written in pieces of different sizes, with a flush in the middle
+		b := data.Bytes()
+		for i, n := 0, 1; i < len(b); i, n = i+n, n*2 {
+			if i+n > len(b) {
+				n = len(b) - i
+			}
+			if _, err := c.Write(b[i : i+n]); err != nil {
+				t.Fatalf("%s: %s", format, err)
+			}
+			if n == 1024 {
+				if err := c.Flush(); err != nil {
+					t.Fatalf("%s: %s", format, err)
+				}
+			}
+		}
+		if err := c.Close(); err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if c.streams < 2 {
+			t.Errorf("%s: expected multiple streams, returned %d", format, c.streams)
+		}
+
+		r, err := newReaders[format](&out)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		result, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if !bytes.Equal(result, b) {
+			t.Errorf("%s: decompressed data differs, %d bytes vs %d bytes", format, len(result), len(b))
+		}
+	}
+}
+
+func TestCompressLevel(t *testing.T) {
+	tests := []struct {
+		format string
+		level  int
+		expect int
+		err    bool
+	}{
+		{"gzip", -1, -1, false},
+		{"gzip", 9, 9, false},
+		{"gzip", 10, -1, true},
+		{"zstd", 19, 19, false},
+		{"xz", 0, 0, false},
+		{"bz2", 0, -1, true},
+		{"none", 5, -1, false},
+	}
+	for _, test := range tests {
+		level, err := compressLevel(test.format, test.level)
+		if (err != nil) != test.err || level != test.expect {
+			t.Errorf("%s level %d: expected %d (error: %v), returned %d (%v)",
+				test.format, test.level, test.expect, test.err, level, err)
+		}
+	}
+}
@@ -49,7 +49,7 @@ so only columns match that of the first files kept.
 		keepUnmatched := getFlagBool(cmd, "keep-unmatched")
 		UnmatchedRepl := getFlagString(cmd, "unmatched-repl")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
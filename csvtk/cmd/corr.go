@@ -26,11 +26,16 @@ import (
 	"math"
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
 	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
 )
 
 // corrCmd represents the corr command
@@ -38,8 +43,40 @@ var corrCmd = &cobra.Command{
 	GroupID: "info",
 
 	Use:   "corr",
-	Short: "calculate Pearson correlation between two columns",
-	Long:  "calculate Pearson correlation between two columns",
+	Short: "calculate correlation or covariance between columns",
+	Long: `calculate correlation or covariance between columns
+
+Correlations of all pairs of the selected fields are computed.
+
+Methods:
+  pearson    Pearson product-moment correlation coefficient
+  spearman   Spearman's rank correlation coefficient, ties get average ranks
+  kendall    Kendall's tau-b
+  cov        sample covariance
+
+Handling of missing or non-numeric values (flag --use):
+  everything  NaN is returned if any value of the two columns is NaN
+  complete    only rows where all the selected fields are numeric are used
+  pairwise    for each pair of columns, rows where both values are numeric are used.
+              Flag -i/--ignore_nan is an alias of "--use pairwise".
+
+Output formats:
+  long   one row per pair: field1, field2, value, and n (number of values used,
+         with --table), and a column "pvalue" with the flag -p/--pvalue
+  wide   a square matrix
+
+P-values are two-sided, computed from the t-distribution for pearson and spearman,
+and the normal approximation for kendall. They are not available for cov.
+
+By default, results are written to stderr as tab-separated values without
+a header row, and the long format has no column "n", as in previous versions.
+Use the flag --table to write a table with a header row to the output
+(stdout or -o/--out-file) instead.
+
+In the passthrough mode (-x/--pass), the input is forwarded to the output,
+and the results are always written to stderr.
+
+`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		config := getConfigs(cmd)
@@ -51,7 +88,51 @@ var corrCmd = &cobra.Command{
 		printPass := getFlagBool(cmd, "pass")
 		printLog := getFlagBool(cmd, "log")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		method := strings.ToLower(getFlagString(cmd, "method"))
+		switch method {
+		case "pearson", "spearman", "kendall", "cov":
+		default:
+			checkError(fmt.Errorf("invalid value of flag -m/--method: %s, available: pearson, spearman, kendall, cov", method))
+		}
+
+		use := strings.ToLower(getFlagString(cmd, "use"))
+		switch use {
+		case "everything", "complete", "pairwise":
+		default:
+			checkError(fmt.Errorf("invalid value of flag --use: %s, available: everything, complete, pairwise", use))
+		}
+		if printIgnore {
+			if cmd.Flags().Changed("use") && use != "pairwise" {
+				checkError(fmt.Errorf("flag -i/--ignore_nan conflicts with --use %s", use))
+			}
+			use = "pairwise"
+		}
+
+		format := strings.ToLower(getFlagString(cmd, "format"))
+		switch format {
+		case "long", "wide":
+		default:
+			checkError(fmt.Errorf("invalid value of flag --format: %s, available: long, wide", format))
+		}
+
+		printPvalue := getFlagBool(cmd, "pvalue")
+		if printPvalue {
+			if method == "cov" {
+				log.Warningf("p-values are not available for covariance, flag -p/--pvalue ignored")
+				printPvalue = false
+			} else if format == "wide" {
+				log.Warningf("p-values are only reported in the long format, flag -p/--pvalue ignored")
+				printPvalue = false
+			}
+		}
+
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+
+		heatmapFile := getFlagString(cmd, "heatmap")
+		table := getFlagBool(cmd, "table")
+
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -147,27 +228,108 @@ var corrCmd = &cobra.Command{
 
 		readerReport(&config, csvReader, file)
 
-		for col1, field1 := range fields {
-			for col2, field2 := range fields {
-				if col1 >= col2 {
-					continue
-				}
+		if len(fields) < 2 {
+			checkError(fmt.Errorf("at least two fields are needed"))
+		}
+
+		names := make([]string, len(fields))
+		for i, f = range fields {
+			if hasHeaderRow {
+				names[i] = HeaderRow[f-1]
+			} else {
+				names[i] = strconv.Itoa(f)
+			}
+		}
+
+		if use == "complete" {
+			data = removeIncompleteRows(data)
+		}
 
-				d1, d2 := data[col1], data[col2]
-				if printIgnore {
+		// compute all pairs
+		nf := len(fields)
+		values := make([][]float64, nf)
+		pvalues := make([][]float64, nf)
+		counts := make([][]int, nf)
+		for i = range values {
+			values[i] = make([]float64, nf)
+			pvalues[i] = make([]float64, nf)
+			counts[i] = make([]int, nf)
+		}
+		var d1, d2 []float64
+		for col1 := 0; col1 < nf; col1++ {
+			for col2 := col1; col2 < nf; col2++ {
+				d1, d2 = data[col1], data[col2]
+				if use == "pairwise" {
 					d1, d2 = removeNaNs(d1, d2)
 				}
+				r := corrValue(method, d1, d2)
+				p := corrPValue(method, r, len(d1))
+				values[col1][col2], values[col2][col1] = r, r
+				pvalues[col1][col2], pvalues[col2][col1] = p, p
+				counts[col1][col2], counts[col2][col1] = len(d1), len(d1)
+			}
+		}
 
-				pearsonr := stat.Correlation(d1, d2, nil)
+		// output
+		var w *csv.Writer
+		if printPass || !table {
+			w = csv.NewWriter(os.Stderr)
+			w.Comma = '\t'
+		} else {
+			w = writer
+		}
 
-				if hasHeaderRow {
-					fmt.Fprintf(os.Stderr, "%s\t%s\t%.4f\n", HeaderRow[field1-1], HeaderRow[field2-1], pearsonr)
-				} else {
-					fmt.Fprintf(os.Stderr, "%d\t%d\t%.4f\n", field1, field2, pearsonr)
-				}
+		formatValue := func(v float64) string {
+			if math.IsNaN(v) {
+				return "NaN"
+			}
+			return fmt.Sprintf(decimalFormat, v)
+		}
 
+		switch format {
+		case "long":
+			if table && !config.NoOutHeader {
+				header := []string{"field1", "field2", method, "n"}
+				if printPvalue {
+					header = append(header, "pvalue")
+				}
+				checkError(w.Write(header))
+			}
+			row := make([]string, 0, 5)
+			for col1 := 0; col1 < nf; col1++ {
+				for col2 := col1 + 1; col2 < nf; col2++ {
+					row = row[:0]
+					row = append(row, names[col1], names[col2], formatValue(values[col1][col2]))
+					if table {
+						row = append(row, strconv.Itoa(counts[col1][col2]))
+					}
+					if printPvalue {
+						row = append(row, formatPValue(pvalues[col1][col2]))
+					}
+					checkError(w.Write(row))
+				}
+			}
+		case "wide":
+			if !config.NoOutHeader {
+				checkError(w.Write(append([]string{""}, names...)))
+			}
+			row := make([]string, nf+1)
+			for col1 := 0; col1 < nf; col1++ {
+				row[0] = names[col1]
+				for col2 := 0; col2 < nf; col2++ {
+					row[col2+1] = formatValue(values[col1][col2])
+				}
+				checkError(w.Write(row))
 			}
 		}
+		if w != writer {
+			w.Flush()
+			checkError(w.Error())
+		}
+
+		if heatmapFile != "" {
+			checkError(plotCorrHeatmap(config, heatmapFile, names, values, method, decimalFormat))
+		}
 	},
 }
 
@@ -186,11 +348,172 @@ func removeNaNs(d1, d2 []float64) ([]float64, []float64) {
 	return r1, r2
 }
 
+// removeIncompleteRows removes rows containing NaN in any column.
+func removeIncompleteRows(data [][]float64) [][]float64 {
+	if len(data) == 0 {
+		return data
+	}
+	result := make([][]float64, len(data))
+	for i := range data {
+		result[i] = make([]float64, 0, len(data[i]))
+	}
+	var ok bool
+	for j := range data[0] {
+		ok = true
+		for i := range data {
+			if math.IsNaN(data[i][j]) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		for i := range data {
+			result[i] = append(result[i], data[i][j])
+		}
+	}
+	return result
+}
+
+// corrValue computes the correlation or covariance between two columns.
+// NaN is returned if any value is NaN.
+func corrValue(method string, x, y []float64) float64 {
+	if len(x) < 2 {
+		return math.NaN()
+	}
+	for i, v := range x {
+		if math.IsNaN(v) || math.IsNaN(y[i]) {
+			return math.NaN()
+		}
+	}
+	switch method {
+	case "pearson":
+		return stat.Correlation(x, y, nil)
+	case "spearman":
+		return stat.Correlation(fractionalRanks(x), fractionalRanks(y), nil)
+	case "kendall":
+		return kendallTauB(x, y)
+	case "cov":
+		return stat.Covariance(x, y, nil)
+	}
+	return math.NaN()
+}
+
+// corrPValue returns the two-sided p-value of a correlation coefficient
+// computed from n pairs of values.
+func corrPValue(method string, r float64, n int) float64 {
+	if math.IsNaN(r) || n < 3 {
+		return math.NaN()
+	}
+	switch method {
+	case "pearson", "spearman":
+		if math.Abs(r) >= 1 {
+			return 0
+		}
+		df := float64(n - 2)
+		t := r * math.Sqrt(df/(1-r*r))
+		return 2 * distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}.Survival(math.Abs(t))
+	case "kendall":
+		nf := float64(n)
+		z := 3 * r * math.Sqrt(nf*(nf-1)) / math.Sqrt(2*(2*nf+5))
+		return 2 * distuv.UnitNormal.Survival(math.Abs(z))
+	}
+	return math.NaN()
+}
+
+func formatPValue(p float64) string {
+	if math.IsNaN(p) {
+		return ""
+	}
+	return strconv.FormatFloat(p, 'g', 4, 64)
+}
+
+// fractionalRanks returns 1-based ranks of values, tied values get the average rank.
+func fractionalRanks(x []float64) []float64 {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return x[idx[i]] < x[idx[j]] })
+
+	ranks := make([]float64, len(x))
+	var j int
+	var r float64
+	for i := 0; i < len(idx); i = j {
+		for j = i + 1; j < len(idx) && x[idx[j]] == x[idx[i]]; j++ {
+		}
+		r = float64(i+j+1) / 2 // average of ranks i+1, ..., j
+		for k := i; k < j; k++ {
+			ranks[idx[k]] = r
+		}
+	}
+	return ranks
+}
+
+// kendallTauB computes Kendall's tau-b, which adjusts for ties.
+func kendallTauB(x, y []float64) float64 {
+	var concordant, discordant, tiesX, tiesY float64
+	var dx, dy float64
+	for i := 0; i < len(x); i++ {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy = x[i]-x[j], y[i]-y[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				tiesX++
+			case dy == 0:
+				tiesY++
+			case (dx > 0) == (dy > 0):
+				concordant++
+			default:
+				discordant++
+			}
+		}
+	}
+	d := math.Sqrt((concordant + discordant + tiesX) * (concordant + discordant + tiesY))
+	if d == 0 {
+		return math.NaN()
+	}
+	return (concordant - discordant) / d
+}
+
+func plotCorrHeatmap(config Config, file string, names []string, values [][]float64, method string, decimalFormat string) error {
+	opt := &heatmapOptions{
+		palette:       "blue-red",
+		min:           -1,
+		max:           1,
+		annotate:      true,
+		decimalFormat: decimalFormat,
+	}
+	if method == "cov" { // symmetric around 0
+		opt.min, opt.max = math.NaN(), math.NaN()
+	}
+	p, pc, err := newHeatmapPlots(names, names, values, opt)
+	if err != nil {
+		return err
+	}
+	p.Title.Text = method
+	p.X.Tick.Label.Rotation = math.Pi / 4
+	p.X.Tick.Label.XAlign = draw.XRight
+	p.X.Tick.Label.YAlign = draw.YTop
+
+	size := vg.Length(1.5+0.8*float64(len(names))) * vg.Inch
+	return saveHeatmap(file, &plotConfigs{format: "png", global: config}, p, pc, size+vg.Inch*0.8, size)
+}
+
 func init() {
 	RootCmd.AddCommand(corrCmd)
 
 	corrCmd.Flags().StringP("fields", "f", "", "comma separated fields")
-	corrCmd.Flags().BoolP("ignore_nan", "i", false, "Ignore non-numeric fields to avoid returning NaN")
+	corrCmd.Flags().BoolP("ignore_nan", "i", false, `Ignore non-numeric fields to avoid returning NaN, i.e., "--use pairwise"`)
 	corrCmd.Flags().BoolP("log", "L", false, "Calcute correlations on Log10 transformed data")
 	corrCmd.Flags().BoolP("pass", "x", false, "passthrough mode (forward input to output)")
+	corrCmd.Flags().StringP("method", "m", "pearson", `method: pearson, spearman, kendall, or cov`)
+	corrCmd.Flags().StringP("use", "", "everything", `handling of non-numeric values: everything, complete, or pairwise`)
+	corrCmd.Flags().StringP("format", "", "long", `output format: long, or wide`)
+	corrCmd.Flags().BoolP("pvalue", "p", false, "report p-values (long format only)")
+	corrCmd.Flags().IntP("decimal-width", "w", 4, "limit floats to N decimal points")
+	corrCmd.Flags().BoolP("table", "", false, "write results as a table with a header row to the output, instead of stderr")
+	corrCmd.Flags().StringP("heatmap", "", "", "plot a heatmap of the matrix to a file, the image format is decided by the file extension, e.g., .png, .pdf, .svg")
 }
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// the example of cor.test() in R
+var corrX = []float64{44.4, 45.9, 41.9, 53.3, 44.7, 44.1, 50.7, 45.2, 60.1}
+var corrY = []float64{2.6, 3.1, 2.5, 5.0, 3.6, 4.0, 5.2, 2.8, 3.8}
+
+func TestFractionalRanks(t *testing.T) {
+	tests := []struct {
+		data     []float64
+		expected []float64
+	}{
+		{[]float64{}, []float64{}},
+		{[]float64{3, 1, 2}, []float64{3, 1, 2}},
+		{[]float64{10, 20, 20, 30, 20}, []float64{1, 3, 3, 5, 3}},
+		{[]float64{2, 2, 1, 1}, []float64{3.5, 3.5, 1.5, 1.5}},
+		{[]float64{5, 5, 5}, []float64{2, 2, 2}},
+	}
+	for i, test := range tests {
+		ranks := fractionalRanks(test.data)
+		if !reflect.DeepEqual(ranks, test.expected) {
+			t.Errorf("case %d: expected %v, returned %v", i, test.expected, ranks)
+		}
+	}
+}
+
+func TestCorrValue(t *testing.T) {
+	tests := []struct {
+		method   string
+		x, y     []float64
+		expected float64
+	}{
+		// cor(x, y, method = ...) in R
+		{"pearson", corrX, corrY, 0.5711816},
+		{"spearman", corrX, corrY, 0.6},
+		{"kendall", corrX, corrY, 0.4444444},
+		// tau-b with ties in both columns
+		{"kendall", []float64{1, 2, 2, 3, 4, 4}, []float64{1, 3, 2, 2, 4, 5}, 0.7412493},
+		{"kendall", []float64{1, 1, 1}, []float64{1, 2, 3}, math.NaN()},
+		{"pearson", []float64{1, math.NaN(), 3}, []float64{1, 2, 3}, math.NaN()},
+		{"pearson", []float64{1}, []float64{1}, math.NaN()},
+	}
+	for i, test := range tests {
+		r := corrValue(test.method, test.x, test.y)
+		if !equalFloat(r, test.expected, 1e-6) {
+			t.Errorf("case %d (%s): expected %v, returned %v", i, test.method, test.expected, r)
+		}
+	}
+}
+
+func TestCorrPValue(t *testing.T) {
+	tests := []struct {
+		method   string
+		r        float64
+		n        int
+		expected float64
+	}{
+		// cor.test(x, y, method = ..., exact = FALSE)$p.value in R
+		{"pearson", 0.5711816, 9, 0.1081731},
+		{"spearman", 0.6, 9, 0.0876228},
+		{"kendall", 0.4444444, 9, 0.0952928},
+		{"pearson", 1, 9, 0},
+		{"pearson", 0.5, 2, math.NaN()},
+		{"pearson", math.NaN(), 9, math.NaN()},
+		{"cov", 0.5, 9, math.NaN()},
+	}
+	for i, test := range tests {
+		p := corrPValue(test.method, test.r, test.n)
+		if !equalFloat(p, test.expected, 1e-6) {
+			t.Errorf("case %d (%s): expected %v, returned %v", i, test.method, test.expected, p)
+		}
+	}
+}
+
+func TestRemoveIncompleteRows(t *testing.T) {
+	nan := math.NaN()
+	// one slice per column
+	data := [][]float64{
+		{1, 2, nan, 4, 5},
+		{1, nan, 3, 4, 5},
+		{1, 2, 3, 4, nan},
+	}
+	expected := [][]float64{
+		{1, 4},
+		{1, 4},
+		{1, 4},
+	}
+	result := removeIncompleteRows(data)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, returned %v", expected, result)
+	}
+
+	if result = removeIncompleteRows([][]float64{{nan}, {1}}); len(result) != 2 || len(result[0]) != 0 || len(result[1]) != 0 {
+		t.Errorf("expected two empty columns, returned %v", result)
+	}
+}
+
+func equalFloat(a, b, eps float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) <= eps
+}
+
+func TestPlotCorrHeatmapCompressed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "corr.svg.gz")
+	config := Config{CompressLevel: -1, NumCPUs: 1}
+	values := [][]float64{{1, 0.5}, {0.5, 1}}
+	if err := plotCorrHeatmap(config, file, []string{"a", "b"}, values, "pearson", "%.2f"); err != nil {
+		t.Fatal(err)
+	}
+
+	fh, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	r, err := gzip.NewReader(fh)
+	if err != nil {
+		t.Fatalf("heatmap should be gzipped: %s", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("<svg")) {
+		t.Errorf("heatmap should be in SVG format")
+	}
+}
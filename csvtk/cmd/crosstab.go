@@ -0,0 +1,495 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/natsort"
+	"github.com/shenwei356/stable"
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+)
+
+// crosstabCmd represents the crosstab command
+var crosstabCmd = &cobra.Command{
+	GroupID: "info",
+
+	Use:   "crosstab",
+	Short: "contingency tables of two fields",
+	Long: `contingency tables of two fields
+
+Values of the row field (-r/--row-field) and the column field (-c/--col-field)
+are laid out as a two-way table, in order of appearance by default.
+
+Cell values:
+  1. Counts of rows (default).
+  2. Percentages of counts (-p/--percent):
+       row    percentages of row totals
+       col    percentages of column totals
+       total  percentages of the grand total
+  3. An aggregate of a value field (-v/--value-field) with an operation of
+     "csvtk summary" (-a/--aggregate, default: sum), e.g., sum, mean, median,
+     count, first, collapse. Percentages are only supported for sum and count.
+
+Margins (-m/--margins):
+  A column of row totals and a row of column totals are added.
+  For aggregates, margins are computed from all values of the row/column,
+  e.g., the mean of all values in a row, rather than the mean of cells.
+  For percentages, margins are percentages too.
+
+Empty cells are filled with --fill, which is "0" for counts and percentages,
+and "" for aggregates by default.
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		rowFieldStr := getFlagString(cmd, "row-field")
+		if rowFieldStr == "" {
+			checkError(fmt.Errorf("flag -r/--row-field needed"))
+		}
+		colFieldStr := getFlagString(cmd, "col-field")
+		if colFieldStr == "" {
+			checkError(fmt.Errorf("flag -c/--col-field needed"))
+		}
+		valueFieldStr := getFlagString(cmd, "value-field")
+
+		op := getFlagString(cmd, "aggregate")
+		if op != "" {
+			if valueFieldStr == "" {
+				checkError(fmt.Errorf("flag -v/--value-field needed for -a/--aggregate"))
+			}
+			_, ok1 := summaryOpFunc(op) // for numbers
+			_, ok2 := allStats2[op]     // for strings
+			if !(ok1 || ok2) {
+				checkError(fmt.Errorf(`invalid operation: %s. run "csvtk crosstab --help" for help`, op))
+			}
+		} else if valueFieldStr != "" {
+			op = "sum"
+		}
+		aggregate := valueFieldStr != ""
+		ignore := getFlagBool(cmd, "ignore-non-numbers")
+
+		percent := strings.ToLower(getFlagString(cmd, "percent"))
+		switch percent {
+		case "", "row", "col", "total":
+		default:
+			checkError(fmt.Errorf("invalid value of flag -p/--percent: %s, available: row, col, total", percent))
+		}
+		if percent != "" && aggregate {
+			switch op {
+			case "sum", "count", "countn":
+			default:
+				checkError(fmt.Errorf("percentages are only supported for aggregates of sum, count, and countn"))
+			}
+		}
+		// cells are numbers
+		numeric := !aggregate || percent != ""
+
+		margins := getFlagBool(cmd, "margins")
+		totalLabel := getFlagString(cmd, "total-label")
+		fill := getFlagString(cmd, "fill")
+		if !cmd.Flags().Changed("fill") && numeric {
+			fill = "0"
+		}
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+		sortKeys := getFlagBool(cmd, "sort-keys")
+		natSort := getFlagBool(cmd, "nat-sort")
+		pretty := getFlagBool(cmd, "pretty")
+		if !pretty && cmd.Flags().Changed("style") {
+			checkError(fmt.Errorf("flag --style needs -P/--pretty"))
+		}
+		style, err := prettyTableStyle(getFlagString(cmd, "style"), "   ")
+		checkError(err)
+
+		fieldStr := rowFieldStr + "," + colFieldStr
+		if aggregate {
+			fieldStr += "," + valueFieldStr
+		}
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+		defer outfh.Close()
+
+		file := files[0]
+
+		csvReader, err := newCSVReaderByConfig(config, file)
+
+		if err != nil {
+			if err == xopen.ErrNoContent {
+				if config.Verbose {
+					log.Warningf("csvtk crosstab: skipping empty input file: %s", file)
+				}
+				return
+			}
+			checkError(err)
+		}
+
+		csvReader.Read(ReadOption{
+			FieldStr: fieldStr,
+
+			DoNotAllowDuplicatedColumnName: true,
+		})
+
+		var fieldR, fieldC, fieldV int
+		var nameR string
+		rowKeys := newCategoryOrder()
+		colKeys := newCategoryOrder()
+		cells := make(map[[2]string][]string)
+		counts := make(map[[2]string]float64)
+		rowValues := make(map[string][]string)
+		colValues := make(map[string][]string)
+		var allValues []string
+
+		var r, c, v string
+		var key [2]string
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				if (aggregate && len(record.Fields) != 3) || (!aggregate && len(record.Fields) != 2) {
+					checkError(fmt.Errorf("each of the row field, column field and value field should match exactly one column"))
+				}
+				fieldR, fieldC = record.Fields[0], record.Fields[1]
+				if aggregate {
+					fieldV = record.Fields[2]
+				}
+
+				if !config.NoHeaderRow || record.IsHeaderRow { // do not replace head line
+					nameR = record.All[fieldR-1]
+					continue
+				}
+				nameR = strconv.Itoa(fieldR)
+			}
+
+			r, c = record.All[fieldR-1], record.All[fieldC-1]
+			rowKeys.Add(r)
+			colKeys.Add(c)
+			key = [2]string{r, c}
+			if !aggregate {
+				counts[key]++
+				continue
+			}
+			v = record.All[fieldV-1]
+			cells[key] = append(cells[key], v)
+			if margins {
+				rowValues[r] = append(rowValues[r], v)
+				colValues[c] = append(colValues[c], v)
+				allValues = append(allValues, v)
+			}
+		}
+
+		readerReport(&config, csvReader, file)
+
+		rows := rowKeys.Keys()
+		cols := colKeys.Keys()
+		for _, keys := range [][]string{rows, cols} {
+			if natSort {
+				natsort.Sort(keys)
+			} else if sortKeys {
+				sort.Strings(keys)
+			}
+		}
+
+		tab := &crosstab{
+			rows:      rows,
+			cols:      cols,
+			cells:     cells,
+			counts:    counts,
+			rowValues: rowValues,
+			colValues: colValues,
+			allValues: allValues,
+		}
+		records, err := tab.records(op, ignore, percent, margins, fill, decimalFormat, totalLabel)
+		checkError(err)
+
+		// output
+		header := make([]string, 0, len(cols)+2)
+		header = append(header, nameR)
+		header = append(header, cols...)
+		if margins {
+			header = append(header, totalLabel)
+		}
+
+		if pretty {
+			tbl := stable.New()
+			columns := make([]stable.Column, len(header))
+			for i, h := range header {
+				columns[i] = stable.Column{Header: h}
+				if i > 0 {
+					columns[i].Align = stable.AlignRight
+				}
+			}
+			_, err = tbl.HeaderWithFormat(columns)
+			checkError(err)
+			for _, record := range records {
+				checkError(tbl.AddRowStringSlice(record))
+			}
+			outfh.Write(tbl.Render(style))
+			return
+		}
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+		defer func() {
+			writer.Flush()
+			checkError(writer.Error())
+		}()
+
+		if !config.NoOutHeader {
+			checkError(writer.Write(header))
+		}
+		for _, record := range records {
+			checkError(writer.Write(record))
+		}
+	},
+}
+
+// crosstab holds the counts or values of each cell of a contingency table.
+type crosstab struct {
+	rows []string
+	cols []string
+
+	counts map[[2]string]float64  // counts of cells
+	cells  map[[2]string][]string // values of cells, for aggregates
+
+	// values of rows, columns and all cells, for margins of aggregates
+	rowValues map[string][]string
+	colValues map[string][]string
+	allValues []string
+}
+
+// records returns rows of the contingency table, with a column of row totals
+// and a row of column totals if margins is true.
+// Values are aggregated with op, or counted if op is empty.
+func (t *crosstab) records(op string, ignore bool, percent string, margins bool,
+	fill string, decimalFormat string, totalLabel string) ([][]string, error) {
+	aggregate := op != ""
+	numeric := !aggregate || percent != ""
+	rows, cols := t.rows, t.cols
+	cells := t.cells
+	var key [2]string
+	var err error
+
+	// cell values as strings, and margins
+	table := make([][]string, len(rows))
+	rowMargins := make([]string, len(rows))
+	colMargins := make([]string, len(cols))
+	var grandMargin string
+
+	if numeric {
+		var x float64
+		counts := t.counts
+		if aggregate {
+			counts = make(map[[2]string]float64, len(cells))
+			for k, vals := range cells {
+				s, err := aggregateValues(op, vals, ignore, "%v")
+				if err != nil {
+					return nil, err
+				}
+				x, _ = strconv.ParseFloat(s, 64)
+				counts[k] = x
+			}
+		}
+
+		rowSums := make([]float64, len(rows))
+		colSums := make([]float64, len(cols))
+		var total float64
+		for i, r := range rows {
+			for j, c := range cols {
+				x = counts[[2]string{r, c}]
+				rowSums[i] += x
+				colSums[j] += x
+				total += x
+			}
+		}
+
+		format := func(x float64) string {
+			if percent == "" {
+				return strconv.FormatFloat(x, 'f', -1, 64)
+			}
+			return fmt.Sprintf(decimalFormat, x)
+		}
+		ratio := func(x, y float64) float64 {
+			if y == 0 {
+				return 0
+			}
+			return x / y * 100
+		}
+
+		var ok bool
+		for i, r := range rows {
+			table[i] = make([]string, len(cols))
+			for j, c := range cols {
+				key = [2]string{r, c}
+				if aggregate {
+					_, ok = cells[key]
+				} else {
+					_, ok = counts[key]
+				}
+				if !ok {
+					table[i][j] = fill
+					continue
+				}
+				x = counts[key]
+				switch percent {
+				case "row":
+					x = ratio(x, rowSums[i])
+				case "col":
+					x = ratio(x, colSums[j])
+				case "total":
+					x = ratio(x, total)
+				}
+				table[i][j] = format(x)
+			}
+		}
+
+		if margins {
+			for i := range rows {
+				switch percent {
+				case "":
+					x = rowSums[i]
+				case "row":
+					x = ratio(rowSums[i], rowSums[i])
+				default:
+					x = ratio(rowSums[i], total)
+				}
+				rowMargins[i] = format(x)
+			}
+			for j := range cols {
+				switch percent {
+				case "":
+					x = colSums[j]
+				case "col":
+					x = ratio(colSums[j], colSums[j])
+				default:
+					x = ratio(colSums[j], total)
+				}
+				colMargins[j] = format(x)
+			}
+			if percent == "" {
+				grandMargin = format(total)
+			} else {
+				grandMargin = format(ratio(total, total))
+			}
+		}
+	} else {
+		var vals []string
+		var ok bool
+		for i, r := range rows {
+			table[i] = make([]string, len(cols))
+			for j, c := range cols {
+				if vals, ok = cells[[2]string{r, c}]; !ok {
+					table[i][j] = fill
+					continue
+				}
+				table[i][j], err = aggregateValues(op, vals, ignore, decimalFormat)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if margins {
+			for i, r := range rows {
+				rowMargins[i], err = aggregateValues(op, t.rowValues[r], ignore, decimalFormat)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for j, c := range cols {
+				colMargins[j], err = aggregateValues(op, t.colValues[c], ignore, decimalFormat)
+				if err != nil {
+					return nil, err
+				}
+			}
+			grandMargin, err = aggregateValues(op, t.allValues, ignore, decimalFormat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	records := make([][]string, 0, len(rows)+1)
+	for i, r := range rows {
+		record := make([]string, 0, len(cols)+2)
+		record = append(record, r)
+		record = append(record, table[i]...)
+		if margins {
+			record = append(record, rowMargins[i])
+		}
+		records = append(records, record)
+	}
+	if margins {
+		record := make([]string, 0, len(cols)+2)
+		record = append(record, totalLabel)
+		record = append(record, colMargins...)
+		record = append(record, grandMargin)
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func init() {
+	RootCmd.AddCommand(crosstabCmd)
+
+	crosstabCmd.Flags().StringP("row-field", "r", "", "field of row keys")
+	crosstabCmd.Flags().StringP("col-field", "c", "", "field of column keys")
+	crosstabCmd.Flags().StringP("value-field", "v", "", "field of values to aggregate")
+	crosstabCmd.Flags().StringP("aggregate", "a", "", `aggregate values with an operation of "csvtk summary", e.g., sum, mean, count, median, first (default "sum" when -v/--value-field is given)`)
+	crosstabCmd.Flags().BoolP("ignore-non-numbers", "i", false, `ignore non-numeric values like "NA" or "N/A" for numeric operations`)
+	crosstabCmd.Flags().StringP("percent", "p", "", "output percentages: row, col, or total")
+	crosstabCmd.Flags().BoolP("margins", "m", false, "add margin totals")
+	crosstabCmd.Flags().StringP("total-label", "", "Total", "label of margin totals")
+	crosstabCmd.Flags().StringP("fill", "", "", `content for filling empty cells (default "0" for counts and percentages, "" for aggregates)`)
+	crosstabCmd.Flags().IntP("decimal-width", "w", 2, "limit floats to N decimal points")
+	crosstabCmd.Flags().BoolP("sort-keys", "", false, "sort keys in alphabetical order, rather than the order of appearance")
+	crosstabCmd.Flags().BoolP("nat-sort", "S", false, "sort keys in natural order")
+	crosstabCmd.Flags().BoolP("pretty", "P", false, "output an aligned table rather than CSV")
+	crosstabCmd.Flags().StringP("style", "", "", "style of the aligned table, for -P/--pretty. available vaules: default, plain, simple, 3line, grid, light, bold, double")
+}
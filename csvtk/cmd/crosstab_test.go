@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCrosstabRecords(t *testing.T) {
+	// g,s,v: a,x,1  a,y,2  b,x,3  a,x,NA  c,y,4  b,y,5
+	counts := map[[2]string]float64{
+		{"a", "x"}: 2, {"a", "y"}: 1,
+		{"b", "x"}: 1, {"b", "y"}: 1,
+		{"c", "y"}: 1,
+	}
+	cells := map[[2]string][]string{
+		{"a", "x"}: {"1", "NA"}, {"a", "y"}: {"2"},
+		{"b", "x"}: {"3"}, {"b", "y"}: {"5"},
+		{"c", "y"}: {"4"},
+	}
+	tab := &crosstab{
+		rows:      []string{"a", "b", "c"},
+		cols:      []string{"x", "y"},
+		counts:    counts,
+		cells:     cells,
+		rowValues: map[string][]string{"a": {"1", "2", "NA"}, "b": {"3", "5"}, "c": {"4"}},
+		colValues: map[string][]string{"x": {"1", "3", "NA"}, "y": {"2", "4", "5"}},
+		allValues: []string{"1", "2", "3", "NA", "4", "5"},
+	}
+
+	tests := []struct {
+		op       string
+		percent  string
+		margins  bool
+		fill     string
+		expected [][]string
+	}{
+		{"", "", false, "0", [][]string{
+			{"a", "2", "1"},
+			{"b", "1", "1"},
+			{"c", "0", "1"},
+		}},
+		{"", "", true, "-", [][]string{
+			{"a", "2", "1", "3"},
+			{"b", "1", "1", "2"},
+			{"c", "-", "1", "1"},
+			{"Total", "3", "3", "6"},
+		}},
+		{"", "row", true, "0", [][]string{
+			{"a", "66.67", "33.33", "100.00"},
+			{"b", "50.00", "50.00", "100.00"},
+			{"c", "0", "100.00", "100.00"},
+			{"Total", "50.00", "50.00", "100.00"},
+		}},
+		{"", "col", true, "0", [][]string{
+			{"a", "66.67", "33.33", "50.00"},
+			{"b", "33.33", "33.33", "33.33"},
+			{"c", "0", "33.33", "16.67"},
+			{"Total", "100.00", "100.00", "100.00"},
+		}},
+		{"", "total", true, "0", [][]string{
+			{"a", "33.33", "16.67", "50.00"},
+			{"b", "16.67", "16.67", "33.33"},
+			{"c", "0", "16.67", "16.67"},
+			{"Total", "50.00", "50.00", "100.00"},
+		}},
+		{"sum", "", true, "", [][]string{
+			{"a", "1.00", "2.00", "3.00"},
+			{"b", "3.00", "5.00", "8.00"},
+			{"c", "", "4.00", "4.00"},
+			{"Total", "4.00", "11.00", "15.00"},
+		}},
+		{"sum", "row", true, "0", [][]string{
+			{"a", "33.33", "66.67", "100.00"},
+			{"b", "37.50", "62.50", "100.00"},
+			{"c", "0", "100.00", "100.00"},
+			{"Total", "26.67", "73.33", "100.00"},
+		}},
+		// margins are computed from all values of the row/column
+		{"mean", "", true, "", [][]string{
+			{"a", "1.00", "2.00", "1.50"},
+			{"b", "3.00", "5.00", "4.00"},
+			{"c", "", "4.00", "4.00"},
+			{"Total", "2.00", "3.67", "3.00"},
+		}},
+	}
+	for i, test := range tests {
+		records, err := tab.records(test.op, true, test.percent, test.margins, test.fill, "%.2f", "Total")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(records, test.expected) {
+			t.Errorf("case %d (op: %q, percent: %q): expected %q, returned %q",
+				i, test.op, test.percent, test.expected, records)
+		}
+	}
+
+	// non-numeric values are not allowed without ignore
+	if _, err := tab.records("mean", false, "", true, "", "%.2f", "Total"); err == nil {
+		t.Errorf("non-numeric values should cause an error")
+	}
+}
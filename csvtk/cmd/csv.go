@@ -43,6 +43,8 @@ type Record struct {
 	All      []string
 	Fields   []int    // selected fields
 	Selected []string // selected columns
+
+	SelectorFields [][]int // fields matched by each extended field selector
 }
 
 // CSVReader is
@@ -62,6 +64,7 @@ type CSVReader struct {
 	NumEmptyRows     []int // rows of emtpy rows
 	NumIllegalRows   []int // rows of illegal rows
 
+	follow *followReader // for --follow
 }
 
 // NewCSVReader is
@@ -75,6 +78,10 @@ func NewCSVReader(file string) (*CSVReader, error) {
 		return nil, err
 	}
 
+	return newCSVReader(file, fh), nil
+}
+
+func newCSVReader(file string, fh *xopen.Reader) *CSVReader {
 	reader := csv.NewReader(fh)
 
 	ch := make(chan Record, 128)
@@ -87,7 +94,7 @@ func NewCSVReader(file string) (*CSVReader, error) {
 		NumEmptyRows:   make([]int, 0, 128),
 		NumIllegalRows: make([]int, 0, 128),
 	}
-	return csvReader, nil
+	return csvReader
 }
 
 type ReadOption struct {
@@ -127,8 +134,23 @@ func (csvReader *CSVReader) Read(opt ReadOption) {
 			csvReader.fh.Close()
 		}()
 
-		fields, colnames, negativeFields, needParseHeaderRow, x2ends := parseFields(fieldStr, fieldStrSep, csvReader.NoHeaderRow, opt.Verbose)
+		selectors, extendedFields, err := parseFieldSelectors(fieldStr, fieldStrSep, ignoreFieldCase)
+		checkError(err)
+
+		var fields []int
+		var colnames []string
+		var negativeFields, needParseHeaderRow bool
+		var x2ends map[int]int
+		if extendedFields {
+			if csvReader.NoHeaderRow && opt.Verbose {
+				log.Warningf("colnames detected, flag -H (--no-header-row) ignored")
+			}
+			needParseHeaderRow = true
+		} else {
+			fields, colnames, negativeFields, needParseHeaderRow, x2ends = parseFields(fieldStr, fieldStrSep, csvReader.NoHeaderRow, opt.Verbose)
+		}
 		var fieldsMap map[int]struct{}
+		var selectorFields [][]int
 
 		selectWithColnames := len(fields) == 0
 
@@ -161,11 +183,38 @@ func (csvReader *CSVReader) Read(opt ReadOption) {
 		ignoreEmptyRow := csvReader.IgnoreEmptyRow
 
 		var record []string
-		var err error
 		var isHeaderRow bool
 
+		// rows read in advance for guessing column types
+		var prefetched []prefetchedRecord
+		var iPrefetched int
+		if extendedFields && hasTypeSelector(selectors) {
+			nSample := defaultTypeSampleRows
+			prefetched = make([]prefetchedRecord, 0, nSample+1)
+			for len(prefetched) <= nSample { // the header row and sampled rows
+				// when following the file, only rows already written are sampled,
+				// after waiting for the header row and the first data row.
+				if csvReader.follow != nil && len(prefetched) == 2 {
+					csvReader.follow.StopAtEnd(true)
+				}
+				record, err = csvReader.Reader.Read()
+				if err == io.EOF {
+					break
+				}
+				prefetched = append(prefetched, prefetchedRecord{record: record, err: err})
+			}
+			if csvReader.follow != nil {
+				csvReader.follow.StopAtEnd(false)
+			}
+		}
+
 		for {
-			record, err = csvReader.Reader.Read()
+			if iPrefetched < len(prefetched) {
+				record, err = prefetched[iPrefetched].record, prefetched[iPrefetched].err
+				iPrefetched++
+			} else {
+				record, err = csvReader.Reader.Read()
+			}
 			if err == io.EOF {
 				break
 			}
@@ -207,7 +256,16 @@ func (csvReader *CSVReader) Read(opt ReadOption) {
 			if parseHeaderRow { // parsing header row
 				isHeaderRow = true
 
-				if len(fields) == 0 { // user gives the colnames
+				if extendedFields {
+					sample := make([][]string, 0, len(prefetched))
+					for _, r := range prefetched[iPrefetched:] {
+						if r.err == nil && r.record != nil {
+							sample = append(sample, r.record)
+						}
+					}
+					fields, selectorFields, err = resolveFieldSelectors(selectors, record, sample, &opt, csvReader.file)
+					checkError(err)
+				} else if len(fields) == 0 { // user gives the colnames
 					// colnames
 					colnames2fileds = make(map[string][]int, len(record))
 					for i, col = range record {
@@ -481,6 +539,7 @@ func (csvReader *CSVReader) Read(opt ReadOption) {
 
 					IsHeaderRow:        isHeaderRow,
 					SelectWithColnames: selectWithColnames,
+					SelectorFields:     selectorFields,
 				}
 
 				continue
@@ -507,6 +566,7 @@ func (csvReader *CSVReader) Read(opt ReadOption) {
 
 				IsHeaderRow:        isHeaderRow,
 				SelectWithColnames: selectWithColnames,
+				SelectorFields:     selectorFields,
 			}
 		}
 
@@ -514,6 +574,11 @@ func (csvReader *CSVReader) Read(opt ReadOption) {
 	}()
 }
 
+type prefetchedRecord struct {
+	record []string
+	err    error
+}
+
 func parseFields(
 	fieldsStr string,
 	fieldsStrSep string,
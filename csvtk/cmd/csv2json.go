@@ -22,6 +22,7 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -38,6 +39,14 @@ var csv2jsonCmd = &cobra.Command{
 	Short: "convert CSV to JSON format",
 	Long: `convert CSV to JSON format
 
+Attention:
+
+  1. Values of "true" and "false" (case ignored) are converted to booleans.
+  2. Numeric values are only parsed in columns given by -n/--parse-num,
+     and inferred as int or float from the first 1000 data rows,
+     with the same type inference of "csvtk schema".
+     Thousands separators are removed.
+
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		config := getConfigs(cmd)
@@ -51,7 +60,7 @@ var csv2jsonCmd = &cobra.Command{
 		blanks := getFlagBool(cmd, "blanks")
 
 		_parseNumCols := getFlagStringSlice(cmd, "parse-num")
-		var parseNumAll, parseNum0, parseNum bool
+		var parseNumAll, parseNum bool
 		parseNumCols := make(map[int]interface{})
 		var err error
 		var n int
@@ -72,7 +81,6 @@ var csv2jsonCmd = &cobra.Command{
 				checkError(fmt.Errorf("positive column index needed: %s", c))
 			}
 		}
-		parseNum0 = true
 
 		indent := getFlagString(cmd, "indent")
 		hasIndent := indent != ""
@@ -86,7 +94,7 @@ var csv2jsonCmd = &cobra.Command{
 
 		keyed := fieldStr != ""
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -136,32 +144,49 @@ var csv2jsonCmd = &cobra.Command{
 		first := true
 		var ok bool
 		var HeaderRow []string
-
-		checkFirstLine := true
 		var hasHeaderLine bool
-		for record := range csvReader.Ch {
-			if record.Err != nil {
-				checkError(record.Err)
-			}
 
-			if checkFirstLine {
-				checkFirstLine = false
+		// columns of numeric types
+		var numericCols []bool
+		var buffer []Record // data rows for inferring column types
+		inferred := !(parseNumAll || len(parseNumCols) > 0)
 
-				if !config.NoHeaderRow || record.IsHeaderRow {
-					HeaderRow = record.All
-					hasHeaderLine = true
+		inferTypes := func() {
+			var ncols int
+			if hasHeaderLine {
+				ncols = len(HeaderRow)
+			}
+			rows := make([][]string, len(buffer))
+			for i, record := range buffer {
+				rows[i] = record.All
+				if len(record.All) > ncols {
+					ncols = len(record.All)
+				}
+			}
+			numericCols = make([]bool, ncols)
+			for i, t := range inferColumnTypes(ncols, rows, naValuesMap(defaultNAValues)) {
+				numericCols[i] = t == colTypeInt || t == colTypeFloat
+			}
+			inferred = true
+		}
 
-					continue
+		isNumericCol := func(i int) bool {
+			if !parseNumAll {
+				if _, ok = parseNumCols[i+1]; !ok {
+					return false
 				}
 			}
+			return i < len(numericCols) && numericCols[i]
+		}
 
+		write := func(record Record) {
 			if keyed {
 				key = record.Selected[0]
 				if _, ok = keysMaps[key]; ok {
 					if config.Verbose {
 						log.Warningf("ignore record with duplicated key (%s) at line %d", key, record.Line)
 					}
-					continue
+					return
 				}
 				keysMaps[key] = struct{}{}
 			}
@@ -179,13 +204,7 @@ var csv2jsonCmd = &cobra.Command{
 					outfh.WriteString(indent + `{` + LF)
 				}
 				for i, col = range HeaderRow {
-					if parseNumAll {
-						parseNum = true
-					} else if parseNum0 {
-						if _, ok = parseNumCols[i+1]; ok {
-							parseNum = true
-						}
-					}
+					parseNum = isNumericCol(i)
 
 					if i < len(record.All)-1 {
 						outfh.WriteString(indent + indent + `"` + unescapeJSONField(col) + `":` + SEP + processJSONValue(record.All[i], blanks, parseNum) + "," + LF)
@@ -204,13 +223,7 @@ var csv2jsonCmd = &cobra.Command{
 				}
 
 				for i, col = range record.All {
-					if parseNumAll {
-						parseNum = true
-					} else if parseNum0 {
-						if _, ok = parseNumCols[i+1]; ok {
-							parseNum = true
-						}
-					}
+					parseNum = isNumericCol(i)
 
 					if i < len(record.All)-1 {
 						outfh.WriteString(indent + indent + `"` + unescapeJSONField(col) + `"` + "," + LF)
@@ -224,6 +237,45 @@ var csv2jsonCmd = &cobra.Command{
 			}
 		}
 
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				if !config.NoHeaderRow || record.IsHeaderRow {
+					HeaderRow = record.All
+					hasHeaderLine = true
+
+					continue
+				}
+			}
+
+			if !inferred {
+				buffer = append(buffer, record)
+				if len(buffer) < defaultTypeSampleRows {
+					continue
+				}
+				inferTypes()
+				for _, r := range buffer {
+					write(r)
+				}
+				buffer = nil
+				continue
+			}
+
+			write(record)
+		}
+		if !inferred {
+			inferTypes()
+			for _, r := range buffer {
+				write(r)
+			}
+		}
+
 		outfh.WriteString(LF)
 		if keyed {
 			outfh.WriteString("}\n")
@@ -240,7 +292,7 @@ func init() {
 	csv2jsonCmd.Flags().StringP("indent", "i", "  ", `indent. if given blank, output json in one line.`)
 	csv2jsonCmd.Flags().StringP("key", "k", "", "output json as an array of objects keyed by a given field rather than as a list. e.g -k 1 or -k columnA")
 	csv2jsonCmd.Flags().BoolP("blanks", "b", false, `do not convert "", "na", "n/a", "none", "null", "." to null`)
-	csv2jsonCmd.Flags().StringSliceP("parse-num", "n", []string{}, `parse numeric values for nth column, multiple values are supported and "a"/"all" for all columns`)
+	csv2jsonCmd.Flags().StringSliceP("parse-num", "n", []string{}, `parse numeric values for nth column if it's inferred as int or float, multiple values are supported and "a"/"all" for all columns`)
 }
 
 func unescapeJSONField(s string) string {
@@ -266,8 +318,16 @@ func processJSONValue(val string, blanks bool, parseNum bool) string {
 		}
 		return "null"
 	}
-	if parseNum && reDigitals.MatchString(val) {
-		return val
+	if parseNum {
+		if v, ok := parseNumber(val); ok {
+			s := strings.TrimPrefix(removeComma(val), "+")
+			if reJSONNumber.MatchString(s) {
+				return s
+			}
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
 	}
 	return `"` + val + `"`
 }
+
+var reJSONNumber = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][\+\-]?\d+)?$`)
@@ -57,7 +57,7 @@ Attention:
 		borderY := getFlagString(cmd, "vertical-border")
 		header := getFlagString(cmd, "header")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -24,7 +24,6 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
-	"strconv"
 
 	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
@@ -44,6 +43,12 @@ Attention:
   1. Multiple CSV/TSV files are saved as separated sheets in .xlsx file.
   2. All input files should all be CSV or TSV.
   3. First rows are freezed unless given '-H/--no-header-row'.
+  4. With -f/--format-numbers, column types are inferred from all data rows
+     of a file, with the same type inference of "csvtk schema".
+     Values in int/float columns are saved as numbers, and values in bool
+     columns are saved as booleans.
+  5. The .xlsx file could be further compressed with the global flag
+     --out-compress or suffixes like .gz, for archiving or transferring.
   
 `,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -63,6 +68,7 @@ Attention:
 			} else {
 				outFile = "stdin.xlsx"
 			}
+			outFile += compressSuffixes[config.OutCompress]
 		}
 
 		xlsx := excelize.NewFile()
@@ -71,6 +77,7 @@ Attention:
 		var sheet, cell, val string
 		var col, line int
 		var valFloat float64
+		var valBool, ok bool
 		var nSheets int
 		var idx, firstIdx int
 		for i, file := range files {
@@ -122,6 +129,39 @@ Attention:
 			}
 
 			line = 1
+			var types []string
+			writeRow := func(row []string) {
+				for col, val = range row {
+					cell = fmt.Sprintf("%s%d", ExcelColumnIndex(col), line)
+					if types == nil {
+						xlsx.SetCellValue(sheet, cell, val)
+						continue
+					}
+					switch types[col] {
+					case colTypeInt, colTypeFloat:
+						if valFloat, ok = parseNumber(val); ok {
+							xlsx.SetCellFloat(sheet, cell, valFloat, -1, 64)
+						} else {
+							xlsx.SetCellValue(sheet, cell, val)
+						}
+					case colTypeBool:
+						if valBool, ok = parseBool(val); ok {
+							xlsx.SetCellBool(sheet, cell, valBool)
+						} else {
+							xlsx.SetCellValue(sheet, cell, val)
+						}
+					default:
+						xlsx.SetCellValue(sheet, cell, val)
+					}
+				}
+				line++
+			}
+
+			// rows are only buffered for inferring column types
+			var rows [][]string
+			if formatNumbers {
+				rows = make([][]string, 0, 1024)
+			}
 			handleHeaderRow := !config.NoHeaderRow
 			for record := range csvReader.Ch {
 				if record.Err != nil {
@@ -133,29 +173,35 @@ Attention:
 					if config.NoOutHeader {
 						continue
 					}
+					writeRow(record.Selected)
+					continue
 				}
 
-				for col, val = range record.Selected {
-					cell = fmt.Sprintf("%s%d", ExcelColumnIndex(col), line)
-					if formatNumbers {
-						valFloat, err = strconv.ParseFloat(val, 64)
-						if err != nil {
-							xlsx.SetCellValue(sheet, cell, val)
-						} else {
-							xlsx.SetCellFloat(sheet, cell, valFloat, -1, 64)
-						}
-					} else {
-						xlsx.SetCellValue(sheet, cell, val)
+				if formatNumbers {
+					rows = append(rows, record.Selected)
+					continue
+				}
+				writeRow(record.Selected)
+			}
+
+			if formatNumbers {
+				var ncols int
+				for _, row := range rows {
+					if len(row) > ncols {
+						ncols = len(row)
 					}
 				}
-				line++
+				types = inferColumnTypes(ncols, rows, naValuesMap(defaultNAValues))
+				for _, row := range rows {
+					writeRow(row)
+				}
 			}
 
 			readerReport(&config, csvReader, file)
 		}
 
 		xlsx.SetActiveSheet(firstIdx)
-		checkError(xlsx.SaveAs(outFile))
+		checkError(saveXlsx(config, xlsx, outFile))
 	},
 }
 
@@ -180,3 +226,19 @@ func ExcelColumnIndex(col int) string {
 	}
 	return string(s)
 }
+
+// saveXlsx saves the workbook to a file, which is compressed according to
+// the global flag --out-compress or the suffix of the file.
+func saveXlsx(config Config, xlsx *excelize.File, file string) error {
+	xlsx.Path = trimCompressSuffix(file) // for checking the file format
+
+	outfh, err := wopenByConfig(config, file)
+	if err != nil {
+		return err
+	}
+	if _, err = xlsx.WriteTo(outfh); err != nil {
+		outfh.Close()
+		return err
+	}
+	return outfh.Close()
+}
@@ -0,0 +1,260 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shenwei356/xopen"
+)
+
+// NewCSVFollowReader is like NewCSVReader, but it keeps polling the file
+// for new data at EOF, like "tail -F". If the file is truncated, or rotated
+// (replaced by a new file with the same name), it's read from the beginning,
+// and the header row of the new file is skipped if it's the same as the
+// original one, so records are sent as a single continuous stream.
+func NewCSVFollowReader(file string, interval time.Duration, headerRow bool, commentChar rune) (*CSVReader, error) {
+	if isStdin(file) || file[0] == '|' ||
+		strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+		return nil, fmt.Errorf("flag --follow only supports plain files: %s", file)
+	}
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(file), ".")) {
+	case "gz", "xz", "zst", "bz2":
+		return nil, fmt.Errorf("flag --follow does not support compressed files: %s", file)
+	}
+
+	r, err := newFollowReader(file, interval, headerRow, commentChar)
+	if err != nil {
+		return nil, err
+	}
+	fh, err := xopen.Buf(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	csvReader := newCSVReader(file, fh)
+	csvReader.follow = r
+	return csvReader, nil
+}
+
+// followReader is an io.Reader which never returns io.EOF,
+// but waits for new data appended to the file.
+type followReader struct {
+	file     string
+	interval time.Duration
+
+	fh     *os.File
+	info   os.FileInfo
+	offset int64
+
+	comment []byte
+
+	checkHeader bool   // capture the header line, or skip it after reopening the file
+	header      []byte // the header line of the first file, without line ending
+	buf         []byte // data read for checking the header line
+
+	stopAtEnd bool // return io.EOF once at the end of available data, for sampling rows
+	last      byte // the last byte returned
+}
+
+func newFollowReader(file string, interval time.Duration, headerRow bool, commentChar rune) (*followReader, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		fh.Close()
+		return nil, fmt.Errorf("flag --follow only supports plain files: %s", file)
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	r := &followReader{
+		file:        file,
+		interval:    interval,
+		fh:          fh,
+		info:        info,
+		checkHeader: headerRow,
+	}
+	if commentChar != 0 {
+		r.comment = []byte(string(commentChar))
+	}
+	return r, nil
+}
+
+// Read reads data from the file, it blocks till new data is available.
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		if r.checkHeader {
+			if err := r.readHeader(); err != nil {
+				return 0, err
+			}
+		}
+		if len(r.buf) > 0 {
+			n := copy(p, r.buf)
+			r.buf = r.buf[n:]
+			r.last = p[n-1]
+			return n, nil
+		}
+
+		n, err := r.fh.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.last = p[n-1]
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		// only stop at the end of a line, a partly written line is waited for
+		if r.stopAtEnd && r.last == '\n' {
+			r.stopAtEnd = false
+			return 0, io.EOF
+		}
+		if _, err = r.wait(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// StopAtEnd makes the next Read return io.EOF when all available complete
+// lines are read, rather than waiting for new data. It's used for reading
+// sample rows without blocking, reading after the io.EOF continues following.
+// Calling it with false cancels it.
+func (r *followReader) StopAtEnd(stop bool) {
+	r.stopAtEnd = stop
+}
+
+// readHeader reads data till the header line, i.e., the first line which is
+// neither blank nor a comment. The header line of the first file is saved,
+// and the same line in a truncated or rotated file is removed.
+func (r *followReader) readHeader() error {
+	tmp := make([]byte, 4096)
+	var start int
+	for {
+		i := bytes.IndexByte(r.buf[start:], '\n')
+		if i < 0 {
+			n, err := r.fh.Read(tmp)
+			r.offset += int64(n)
+			r.buf = append(r.buf, tmp[:n]...)
+			if n > 0 {
+				continue
+			}
+			if err != nil && err != io.EOF {
+				return err
+			}
+			reopened, err := r.wait()
+			if err != nil {
+				return err
+			}
+			if reopened {
+				start = 0
+			}
+			continue
+		}
+
+		line := trimLineEnding(r.buf[start : start+i+1])
+		if len(line) == 0 || (r.comment != nil && bytes.HasPrefix(line, r.comment)) {
+			start += i + 1
+			continue
+		}
+
+		if r.header == nil {
+			r.header = append([]byte{}, line...)
+		} else if bytes.Equal(line, r.header) {
+			r.buf = append(r.buf[:start], r.buf[start+i+1:]...)
+		}
+		r.checkHeader = false
+		return nil
+	}
+}
+
+func trimLineEnding(line []byte) []byte {
+	line = bytes.TrimPrefix(line, []byte("\uFEFF"))
+	return bytes.TrimRight(line, "\r\n")
+}
+
+// wait checks whether the file is truncated or rotated, and sleeps if no new
+// data is written. It returns true if the file is reopened.
+func (r *followReader) wait() (bool, error) {
+	info, err := os.Stat(r.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+		// the file is being rotated
+	} else if !os.SameFile(info, r.info) { // rotated
+		// data written to the old file before rotation
+		if old, err := r.fh.Stat(); err == nil && old.Size() > r.offset {
+			return false, nil
+		}
+
+		fh, err := os.Open(r.file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				time.Sleep(r.interval)
+				return false, nil
+			}
+			return false, err
+		}
+		if info, err = fh.Stat(); err != nil {
+			fh.Close()
+			return false, err
+		}
+		r.fh.Close()
+		r.fh, r.info = fh, info
+		r.reset()
+		return true, nil
+	} else if info.Size() < r.offset { // truncated
+		if _, err = r.fh.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		r.reset()
+		return true, nil
+	} else if info.Size() > r.offset {
+		return false, nil
+	}
+
+	time.Sleep(r.interval)
+	return false, nil
+}
+
+func (r *followReader) reset() {
+	r.offset = 0
+	r.buf = r.buf[:0]
+	r.checkHeader = r.header != nil
+}
+
+// Close closes the file.
+func (r *followReader) Close() error {
+	return r.fh.Close()
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowReader(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "log.csv")
+	write := func(s string, flag int) {
+		fh, err := os.OpenFile(file, flag|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fh.WriteString(s)
+		fh.Close()
+	}
+
+	write("#comment\na,b\n1,x\n", os.O_TRUNC)
+	r, err := newFollowReader(file, 10*time.Millisecond, true, '#')
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	reader := bufio.NewReader(r)
+
+	steps := []struct {
+		do     func()
+		expect []string
+	}{
+		{func() {}, []string{"#comment\n", "a,b\n", "1,x\n"}},
+		// appended
+		{func() { write("2,y\n", os.O_APPEND) }, []string{"2,y\n"}},
+		// rotated, the header row is skipped
+		{func() {
+			os.Rename(file, file+".1")
+			write("#comment\na,b\n3,z\n", os.O_TRUNC)
+		}, []string{"#comment\n", "3,z\n"}},
+		// truncated
+		{func() { write("a,b\n4\n", os.O_TRUNC) }, []string{"4\n"}},
+	}
+	for i, step := range steps {
+		step.do()
+		for _, expect := range step.expect {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("step %d: %s", i, err)
+			}
+			if line != expect {
+				t.Errorf("step %d: expected %q, returned %q", i, expect, line)
+			}
+		}
+	}
+}
+
+func TestFollowTypeSelectors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "growing.csv")
+	// far fewer rows than the sample size, and the last row is partly written
+	if err := os.WriteFile(file, []byte("id,name\n1,a\n2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	csvReader, err := NewCSVFollowReader(file, 10*time.Millisecond, true, '#')
+	if err != nil {
+		t.Fatal(err)
+	}
+	csvReader.Read(ReadOption{FieldStr: "@int"})
+
+	next := func() string {
+		select {
+		case record := <-csvReader.Ch:
+			if record.Err != nil {
+				t.Fatal(record.Err)
+			}
+			return strings.Join(record.Selected, ",")
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for records")
+		}
+		return ""
+	}
+
+	fh, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	time.Sleep(50 * time.Millisecond)
+	fh.WriteString("0,b\n")
+	for _, expect := range []string{"id", "1", "20"} {
+		if v := next(); v != expect {
+			t.Errorf("expected %q, returned %q", expect, v)
+		}
+	}
+
+	// following continues after sampling
+	fh.WriteString("3,c\n")
+	if v := next(); v != "3" {
+		t.Errorf("expected %q, returned %q", "3", v)
+	}
+}
@@ -55,6 +55,18 @@ Examples:
      csvtk cut -f -1--3       # discard 1st to 3rd column
      csvtk cut -f -2-         # discard 2nd and all columns on the right.
      csvtu cut -f -colA,-colB # discard colA and colB
+  5. Regular expressions, column types and column name ranges
+     csvtk cut -f '/^sample_\d+$/'  # columns with names matching the regular expression
+     csvtk cut -f @numeric           # numeric columns, guessed from the first 1000 rows.
+                                     # available types: @numeric (@int, @float), @bool,
+                                     # @date (@datetime), @string (@categorical).
+                                     # type "csvtk schema -h" for details
+     csvtk cut -f colB:colE          # colB to colE in the header row
+     csvtk cut -f colC:              # colC and all columns on the right
+  6. Set operations
+     csvtk cut -f id,@numeric        # union
+     csvtk cut -f @numeric,-id       # difference
+     csvtk cut -f -@string           # discard all string columns
 	 
 `,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -78,7 +90,7 @@ Examples:
 		allowMissingColumn := getFlagBool(cmd, "allow-missing-col")
 		blankMissingColumn := getFlagBool(cmd, "blank-missing-col")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -138,6 +150,11 @@ Examples:
 			}
 
 			writer.Write(record.Selected)
+
+			if config.Follow { // emit records as they arrive
+				writer.Flush()
+				checkError(outfh.Flush())
+			}
 		}
 
 		readerReport(&config, csvReader, file)
@@ -0,0 +1,230 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// densityCmd represents the density command
+var densityCmd = &cobra.Command{
+	Use:   "density",
+	Short: "kernel density estimate",
+	Long: `kernel density estimate
+
+The bandwidth is the standard deviation of the kernel. By default, it is
+chosen by Silverman's rule of thumb, i.e., bw.nrd0() in R:
+  0.9 * min(sd, IQR/1.34) * n^(-1/5)
+Densities are computed in the log10 scale with --x-log.
+
+Notes:
+
+  1. Output file can be set by flag -o/--out-file.
+  2. File format is determined by the out file suffix.
+     Supported formats: eps, jpg|jpeg, pdf, png, svg, and tif|tiff
+  3. If flag -o/--out-file not set (default), image is written to stdout,
+     you can display the image by pipping to "display" command of Imagemagic
+     or just redirect to file.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		plotConfig := getPlotConfigs(cmd)
+
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		bw := getFlagNonNegativeFloat64(cmd, "bw")
+		bwAdjust := getFlagPositiveFloat64(cmd, "bw-adjust")
+		kernel := getFlagString(cmd, "kernel")
+		switch kernel {
+		case "gaussian", "epanechnikov", "rectangular":
+		default:
+			checkError(fmt.Errorf("invalid kernel: %s. available: gaussian, epanechnikov, rectangular", kernel))
+		}
+		nPoints := getFlagPositiveInt(cmd, "n-points")
+		if nPoints < 2 {
+			checkError(fmt.Errorf("value of flag --n-points should be greater than 1"))
+		}
+		lineWidth := vg.Points(getFlagPositiveFloat64(cmd, "line-width"))
+		colorIndex := getFlagPositiveInt(cmd, "color-index")
+		if colorIndex > 7 {
+			checkError(fmt.Errorf("unsupported color index"))
+		}
+		if plotConfig.yaxis.trans != "" {
+			log.Warning("flag --y-trans ignored for command density")
+			plotConfig.yaxis.trans = ""
+		}
+
+		groups := readPlotValueGroups(cmd, config, plotConfig, files[0],
+			plotConfig.dataFieldStr, []*plotAxisConfig{plotConfig.xaxis})
+
+		p := plotConfig.newPlot()
+		for i, name := range groups.names {
+			x := groups.values[i][0]
+			if len(x) < 2 {
+				log.Warningf("group %s skipped: at least two values needed", name)
+				continue
+			}
+			if plotConfig.xaxis.log {
+				for k, v := range x {
+					x[k] = math.Log10(v)
+				}
+			}
+
+			h := bw
+			if h == 0 {
+				h = bandwidthNRD0(x)
+			}
+			xys := kernelDensity(x, h*bwAdjust, kernel, nPoints)
+			if plotConfig.xaxis.log {
+				for k := range xys {
+					xys[k].X = math.Pow(10, xys[k].X)
+				}
+			}
+
+			line, err := plotter.NewLine(xys)
+			checkError(err)
+			line.Color = plotConfig.color(colorIndex - 1 + i)
+			line.LineStyle.Dashes = plotutil.Dashes(colorIndex - 1 + i)
+			line.LineStyle.Width = lineWidth
+			p.Add(line)
+			if plotConfig.groupFieldStr != "" {
+				p.Legend.Add(name, line)
+			}
+		}
+		p.Legend.Top = getFlagBool(cmd, "legend-top")
+		p.Legend.Left = getFlagBool(cmd, "legend-left")
+
+		if plotConfig.xlab == "" && len(groups.header) > 0 {
+			plotConfig.xlab = plotConfig.xaxis.Label(groups.header[0])
+		}
+		if plotConfig.ylab == "" {
+			plotConfig.ylab = "Density"
+		}
+
+		plotConfig.applyStyle(p)
+		plotConfig.applyLimits(p)
+		plotConfig.applyScales(p)
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
+		}
+
+		// Save image
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
+	},
+}
+
+// bandwidthNRD0 returns the bandwidth by Silverman's rule of thumb, the same as bw.nrd0() in R.
+func bandwidthNRD0(x []float64) float64 {
+	sorted := append([]float64{}, x...)
+	sort.Float64s(sorted)
+	sd := stat.StdDev(x, nil)
+	lo := math.Min(sd, (percentileValue(sorted, 0.75)-percentileValue(sorted, 0.25))/1.34)
+	if lo == 0 {
+		lo = sd
+		if lo == 0 {
+			lo = math.Abs(sorted[0])
+			if lo == 0 {
+				lo = 1
+			}
+		}
+	}
+	return 0.9 * lo * math.Pow(float64(len(x)), -0.2)
+}
+
+// kernelDensity estimates densities at n evenly spaced points, ranging from
+// min(x) - 3*bw to max(x) + 3*bw. The bandwidth is the standard deviation of the kernel.
+func kernelDensity(x []float64, bw float64, kernel string, n int) plotter.XYs {
+	var k func(u float64) float64
+	switch kernel {
+	case "epanechnikov":
+		a := math.Sqrt(5)
+		k = func(u float64) float64 {
+			if math.Abs(u) >= a {
+				return 0
+			}
+			return 3 / (4 * a) * (1 - u*u/5)
+		}
+	case "rectangular":
+		a := math.Sqrt(3)
+		k = func(u float64) float64 {
+			if math.Abs(u) >= a {
+				return 0
+			}
+			return 1 / (2 * a)
+		}
+	default:
+		c := 1 / math.Sqrt(2*math.Pi)
+		k = func(u float64) float64 {
+			return c * math.Exp(-u*u/2)
+		}
+	}
+
+	min, max := x[0], x[0]
+	for _, v := range x {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	from, to := min-3*bw, max+3*bw
+	step := (to - from) / float64(n-1)
+
+	xys := make(plotter.XYs, n)
+	var s float64
+	nx := float64(len(x))
+	for i := range xys {
+		xys[i].X = from + float64(i)*step
+		s = 0
+		for _, v := range x {
+			s += k((xys[i].X - v) / bw)
+		}
+		xys[i].Y = s / (nx * bw)
+	}
+	return xys
+}
+
+func init() {
+	plotCmd.AddCommand(densityCmd)
+
+	densityCmd.Flags().Float64P("bw", "", 0, `bandwidth, 0 for Silverman's rule of thumb`)
+	densityCmd.Flags().Float64P("bw-adjust", "", 1, `multiply the bandwidth by this value`)
+	densityCmd.Flags().StringP("kernel", "", "gaussian", `kernel: gaussian, epanechnikov, or rectangular`)
+	densityCmd.Flags().IntP("n-points", "", 512, `number of points to estimate densities`)
+
+	densityCmd.Flags().BoolP("legend-top", "", false, "locate legend along the top edge of the plot")
+	densityCmd.Flags().BoolP("legend-left", "", false, "locate legend along the left edge of the plot")
+	densityCmd.Flags().Float64P("line-width", "", 1.5, "line width")
+	densityCmd.Flags().IntP("color-index", "", 1, `color index, 1-7`)
+}
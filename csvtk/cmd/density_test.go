@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBandwidthNRD0(t *testing.T) {
+	// bw.nrd0(c(1, 2, 3, 4, 10)) in R
+	if bw := bandwidthNRD0([]float64{1, 2, 3, 4, 10}); math.Abs(bw-0.9735846) > 1e-6 {
+		t.Errorf("expected 0.9735846, returned %f", bw)
+	}
+}
+
+func TestKernelDensity(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 10}
+	for _, kernel := range []string{"gaussian", "epanechnikov", "rectangular"} {
+		xys := kernelDensity(x, 1, kernel, 2048)
+		// the area should be close to 1
+		var area float64
+		for i := 1; i < len(xys); i++ {
+			area += (xys[i].X - xys[i-1].X) * (xys[i].Y + xys[i-1].Y) / 2
+		}
+		if math.Abs(area-1) > 0.01 {
+			t.Errorf("%s: area under the density curve: %f", kernel, area)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/stable"
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// describeCmd represents the describe command
+var describeCmd = &cobra.Command{
+	GroupID: "info",
+
+	Use:   "describe",
+	Short: "profile all columns automatically",
+	Long: `profile all columns automatically
+
+Column types are inferred in the same way as "csvtk schema".
+
+Statistics:
+
+  column        column name, or field number for data without header row
+  type          inferred column type
+  count         number of values
+  missing       number of NA values, see --na-values
+  distinct      number of distinct non-NA values, estimated with HyperLogLog
+                for more than 65536 values
+  min, max      minimum and maximum values
+  mean, std     mean and standard deviation, for numeric columns
+  q1, median, q3
+                quartiles, for numeric columns. They are estimated with
+                KLL sketches for more than 200 values, with a normalized
+                rank error of about 1.33%
+  top           the most frequent values and their counts, for non-numeric
+                and non-date columns. Not reported for columns with more
+                than 65536 distinct values
+
+Output formats:
+
+  wide          one row per column
+  long          one row per column and statistic, with columns: column, stat, value
+  pretty        the wide table in aligned format, like "csvtk pretty"
+  html          an HTML report with histograms of numeric columns and
+                frequencies of top values. Histograms are drawn from the
+                weighted values retained in KLL sketches, so they are
+                approximate for more than 200 values
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		fieldStr := getFlagString(cmd, "fields")
+		if fieldStr == "" {
+			checkError(fmt.Errorf("flag -f (--fields) needed"))
+		}
+		fuzzyFields := getFlagBool(cmd, "fuzzy-fields")
+		maxCategories := getFlagNonNegativeInt(cmd, "max-categories")
+		naMap := naValuesMap(getFlagStringSlice(cmd, "na-values"))
+		topK := getFlagNonNegativeInt(cmd, "top")
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+		bins := getFlagPositiveInt(cmd, "bins")
+
+		format := strings.ToLower(getFlagString(cmd, "format"))
+		switch format {
+		case "wide", "long", "pretty", "html":
+		default:
+			checkError(fmt.Errorf("invalid value of flag --format: %s. available: wide, long, pretty, html", format))
+		}
+
+		file := files[0]
+		csvReader, err := newCSVReaderByConfig(config, file)
+		if err != nil {
+			if err == xopen.ErrNoContent {
+				if config.Verbose {
+					log.Warningf("csvtk describe: skipping empty input file: %s", file)
+				}
+				return
+			}
+			checkError(err)
+		}
+
+		csvReader.Read(ReadOption{
+			FieldStr:    fieldStr,
+			FuzzyFields: fuzzyFields,
+		})
+
+		var columns []*describeColumn
+		var i int
+		var v string
+		var x float64
+		var ok bool
+		var c *describeColumn
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				columns = make([]*describeColumn, len(record.Fields))
+				if !config.NoHeaderRow || record.IsHeaderRow {
+					for i, v = range record.Selected {
+						columns[i] = newDescribeColumn(v, naMap, maxCategories)
+					}
+					continue
+				}
+				for i, f := range record.Fields {
+					columns[i] = newDescribeColumn(strconv.Itoa(f), naMap, maxCategories)
+				}
+			}
+
+			for i, v = range record.Selected {
+				c = columns[i]
+				c.profile.Add(v)
+				if isNA(v, naMap) {
+					continue
+				}
+				c.counts.Add(v)
+				if x, ok = parseNumber(v); ok {
+					c.stats.AddNumber(x)
+				}
+			}
+		}
+
+		readerReport(&config, csvReader, file)
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+		defer outfh.Close()
+
+		header := []string{"column", "type", "count", "missing", "distinct", "min", "max",
+			"mean", "std", "q1", "median", "q3", "top"}
+		rows := make([][]string, len(columns))
+		for i, c = range columns {
+			rows[i] = c.Row(decimalFormat, topK)
+		}
+
+		switch format {
+		case "html":
+			checkError(writeDescribeHTML(outfh, file, header, rows, columns, topK, bins))
+			return
+		case "pretty":
+			tbl := stable.New()
+			_, err = tbl.Header(header)
+			checkError(err)
+			for _, row := range rows {
+				checkError(tbl.AddRowStringSlice(row))
+			}
+			outfh.Write(tbl.Render(&stable.TableStyle{
+				Name:            "default",
+				LineBelowHeader: stable.LineStyle{Hline: "-", Sep: "   "},
+				HeaderRow:       stable.RowStyle{Sep: "   "},
+				DataRow:         stable.RowStyle{Sep: "   "},
+			}))
+			return
+		}
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+
+		if format == "long" {
+			if !config.NoOutHeader {
+				checkError(writer.Write([]string{"column", "stat", "value"}))
+			}
+			for _, row := range rows {
+				for j := 1; j < len(header); j++ {
+					checkError(writer.Write([]string{row[0], header[j], row[j]}))
+				}
+			}
+		} else {
+			if !config.NoOutHeader {
+				checkError(writer.Write(header))
+			}
+			for _, row := range rows {
+				checkError(writer.Write(row))
+			}
+		}
+		writer.Flush()
+		checkError(writer.Error())
+	},
+}
+
+// describeColumn collects the profile and statistics of a column.
+type describeColumn struct {
+	profile *columnProfile
+	stats   *streamingStats
+	counts  *valueCounter
+}
+
+func newDescribeColumn(name string, naMap map[string]struct{}, maxCategories int) *describeColumn {
+	return &describeColumn{
+		profile: newColumnProfile(name, naMap, maxCategories),
+		stats:   newStreamingStats(false, true),
+		counts:  newValueCounter(maxExactDistinct),
+	}
+}
+
+func (c *describeColumn) isNumeric() bool {
+	t := c.profile.Type()
+	return t == colTypeInt || t == colTypeFloat
+}
+
+func (c *describeColumn) hasTop() bool {
+	switch c.profile.Type() {
+	case colTypeInt, colTypeFloat, colTypeDate, colTypeDatetime:
+		return false
+	}
+	return true
+}
+
+// Row returns the values in the order of the wide table.
+func (c *describeColumn) Row(decimalFormat string, topK int) []string {
+	p := c.profile
+	distinct, _ := p.Distinct()
+	row := []string{
+		p.Name,
+		p.Type(),
+		strconv.FormatInt(p.N, 10),
+		strconv.FormatInt(p.NA, 10),
+		strconv.FormatUint(distinct, 10),
+		p.Min(),
+		p.Max(),
+		"", "", "", "", "", "",
+	}
+	if c.isNumeric() {
+		row[7] = fmt.Sprintf(decimalFormat, c.stats.Number("mean"))
+		row[8] = fmt.Sprintf(decimalFormat, c.stats.Number("stdev"))
+		row[9] = fmt.Sprintf(decimalFormat, c.stats.Number("q1"))
+		row[10] = fmt.Sprintf(decimalFormat, c.stats.Number("median"))
+		row[11] = fmt.Sprintf(decimalFormat, c.stats.Number("q3"))
+	}
+	if c.hasTop() && topK > 0 {
+		top := c.counts.Top(topK)
+		items := make([]string, len(top))
+		for i, t := range top {
+			items[i] = fmt.Sprintf("%s (%d)", t.value, t.count)
+		}
+		row[12] = strings.Join(items, "; ")
+	}
+	return row
+}
+
+// valueCounter counts values exactly, counting stops when there are too many distinct values.
+type valueCounter struct {
+	max      int
+	counts   map[string]*valueCount
+	overflow bool
+}
+
+type valueCount struct {
+	value string
+	count int64
+	order int // order of the first appearance
+}
+
+func newValueCounter(max int) *valueCounter {
+	return &valueCounter{max: max, counts: make(map[string]*valueCount, 64)}
+}
+
+// Add adds a value.
+func (c *valueCounter) Add(v string) {
+	if c.overflow {
+		return
+	}
+	if vc, ok := c.counts[v]; ok {
+		vc.count++
+		return
+	}
+	if len(c.counts) == c.max {
+		c.overflow = true
+		c.counts = nil
+		return
+	}
+	c.counts[v] = &valueCount{value: v, count: 1, order: len(c.counts)}
+}
+
+// Top returns the k most frequent values, ties are broken by the order of appearance.
+// Nothing is returned if counting stopped.
+func (c *valueCounter) Top(k int) []*valueCount {
+	if c.overflow {
+		return nil
+	}
+	list := make([]*valueCount, 0, len(c.counts))
+	for _, vc := range c.counts {
+		list = append(list, vc)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].count == list[j].count {
+			return list[i].order < list[j].order
+		}
+		return list[i].count > list[j].count
+	})
+	if len(list) > k {
+		list = list[:k]
+	}
+	return list
+}
+
+// describeHistogram plots a histogram of values retained in the KLL sketch,
+// and returns the PNG image. Bin counts are exact only when the sketch is,
+// otherwise each retained value stands for weight values around it.
+func describeHistogram(c *describeColumn, bins int) ([]byte, error) {
+	values, weights := c.stats.quantiles.Items()
+	if len(values) == 0 {
+		return nil, nil
+	}
+	xys := make(plotter.XYs, len(values))
+	for i, v := range values {
+		xys[i].X, xys[i].Y = v, weights[i]
+	}
+
+	p := plot.New()
+	h, err := plotter.NewHistogram(xys, bins)
+	if err != nil {
+		return nil, err
+	}
+	h.FillColor = plotutil.Color(0)
+	p.Add(h)
+	p.X.Label.Text = c.profile.Name
+	p.Y.Label.Text = "Count"
+
+	fh, err := p.WriterTo(4*vg.Inch, 3*vg.Inch, "png")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err = fh.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const describeHTMLStyle = `body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+.bar { background: #4e79a7; height: 1em; display: inline-block; }
+.column { display: inline-block; vertical-align: top; margin: 0 2em 2em 0; }`
+
+func writeDescribeHTML(w io.Writer, file string, header []string, rows [][]string,
+	columns []*describeColumn, topK int, bins int) error {
+
+	title := html.EscapeString(file)
+	if isStdin(file) {
+		title = "stdin"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>csvtk describe: %s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", title, describeHTMLStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", title)
+	var nrows int64
+	if len(columns) > 0 {
+		nrows = columns[0].profile.N
+	}
+	fmt.Fprintf(&b, "<p>%d rows, %d columns</p>\n", nrows, len(columns))
+
+	b.WriteString("<table>\n<tr>")
+	for _, h := range header {
+		fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(h))
+	}
+	b.WriteString("</tr>\n")
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, v := range row {
+			fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(v))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+
+	for _, c := range columns {
+		fmt.Fprintf(&b, "<div class=\"column\">\n<h2>%s <small>(%s)</small></h2>\n",
+			html.EscapeString(c.profile.Name), c.profile.Type())
+		if c.isNumeric() {
+			img, err := describeHistogram(c, bins)
+			if err != nil {
+				return err
+			}
+			if img != nil {
+				fmt.Fprintf(&b, "<img alt=\"histogram of %s\" src=\"data:image/png;base64,%s\">\n",
+					html.EscapeString(c.profile.Name), base64.StdEncoding.EncodeToString(img))
+				if !c.stats.quantiles.Exact() {
+					b.WriteString("<p><small>approximate histogram from a KLL sketch</small></p>\n")
+				}
+			}
+		} else if c.hasTop() && topK > 0 {
+			top := c.counts.Top(topK)
+			if len(top) > 0 {
+				b.WriteString("<table>\n<tr><th>value</th><th>count</th><th></th></tr>\n")
+				max := float64(top[0].count)
+				for _, t := range top {
+					fmt.Fprintf(&b, "<tr><td>%s</td><td>%d</td><td><span class=\"bar\" style=\"width: %.0fpx\"></span></td></tr>\n",
+						html.EscapeString(t.value), t.count, math.Max(1, 200*float64(t.count)/max))
+				}
+				b.WriteString("</table>\n")
+			}
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(describeCmd)
+	describeCmd.Flags().StringP("fields", "f", "1-", `select only these fields. type "csvtk cut -h" for examples`)
+	describeCmd.Flags().BoolP("fuzzy-fields", "F", false, `using fuzzy fields, e.g., -F -f "*name" or -F -f "id123*"`)
+	describeCmd.Flags().IntP("max-categories", "m", defaultMaxCategories, "maximum number of distinct values of a categorical column")
+	describeCmd.Flags().StringSliceP("na-values", "", defaultNAValues, `NA values, case ignored`)
+	describeCmd.Flags().IntP("top", "k", 5, "number of the most frequent values to report, 0 for none")
+	describeCmd.Flags().IntP("decimal-width", "w", 2, "limit floats to N decimal points")
+	describeCmd.Flags().IntP("bins", "", 20, "number of bins of histograms in the HTML report")
+	describeCmd.Flags().StringP("format", "", "wide", `output format: wide, long, pretty, html`)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// describeColumnsOf profiles columns in the same way as "csvtk describe".
+func describeColumnsOf(header []string, records [][]string) []*describeColumn {
+	naMap := naValuesMap(defaultNAValues)
+	columns := make([]*describeColumn, len(header))
+	for i, name := range header {
+		columns[i] = newDescribeColumn(name, naMap, defaultMaxCategories)
+	}
+	for _, record := range records {
+		for i, v := range record {
+			c := columns[i]
+			c.profile.Add(v)
+			if isNA(v, naMap) {
+				continue
+			}
+			c.counts.Add(v)
+			if x, ok := parseNumber(v); ok {
+				c.stats.AddNumber(x)
+			}
+		}
+	}
+	return columns
+}
+
+func TestDescribeColumnRow(t *testing.T) {
+	columns := describeColumnsOf([]string{"id", "name", "score"}, [][]string{
+		{"1", "a", "3.5"},
+		{"2", "b", "NA"},
+		{"3", "a", "1"},
+		{"4", "c", "10"},
+	})
+	expected := [][]string{
+		{"id", "int", "4", "0", "4", "1", "4", "2.50", "1.29", "1.75", "2.50", "3.25", ""},
+		{"name", "string", "4", "0", "3", "a", "c", "", "", "", "", "", "a (2); b (1)"},
+		{"score", "float", "4", "1", "3", "1", "10", "4.83", "4.65", "2.25", "3.50", "6.75", ""},
+	}
+	for i, c := range columns {
+		if row := c.Row("%.2f", 2); !reflect.DeepEqual(row, expected[i]) {
+			t.Errorf("column %s: expected %q, returned %q", c.profile.Name, expected[i], row)
+		}
+	}
+
+	// top values are not reported when too many distinct values are seen
+	c := newDescribeColumn("x", naValuesMap(defaultNAValues), defaultMaxCategories)
+	c.counts = newValueCounter(2)
+	for _, v := range []string{"a", "b", "c", "a"} {
+		c.profile.Add(v)
+		c.counts.Add(v)
+	}
+	if row := c.Row("%.2f", 5); row[12] != "" {
+		t.Errorf("top values of overflowed counter: expected nothing, returned %q", row[12])
+	}
+}
+
+func TestDescribeHTML(t *testing.T) {
+	records := make([][]string, 0, 500)
+	for i := 0; i < 500; i++ {
+		records = append(records, []string{strconv.Itoa(i), "<b>"})
+	}
+	header := []string{"n", "tag"}
+	columns := describeColumnsOf(header, records)
+	rows := make([][]string, len(columns))
+	for i, c := range columns {
+		rows[i] = c.Row("%.2f", 5)
+	}
+
+	var buf bytes.Buffer
+	if err := writeDescribeHTML(&buf, "-", header, rows, columns, 5, 10); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	for _, sub := range []string{
+		"<title>csvtk describe: stdin</title>",
+		"<p>500 rows, 2 columns</p>",
+		`<img alt="histogram of n" src="data:image/png;base64,`,
+		"approximate histogram from a KLL sketch",
+		"<td>&lt;b&gt;</td><td>500</td>",
+	} {
+		if !strings.Contains(s, sub) {
+			t.Errorf("HTML report should contain %q", sub)
+		}
+	}
+	if strings.Contains(s, "<td><b></td>") {
+		t.Errorf("values should be escaped in the HTML report")
+	}
+
+	// histograms of few values are exact
+	few := make([][]string, 100)
+	for i := range few {
+		few[i] = records[i][:1]
+	}
+	columns = describeColumnsOf(header[:1], few)
+	buf.Reset()
+	if err := writeDescribeHTML(&buf, "a.csv", header[:1], [][]string{columns[0].Row("%.2f", 5)}, columns, 5, 10); err != nil {
+		t.Fatal(err)
+	}
+	if s = buf.String(); strings.Contains(s, "approximate histogram") {
+		t.Errorf("histogram of 100 values should be exact")
+	}
+}
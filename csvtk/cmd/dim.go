@@ -49,7 +49,7 @@ var dimCmd = &cobra.Command{
 		rows := getFlagBool(cmd, "rows")
 		noFiles := getFlagBool(cmd, "no-files")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -0,0 +1,164 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// ecdfCmd represents the ecdf command
+var ecdfCmd = &cobra.Command{
+	Use:   "ecdf",
+	Short: "empirical cumulative distribution",
+	Long: `empirical cumulative distribution
+
+The complementary ECDF (--complementary), i.e., the fraction of values
+greater than or equal to x, is helpful for heavy-tailed data with log axes.
+
+Notes:
+
+  1. Output file can be set by flag -o/--out-file.
+  2. File format is determined by the out file suffix.
+     Supported formats: eps, jpg|jpeg, pdf, png, svg, and tif|tiff
+  3. If flag -o/--out-file not set (default), image is written to stdout,
+     you can display the image by pipping to "display" command of Imagemagic
+     or just redirect to file.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		plotConfig := getPlotConfigs(cmd)
+
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		complementary := getFlagBool(cmd, "complementary")
+		lineWidth := vg.Points(getFlagPositiveFloat64(cmd, "line-width"))
+		colorIndex := getFlagPositiveInt(cmd, "color-index")
+		if colorIndex > 7 {
+			checkError(fmt.Errorf("unsupported color index"))
+		}
+		if plotConfig.yaxis.trans != "" {
+			log.Warning("flag --y-trans ignored for command ecdf")
+			plotConfig.yaxis.trans = ""
+		}
+
+		groups := readPlotValueGroups(cmd, config, plotConfig, files[0],
+			plotConfig.dataFieldStr, []*plotAxisConfig{plotConfig.xaxis})
+
+		p := plotConfig.newPlot()
+		for i, name := range groups.names {
+			x := groups.values[i][0]
+			if len(x) == 0 {
+				continue
+			}
+
+			line, err := plotter.NewLine(ecdfPoints(x, complementary))
+			checkError(err)
+			line.StepStyle = plotter.PostStep
+			if complementary { // left-continuous
+				line.StepStyle = plotter.PreStep
+			}
+			line.Color = plotConfig.color(colorIndex - 1 + i)
+			line.LineStyle.Dashes = plotutil.Dashes(colorIndex - 1 + i)
+			line.LineStyle.Width = lineWidth
+			p.Add(line)
+			if plotConfig.groupFieldStr != "" {
+				p.Legend.Add(name, line)
+			}
+		}
+		p.Legend.Top = getFlagBool(cmd, "legend-top")
+		p.Legend.Left = getFlagBool(cmd, "legend-left")
+
+		if plotConfig.xlab == "" && len(groups.header) > 0 {
+			plotConfig.xlab = plotConfig.xaxis.Label(groups.header[0])
+		}
+		if plotConfig.ylab == "" {
+			if complementary {
+				plotConfig.ylab = "1 - ECDF"
+			} else {
+				plotConfig.ylab = "ECDF"
+			}
+		}
+
+		plotConfig.applyStyle(p)
+		plotConfig.applyLimits(p)
+		plotConfig.applyScales(p)
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
+		}
+
+		// Save image
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
+	},
+}
+
+// ecdfPoints returns points of the step function of the ECDF, to be drawn with plotter.PostStep.
+// For the complementary ECDF, Y is the fraction of values greater than or equal to X,
+// which is always positive and suitable for log scales, to be drawn with plotter.PreStep.
+func ecdfPoints(x []float64, complementary bool) plotter.XYs {
+	sorted := append([]float64{}, x...)
+	sort.Float64s(sorted)
+	n := float64(len(sorted))
+
+	xys := make(plotter.XYs, 0, len(sorted)+1)
+	if complementary {
+		for i, v := range sorted {
+			if i > 0 && v == sorted[i-1] {
+				continue
+			}
+			xys = append(xys, plotter.XY{X: v, Y: (n - float64(i)) / n})
+		}
+		return xys
+	}
+
+	xys = append(xys, plotter.XY{X: sorted[0], Y: 0})
+	for i, v := range sorted {
+		if i+1 < len(sorted) && sorted[i+1] == v {
+			continue
+		}
+		xys = append(xys, plotter.XY{X: v, Y: float64(i+1) / n})
+	}
+	return xys
+}
+
+func init() {
+	plotCmd.AddCommand(ecdfCmd)
+
+	ecdfCmd.Flags().BoolP("complementary", "", false, "plot the complementary ECDF, i.e., 1 - ECDF")
+
+	ecdfCmd.Flags().BoolP("legend-top", "", false, "locate legend along the top edge of the plot")
+	ecdfCmd.Flags().BoolP("legend-left", "", false, "locate legend along the left edge of the plot")
+	ecdfCmd.Flags().Float64P("line-width", "", 1.5, "line width")
+	ecdfCmd.Flags().IntP("color-index", "", 1, `color index, 1-7`)
+}
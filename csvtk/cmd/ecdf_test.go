@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestECDFPoints(t *testing.T) {
+	x := []float64{3, 1, 2, 2}
+	tests := []struct {
+		complementary bool
+		xs, ys        []float64
+	}{
+		{false, []float64{1, 1, 2, 3}, []float64{0, 0.25, 0.75, 1}},
+		{true, []float64{1, 2, 3}, []float64{1, 0.75, 0.25}},
+	}
+	for _, test := range tests {
+		xys := ecdfPoints(x, test.complementary)
+		if len(xys) != len(test.xs) {
+			t.Errorf("complementary: %v, expected %d points, returned %d", test.complementary, len(test.xs), len(xys))
+			continue
+		}
+		for i, xy := range xys {
+			if xy.X != test.xs[i] || xy.Y != test.ys[i] {
+				t.Errorf("complementary: %v, point %d: expected (%g, %g), returned (%g, %g)",
+					test.complementary, i, test.xs[i], test.ys[i], xy.X, xy.Y)
+			}
+		}
+	}
+}
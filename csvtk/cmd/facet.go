@@ -0,0 +1,272 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+// plotFacets splits data into sub-plots by values of a field.
+type plotFacets struct {
+	field  string
+	cols   int
+	scales string // fixed, free, free_x, or free_y
+
+	keys  []string // facet values in order of appearance
+	index map[string]int
+
+	colorBar *plot.Plot // optional, drawn at the right side of sub-plots
+}
+
+func getPlotFacets(cmd *cobra.Command) *plotFacets {
+	f := &plotFacets{
+		field:  getFlagString(cmd, "facet"),
+		cols:   getFlagNonNegativeInt(cmd, "facet-cols"),
+		scales: strings.ToLower(getFlagString(cmd, "facet-scales")),
+		index:  make(map[string]int),
+	}
+	if f.field == "" {
+		return f
+	}
+	if strings.Contains(f.field, ",") {
+		checkError(fmt.Errorf("only one field allowed for flag --facet"))
+	}
+	if f.field[0] == '-' {
+		checkError(fmt.Errorf("unselect not allowed for flag --facet"))
+	}
+	switch f.scales {
+	case "fixed", "free", "free_x", "free_y":
+	default:
+		checkError(fmt.Errorf("invalid value of flag --facet-scales: %s. available: fixed, free, free_x, free_y", f.scales))
+	}
+	return f
+}
+
+// Enabled tells whether --facet is given.
+func (f *plotFacets) Enabled() bool {
+	return f.field != ""
+}
+
+// Split removes the facet field, which should be the last selected field,
+// from the header row and data, and returns the facet index of each row.
+func (f *plotFacets) Split(headerRow []string, data [][]string) ([]string, []int) {
+	rowFacets := make([]int, len(data))
+	if !f.Enabled() {
+		return headerRow, rowFacets
+	}
+	if len(headerRow) > 0 {
+		headerRow = headerRow[:len(headerRow)-1]
+	}
+	var i, last int
+	var ok bool
+	for r, d := range data {
+		last = len(d) - 1
+		if i, ok = f.index[d[last]]; !ok {
+			i = len(f.keys)
+			f.index[d[last]] = i
+			f.keys = append(f.keys, d[last])
+		}
+		rowFacets[r] = i
+		data[r] = d[:last]
+	}
+	return headerRow, rowFacets
+}
+
+// N returns the number of sub-plots.
+func (f *plotFacets) N() int {
+	if len(f.keys) == 0 {
+		return 1
+	}
+	return len(f.keys)
+}
+
+// Grid returns the numbers of rows and columns of n sub-plots.
+func (f *plotFacets) Grid(n int) (rows, cols int) {
+	cols = f.cols
+	if cols == 0 {
+		cols = int(math.Ceil(math.Sqrt(float64(n))))
+	}
+	if cols > n {
+		cols = n
+	}
+	return (n + cols - 1) / cols, cols
+}
+
+// Save unifies axis ranges, applies axis scales, and saves sub-plots in a grid,
+// with facet values as titles of sub-plots. Empty sub-plots are skipped.
+func (f *plotFacets) Save(plots []*plot.Plot, plotConfig *plotConfigs, file string) error {
+	n := len(plots)
+	rows, cols := f.Grid(n)
+
+	sharedX := f.scales == "fixed" || f.scales == "free_y"
+	sharedY := f.scales == "fixed" || f.scales == "free_x"
+	xmin, xmax := math.Inf(1), math.Inf(-1)
+	ymin, ymax := math.Inf(1), math.Inf(-1)
+	// axis labels are only shown in the bottom row and the first column,
+	// unless they differ between sub-plots.
+	var xlab, ylab string
+	sameXLabel, sameYLabel := true, true
+	first := true
+	for _, p := range plots {
+		if p == nil {
+			continue
+		}
+		xmin, xmax = math.Min(xmin, p.X.Min), math.Max(xmax, p.X.Max)
+		ymin, ymax = math.Min(ymin, p.Y.Min), math.Max(ymax, p.Y.Max)
+		if first {
+			xlab, ylab = p.X.Label.Text, p.Y.Label.Text
+			first = false
+			continue
+		}
+		sameXLabel = sameXLabel && p.X.Label.Text == xlab
+		sameYLabel = sameYLabel && p.Y.Label.Text == ylab
+	}
+
+	grid := make([][]*plot.Plot, rows)
+	for r := range grid {
+		grid[r] = make([]*plot.Plot, cols)
+	}
+	for i, p := range plots {
+		if p == nil {
+			continue
+		}
+		if sharedX {
+			p.X.Min, p.X.Max = xmin, xmax
+		}
+		if sharedY {
+			p.Y.Min, p.Y.Max = ymin, ymax
+		}
+		plotConfig.applyScales(p)
+
+		p.Title.Text = f.keys[i]
+		p.Title.TextStyle.Font.Size = plotConfig.labelSize
+		if sameXLabel && i+cols < n { // not in the bottom row
+			p.X.Label.Text = ""
+		}
+		if sameYLabel && i%cols != 0 { // not in the first column
+			p.Y.Label.Text = ""
+		}
+		grid[i/cols][i%cols] = p
+	}
+
+	fn := func(dc draw.Canvas) {
+		if plotConfig.title != "" {
+			style := plot.New().Title.TextStyle
+			style.Font.Size = plotConfig.titleSize
+			style.XAlign = text.XCenter
+			style.YAlign = text.YTop
+			pt := vg.Point{X: (dc.Min.X + dc.Max.X) / 2, Y: dc.Max.Y}
+			dc.FillText(style, pt, plotConfig.title)
+			dc = draw.Crop(dc, 0, 0, 0, -style.Height(plotConfig.title)-vg.Points(4))
+		}
+		tiles := draw.Tiles{
+			Rows: rows, Cols: cols,
+			PadX: vg.Points(8), PadY: vg.Points(8),
+			PadTop: vg.Points(2), PadRight: vg.Points(4),
+		}
+		canvases := plot.Align(grid, tiles, dc)
+		for r := range grid {
+			for c, p := range grid[r] {
+				if p != nil {
+					p.Draw(canvases[r][c])
+				}
+			}
+		}
+	}
+
+	if f.colorBar != nil {
+		drawPlots := fn
+		fn = func(dc draw.Canvas) {
+			drawWithColorBar(dc, f.colorBar, drawPlots)
+		}
+		plots = append(plots, f.colorBar)
+	}
+
+	if plotConfig.term {
+		return plotConfig.saveTerm(file, fn, plots...)
+	}
+	return plotConfig.saveImage(file, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, fn)
+}
+
+// saveImage draws on a canvas of the given size with fn, and writes the image
+// to stdout in the given format, or to a file in the format of the file extension.
+// The DPI and background of the theme are used.
+func (config *plotConfigs) saveImage(file string, width, height vg.Length, fn func(dc draw.Canvas)) (err error) {
+	format := config.format
+	if !isStdin(file) {
+		format = strings.TrimPrefix(filepath.Ext(trimCompressSuffix(file)), ".")
+	}
+	outfh, err := wopenByConfig(config.global, file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := outfh.Close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	var c vg.CanvasWriterTo
+	background := config.background
+	if background == nil {
+		background = color.White
+	}
+	dpi := config.dpi
+	if dpi <= 0 {
+		dpi = vgimg.DefaultDPI
+	}
+	newImage := func() *vgimg.Canvas {
+		return vgimg.NewWith(vgimg.UseWH(width, height), vgimg.UseDPI(dpi), vgimg.UseBackgroundColor(background))
+	}
+	switch strings.ToLower(format) {
+	case "png":
+		c = vgimg.PngCanvas{Canvas: newImage()}
+	case "jpg", "jpeg":
+		c = vgimg.JpegCanvas{Canvas: newImage()}
+	case "tif", "tiff":
+		c = vgimg.TiffCanvas{Canvas: newImage()}
+	default:
+		c, err = draw.NewFormattedCanvas(width, height, strings.ToLower(format))
+		if err != nil {
+			return err
+		}
+	}
+	dc := draw.New(c)
+	if config.background != nil { // areas not covered by plots, e.g., in vector images
+		dc.SetColor(config.background)
+		dc.Fill(dc.Rectangle.Path())
+	}
+	fn(dc)
+	_, err = c.WriteTo(outfh)
+	return err
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestPlotFacetsSplit(t *testing.T) {
+	f := &plotFacets{field: "g", index: make(map[string]int)}
+	header := []string{"x", "g"}
+	data := [][]string{{"1", "b"}, {"2", "a"}, {"3", "b"}}
+	header, rowFacets := f.Split(header, data)
+	if len(header) != 1 || header[0] != "x" {
+		t.Errorf("unexpected header row: %v", header)
+	}
+	expected := []int{0, 1, 0}
+	for i, d := range data {
+		if len(d) != 1 {
+			t.Errorf("facet field not removed: %v", d)
+		}
+		if rowFacets[i] != expected[i] {
+			t.Errorf("row %d: expected facet %d, returned %d", i, expected[i], rowFacets[i])
+		}
+	}
+	if f.N() != 2 || f.keys[0] != "b" || f.keys[1] != "a" {
+		t.Errorf("unexpected facets: %v", f.keys)
+	}
+}
+
+func TestPlotFacetsGrid(t *testing.T) {
+	tests := []struct {
+		cols, n     int
+		rows, cols2 int
+	}{
+		{0, 1, 1, 1},
+		{0, 3, 2, 2},
+		{0, 10, 3, 4},
+		{3, 7, 3, 3},
+		{5, 2, 1, 2},
+	}
+	for _, test := range tests {
+		f := &plotFacets{cols: test.cols}
+		rows, cols := f.Grid(test.n)
+		if rows != test.rows || cols != test.cols2 {
+			t.Errorf("%d plots with --facet-cols %d: expected %dx%d, returned %dx%d",
+				test.n, test.cols, test.rows, test.cols2, rows, cols)
+		}
+	}
+}
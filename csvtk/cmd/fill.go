@@ -0,0 +1,422 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+	"gonum.org/v1/gonum/stat"
+)
+
+// fillCmd represents the fill command
+var fillCmd = &cobra.Command{
+	GroupID: "edit",
+
+	Use:   "fill",
+	Short: "fill NA values of selected fields",
+	Long: `fill NA values of selected fields
+
+Methods:
+
+  ffill     forward fill, i.e., using the last non-NA value above
+  bfill     backward fill, i.e., using the next non-NA value below
+  const     a constant value given by -v/--value
+  mean      mean of numeric values in the column
+  median    median of numeric values in the column
+  mode      the most frequent value in the column, ties are broken by the first appearance
+  linear    linear interpolation of numeric values, NA values in the head
+            or tail are not filled. Rows are ordered by the numeric values
+            of --by if given, otherwise by their positions
+
+Attention:
+
+  1. All methods are applied within groups if -g/--groups is given.
+  2. Non-numeric values are ignored by mean, median and linear.
+  3. Output of mean, median and linear are formatted with -w/--decimal-width.
+  4. Methods except ffill and const need to read all data into memory.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		fieldStr := getFlagString(cmd, "fields")
+		if fieldStr == "" {
+			checkError(fmt.Errorf("flag -f (--fields) needed"))
+		}
+		fuzzyFields := getFlagBool(cmd, "fuzzy-fields")
+		groupsStr := getFlagString(cmd, "groups")
+		byStr := getFlagString(cmd, "by")
+		method := strings.ToLower(getFlagString(cmd, "method"))
+		value := getFlagString(cmd, "value")
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		naMap := naValuesMap(getFlagStringSlice(cmd, "na-values"))
+
+		switch method {
+		case "ffill", "bfill", "mean", "median", "mode":
+		case "linear":
+		case "const":
+			if !cmd.Flags().Changed("value") {
+				checkError(fmt.Errorf("flag -v (--value) needed for method const"))
+			}
+		default:
+			checkError(fmt.Errorf("invalid method: %s. available: ffill, bfill, const, mean, median, mode, linear", method))
+		}
+		if byStr != "" && method != "linear" {
+			log.Warningf("flag --by is only used for method linear")
+		}
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+		defer outfh.Close()
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+		defer func() {
+			writer.Flush()
+			checkError(writer.Error())
+		}()
+
+		file := files[0]
+		csvReader, err := newCSVReaderByConfig(config, file)
+		if err != nil {
+			if err == xopen.ErrNoContent {
+				if config.Verbose {
+					log.Warningf("csvtk fill: skipping empty input file: %s", file)
+				}
+				return
+			}
+			checkError(err)
+		}
+
+		csvReader.Read(ReadOption{
+			FieldStr:    fieldStr,
+			FuzzyFields: fuzzyFields,
+		})
+
+		streaming := method == "ffill" || method == "const"
+
+		var fields []int
+		var groupFields []int
+		byField := -1
+		var rows [][]string
+		last := make(map[string][]*string, 8) // group -> last non-NA values of fields, for ffill
+		var group string
+		var i, f int
+		var ok bool
+		var values []*string
+
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				fields = record.Fields
+				var header []string
+				hasHeaderRow := !config.NoHeaderRow || record.IsHeaderRow
+				if hasHeaderRow {
+					header = record.All
+				}
+				opt := &ReadOption{FuzzyFields: fuzzyFields}
+				if groupsStr != "" {
+					groupFields, err = resolveFieldsOfHeader(groupsStr, header, len(record.All), opt, file)
+					checkError(err)
+				}
+				if byStr != "" && method == "linear" {
+					byFields, err := resolveFieldsOfHeader(byStr, header, len(record.All), opt, file)
+					checkError(err)
+					if len(byFields) != 1 {
+						checkError(fmt.Errorf("flag --by should match exactly one column: %s", byStr))
+					}
+					byField = byFields[0]
+				}
+
+				if hasHeaderRow {
+					if !config.NoOutHeader {
+						checkError(writer.Write(record.All))
+					}
+					continue
+				}
+			}
+
+			if !streaming {
+				rows = append(rows, record.All)
+				continue
+			}
+
+			if method == "const" {
+				for _, f = range fields {
+					if f > len(record.All) {
+						continue
+					}
+					if isNA(record.All[f-1], naMap) {
+						record.All[f-1] = value
+					}
+				}
+				checkError(writer.Write(record.All))
+				continue
+			}
+
+			// ffill
+			group = groupKey(record.All, groupFields)
+			if values, ok = last[group]; !ok {
+				values = make([]*string, len(fields))
+				last[group] = values
+			}
+			for i, f = range fields {
+				if f > len(record.All) {
+					continue
+				}
+				if isNA(record.All[f-1], naMap) {
+					if values[i] != nil {
+						record.All[f-1] = *values[i]
+					}
+				} else {
+					v := record.All[f-1]
+					values[i] = &v
+				}
+			}
+			checkError(writer.Write(record.All))
+		}
+
+		readerReport(&config, csvReader, file)
+
+		if streaming {
+			return
+		}
+
+		// row indexes of groups, in the order of appearance
+		groups := make([]string, 0, 8)
+		group2rows := make(map[string][]int, 8)
+		for i, row := range rows {
+			group = groupKey(row, groupFields)
+			if _, ok = group2rows[group]; !ok {
+				groups = append(groups, group)
+			}
+			group2rows[group] = append(group2rows[group], i)
+		}
+
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+		for _, group = range groups {
+			idx := group2rows[group]
+			switch method {
+			case "bfill":
+				for _, f = range fields {
+					var next *string
+					for j := len(idx) - 1; j >= 0; j-- {
+						row := rows[idx[j]]
+						if f > len(row) {
+							continue
+						}
+						if isNA(row[f-1], naMap) {
+							if next != nil {
+								row[f-1] = *next
+							}
+						} else {
+							next = &row[f-1]
+						}
+					}
+				}
+			case "mean", "median", "mode":
+				for _, f = range fields {
+					var fill string
+					if method == "mode" {
+						fill = modeOfColumn(rows, idx, f-1, naMap)
+					} else {
+						nums := numbersOfColumn(rows, idx, f-1, naMap)
+						if len(nums) > 0 {
+							if method == "mean" {
+								fill = fmt.Sprintf(decimalFormat, stat.Mean(nums, nil))
+							} else {
+								sort.Float64s(nums)
+								fill = fmt.Sprintf(decimalFormat, median(nums))
+							}
+						}
+					}
+					if fill == "" {
+						continue
+					}
+					for _, j := range idx {
+						if f <= len(rows[j]) && isNA(rows[j][f-1], naMap) {
+							rows[j][f-1] = fill
+						}
+					}
+				}
+			case "linear":
+				for _, f = range fields {
+					interpolateColumn(rows, idx, f-1, byField, naMap, decimalFormat)
+				}
+			}
+		}
+
+		for _, row := range rows {
+			checkError(writer.Write(row))
+		}
+	},
+}
+
+func groupKey(row []string, fields []int) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	items := make([]string, len(fields))
+	for i, f := range fields {
+		if f <= len(row) {
+			items[i] = row[f-1]
+		}
+	}
+	return strings.Join(items, "_shenwei356_")
+}
+
+// numbersOfColumn returns numeric values of the column j in rows with the given indexes.
+func numbersOfColumn(rows [][]string, idx []int, j int, naMap map[string]struct{}) []float64 {
+	nums := make([]float64, 0, len(idx))
+	for _, i := range idx {
+		if j >= len(rows[i]) || isNA(rows[i][j], naMap) {
+			continue
+		}
+		if v, ok := parseNumber(rows[i][j]); ok {
+			nums = append(nums, v)
+		}
+	}
+	return nums
+}
+
+// modeOfColumn returns the most frequent non-NA value of the column j in rows with the given indexes,
+// ties are broken by the first appearance.
+func modeOfColumn(rows [][]string, idx []int, j int, naMap map[string]struct{}) string {
+	counts := make(map[string]int, 8)
+	values := make([]string, 0, 8) // in order of first appearance
+	for _, i := range idx {
+		if j >= len(rows[i]) {
+			continue
+		}
+		v := rows[i][j]
+		if isNA(v, naMap) {
+			continue
+		}
+		if _, ok := counts[v]; !ok {
+			values = append(values, v)
+		}
+		counts[v]++
+	}
+	var mode string
+	var max int
+	for _, v := range values {
+		if counts[v] > max {
+			mode, max = v, counts[v]
+		}
+	}
+	return mode
+}
+
+// interpolateColumn fills NA values of the column j in rows with the given indexes,
+// by linear interpolation between the nearest numeric values.
+// Rows are ordered by the numeric values of the column by (1-based), or positions if by < 0.
+func interpolateColumn(rows [][]string, idx []int, j int, by int, naMap map[string]struct{}, decimalFormat string) {
+	type point struct {
+		i int     // row index
+		x float64 // position
+	}
+	points := make([]point, 0, len(idx))
+	for k, i := range idx {
+		if j >= len(rows[i]) {
+			continue
+		}
+		if by < 0 {
+			points = append(points, point{i: i, x: float64(k)})
+			continue
+		}
+		if by > len(rows[i]) {
+			continue
+		}
+		x, ok := parseNumber(rows[i][by-1])
+		if !ok {
+			continue
+		}
+		points = append(points, point{i: i, x: x})
+	}
+	if by > 0 {
+		sort.SliceStable(points, func(a, b int) bool { return points[a].x < points[b].x })
+	}
+
+	var x0, y0 float64
+	var hasPrev bool
+	pending := make([]point, 0, 8)
+	for _, p := range points {
+		s := rows[p.i][j]
+		if isNA(s, naMap) {
+			if hasPrev {
+				pending = append(pending, p)
+			}
+			continue
+		}
+		y, ok := parseNumber(s)
+		if !ok {
+			hasPrev = false
+			pending = pending[:0]
+			continue
+		}
+		for _, q := range pending {
+			v := y0
+			if p.x != x0 {
+				v = y0 + (y-y0)*(q.x-x0)/(p.x-x0)
+			}
+			rows[q.i][j] = fmt.Sprintf(decimalFormat, v)
+		}
+		pending = pending[:0]
+		x0, y0, hasPrev = p.x, y, true
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(fillCmd)
+	fillCmd.Flags().StringP("fields", "f", "1", `select only these fields. type "csvtk cut -h" for examples`)
+	fillCmd.Flags().BoolP("fuzzy-fields", "F", false, `using fuzzy fields, e.g., -F -f "*name" or -F -f "id123*"`)
+	fillCmd.Flags().StringP("method", "m", "ffill", `fill method: ffill, bfill, const, mean, median, mode, linear`)
+	fillCmd.Flags().StringP("value", "v", "", `the constant value for method const`)
+	fillCmd.Flags().StringP("groups", "g", "", `fill within groups of these fields, e.g., -g 1,2 or -g columnA,columnB`)
+	fillCmd.Flags().StringP("by", "b", "", `order rows by the numeric values of this field for method linear`)
+	fillCmd.Flags().IntP("decimal-width", "w", 2, "limit floats to N decimal points")
+	fillCmd.Flags().StringSliceP("na-values", "", defaultNAValues, `NA values, case ignored`)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterpolateColumn(t *testing.T) {
+	naMap := naValuesMap(defaultNAValues)
+	rows := [][]string{
+		{"1", "NA"},
+		{"2", "1"},
+		{"4", "NA"},
+		{"3", ""},
+		{"5", "7"},
+		{"6"}, // ragged row
+		{"7", "NA"},
+	}
+	idx := []int{0, 1, 2, 3, 4, 5, 6}
+
+	// by positions
+	rows1 := make([][]string, len(rows))
+	for i, row := range rows {
+		rows1[i] = append([]string{}, row...)
+	}
+	interpolateColumn(rows1, idx, 1, -1, naMap, "%.1f")
+	expect := []string{"NA", "1", "3.0", "5.0", "7", "NA"}
+	for i, j := range []int{0, 1, 2, 3, 4, 6} {
+		if rows1[j][1] != expect[i] {
+			t.Errorf("by positions, row %d: expected %s, returned %s", j+1, expect[i], rows1[j][1])
+		}
+	}
+
+	// by the first column
+	interpolateColumn(rows, idx, 1, 1, naMap, "%.1f")
+	expect = []string{"NA", "1", "5.0", "3.0", "7", "NA"}
+	for i, j := range []int{0, 1, 2, 3, 4, 6} {
+		if rows[j][1] != expect[i] {
+			t.Errorf("by column 1, row %d: expected %s, returned %s", j+1, expect[i], rows[j][1])
+		}
+	}
+}
+
+func TestFillHelpers(t *testing.T) {
+	naMap := naValuesMap(defaultNAValues)
+	rows := [][]string{{"a", "1"}, {"b", "NA"}, {"a"}, {"c", "x"}, {"d", "1"}, {"e", "3"}}
+	idx := []int{0, 1, 2, 3, 4, 5}
+
+	if nums := numbersOfColumn(rows, idx, 1, naMap); !reflect.DeepEqual(nums, []float64{1, 1, 3}) {
+		t.Errorf("numbersOfColumn: unexpected %v", nums)
+	}
+	if mode := modeOfColumn(rows, idx, 1, naMap); mode != "1" {
+		t.Errorf("modeOfColumn: expected 1, returned %s", mode)
+	}
+	// ties are broken by the first appearance
+	ties := [][]string{{"a"}, {"b"}, {"b"}, {"NA"}, {"a"}}
+	if mode := modeOfColumn(ties, []int{0, 1, 2, 3, 4}, 0, naMap); mode != "a" {
+		t.Errorf("modeOfColumn with ties: expected a, returned %s", mode)
+	}
+	if key := groupKey(rows[2], []int{1, 2}); key != groupKey([]string{"a", ""}, []int{1, 2}) {
+		t.Errorf("groupKey: unexpected key of ragged row: %s", key)
+	}
+}
+
+func TestResolveFieldsOfHeader(t *testing.T) {
+	header := []string{"id", "group", "sub_group", "value"}
+	cases := []struct {
+		s      string
+		fuzzy  bool
+		expect []int
+	}{
+		{"group", false, []int{2}},
+		{"2,id", false, []int{2, 1}},
+		{"2-3", false, []int{2, 3}},
+		{"group:value", false, []int{2, 3, 4}},
+		{"*group", true, []int{2, 3}},
+		{"/group$/", false, []int{2, 3}},
+	}
+	for _, c := range cases {
+		fields, err := resolveFieldsOfHeader(c.s, header, len(header), &ReadOption{FuzzyFields: c.fuzzy}, "-")
+		if err != nil {
+			t.Errorf("resolve %s: %s", c.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, c.expect) {
+			t.Errorf("resolve %s: expected %v, returned %v", c.s, c.expect, fields)
+		}
+	}
+
+	// data without header row
+	if fields, err := resolveFieldsOfHeader("1,3-4", nil, 4, &ReadOption{}, "-"); err != nil || !reflect.DeepEqual(fields, []int{1, 3, 4}) {
+		t.Errorf("resolve 1,3-4 without header row: unexpected %v (%v)", fields, err)
+	}
+	for _, s := range []string{"group", "@int", "5"} {
+		if _, err := resolveFieldsOfHeader(s, nil, 4, &ReadOption{}, "-"); err == nil {
+			t.Errorf("resolve %s without header row: error expected", s)
+		}
+	}
+}
@@ -75,7 +75,7 @@ var filterCmd = &cobra.Command{
 		threshold, err := strconv.ParseFloat(items[0][3], 64)
 		checkError(err)
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -171,7 +171,7 @@ Custom functions:
 		var expression *govaluate.EvaluableExpression
 		var err error
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -407,6 +407,11 @@ Custom functions:
 					unshift(&record.All, strconv.Itoa(record.Row))
 				}
 				checkError(writer.Write(record.All))
+
+				if config.Follow { // emit matched records as they arrive
+					writer.Flush()
+					checkError(outfh.Flush())
+				}
 			}
 
 			readerReport(&config, csvReader, file)
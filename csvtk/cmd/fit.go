@@ -0,0 +1,406 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// fitCmd represents the fit command
+var fitCmd = &cobra.Command{
+	GroupID: "info",
+
+	Use:   "fit",
+	Short: "linear and polynomial regression",
+	Long: `linear and polynomial regression
+
+The response field (-y/--response) is regressed on one or more predictor
+fields (-x/--predictors) by ordinary least squares. With -n/--degree N,
+powers 1..N of each predictor are used as terms, without interactions.
+Rows with non-numeric values in any of the fields are ignored.
+
+Outputs:
+  default         coefficients: term, estimate, std_error, t_value, pvalue
+  -s/--stats      model statistics: n, df (residual degrees of freedom),
+                  r_squared, adj_r_squared, sigma (residual standard error),
+                  f_statistic, f_pvalue
+  -a/--append     append columns "fitted" and "residual" to each row,
+                  they are empty for ignored rows
+
+R-squared is computed with the uncentered total sum of squares if
+there's no intercept (--no-intercept), the same as R.
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		responseStr := getFlagString(cmd, "response")
+		if responseStr == "" {
+			checkError(fmt.Errorf("flag -y/--response needed"))
+		}
+		predictorsStr := getFlagString(cmd, "predictors")
+		if predictorsStr == "" {
+			checkError(fmt.Errorf("flag -x/--predictors needed"))
+		}
+		degree := getFlagPositiveInt(cmd, "degree")
+		intercept := !getFlagBool(cmd, "no-intercept")
+		outStats := getFlagBool(cmd, "stats")
+		appendCols := getFlagBool(cmd, "append")
+		if outStats && appendCols {
+			checkError(fmt.Errorf("flag -s/--stats and -a/--append are incompatible"))
+		}
+
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+		defer outfh.Close()
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+		defer func() {
+			writer.Flush()
+			checkError(writer.Error())
+		}()
+
+		file := files[0]
+
+		csvReader, err := newCSVReaderByConfig(config, file)
+
+		if err != nil {
+			if err == xopen.ErrNoContent {
+				if config.Verbose {
+					log.Warningf("csvtk fit: skipping empty input file: %s", file)
+				}
+				return
+			}
+			checkError(err)
+		}
+
+		csvReader.Read(ReadOption{
+			FieldStr: responseStr + "," + predictorsStr,
+
+			DoNotAllowDuplicatedColumnName: true,
+		})
+
+		var fieldY int
+		var fieldsX []int
+		var nameY string
+		var namesX []string
+		var header []string
+		var rows [][]string // all rows, for -a/--append
+		var used []bool     // whether a row is used for fitting
+		var xs [][]float64
+		var ys []float64
+
+		var i, f int
+		var x []float64
+		var y float64
+		var ok bool
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				if len(record.Fields) < 2 {
+					checkError(fmt.Errorf("a response field and predictor fields needed"))
+				}
+				fieldY = record.Fields[0]
+				fieldsX = record.Fields[1:]
+
+				namesX = make([]string, len(fieldsX))
+				if !config.NoHeaderRow || record.IsHeaderRow { // do not replace head line
+					header = record.All
+					nameY = record.All[fieldY-1]
+					for i, f = range fieldsX {
+						namesX[i] = record.All[f-1]
+					}
+					continue
+				}
+				nameY = strconv.Itoa(fieldY)
+				for i, f = range fieldsX {
+					namesX[i] = strconv.Itoa(f)
+				}
+			}
+
+			ok = true
+			y, err = strconv.ParseFloat(removeComma(record.All[fieldY-1]), 64)
+			if err != nil || math.IsNaN(y) {
+				ok = false
+			}
+			x = make([]float64, len(fieldsX))
+			if ok {
+				for i, f = range fieldsX {
+					x[i], err = strconv.ParseFloat(removeComma(record.All[f-1]), 64)
+					if err != nil || math.IsNaN(x[i]) {
+						ok = false
+						break
+					}
+				}
+			}
+			if ok {
+				xs = append(xs, x)
+				ys = append(ys, y)
+			}
+			if appendCols {
+				rows = append(rows, record.All)
+				used = append(used, ok)
+			}
+		}
+
+		readerReport(&config, csvReader, file)
+
+		model, err := fitLinearModel(xs, ys, namesX, degree, intercept)
+		checkError(err)
+
+		formatValue := func(v float64) string {
+			if math.IsNaN(v) {
+				return ""
+			}
+			return fmt.Sprintf(decimalFormat, v)
+		}
+
+		if appendCols {
+			if header != nil && !config.NoOutHeader {
+				checkError(writer.Write(append(header, "fitted", "residual")))
+			}
+			var j int
+			for k, row := range rows {
+				if !used[k] {
+					checkError(writer.Write(append(row, "", "")))
+					continue
+				}
+				checkError(writer.Write(append(row,
+					formatValue(model.fitted[j]), formatValue(model.residuals[j]))))
+				j++
+			}
+			return
+		}
+
+		if outStats {
+			if !config.NoOutHeader {
+				checkError(writer.Write([]string{"response", "n", "df", "r_squared", "adj_r_squared",
+					"sigma", "f_statistic", "f_pvalue"}))
+			}
+			fstat, fp := model.FStatistic()
+			checkError(writer.Write([]string{nameY, strconv.Itoa(model.n), strconv.Itoa(model.n - model.p),
+				formatValue(model.RSquared()), formatValue(model.AdjRSquared()),
+				formatValue(model.Sigma()), formatValue(fstat), formatPValue(fp)}))
+			return
+		}
+
+		if !config.NoOutHeader {
+			checkError(writer.Write([]string{"term", "estimate", "std_error", "t_value", "pvalue"}))
+		}
+		var t float64
+		df := float64(model.n - model.p)
+		for k, term := range model.terms {
+			t = model.coef[k] / model.se[k]
+			p := math.NaN()
+			if df > 0 && !math.IsNaN(t) {
+				p = 2 * distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}.Survival(math.Abs(t))
+			}
+			checkError(writer.Write([]string{term, formatValue(model.coef[k]),
+				formatValue(model.se[k]), formatValue(t), formatPValue(p)}))
+		}
+	},
+}
+
+// linearModel is a linear model fitted by ordinary least squares.
+type linearModel struct {
+	terms     []string
+	degree    int
+	intercept bool
+
+	n, p      int // numbers of observations and coefficients
+	coef, se  []float64
+	fitted    []float64
+	residuals []float64
+	rss, tss  float64
+}
+
+// designRow returns terms of a row of predictors.
+func (m *linearModel) designRow(x []float64, row []float64) {
+	var k int
+	if m.intercept {
+		row[0] = 1
+		k = 1
+	}
+	for _, v := range x {
+		for d := 1; d <= m.degree; d++ {
+			row[k] = math.Pow(v, float64(d))
+			k++
+		}
+	}
+}
+
+// Predict returns the predicted value of a row of predictors.
+func (m *linearModel) Predict(x []float64) float64 {
+	row := make([]float64, m.p)
+	m.designRow(x, row)
+	var v float64
+	for k, c := range m.coef {
+		v += c * row[k]
+	}
+	return v
+}
+
+// fitLinearModel fits y on powers 1..degree of predictors xs with QR decomposition.
+func fitLinearModel(xs [][]float64, y []float64, names []string, degree int, intercept bool) (*linearModel, error) {
+	m := &linearModel{degree: degree, intercept: intercept, n: len(y)}
+	if intercept {
+		m.terms = append(m.terms, "(Intercept)")
+	}
+	for _, name := range names {
+		m.terms = append(m.terms, name)
+		for d := 2; d <= degree; d++ {
+			m.terms = append(m.terms, fmt.Sprintf("%s^%d", name, d))
+		}
+	}
+	m.p = len(m.terms)
+	if m.n < m.p {
+		return nil, fmt.Errorf("too few observations (%d) to fit %d coefficients", m.n, m.p)
+	}
+
+	X := mat.NewDense(m.n, m.p, nil)
+	for i, x := range xs {
+		m.designRow(x, X.RawRowView(i))
+	}
+	Y := mat.NewVecDense(m.n, y)
+
+	var qr mat.QR
+	qr.Factorize(X)
+	var beta mat.VecDense
+	if err := qr.SolveVecTo(&beta, false, Y); err != nil {
+		return nil, fmt.Errorf("fail to fit the model: %s", err)
+	}
+	m.coef = make([]float64, m.p)
+	for k := range m.coef {
+		m.coef[k] = beta.AtVec(k)
+	}
+
+	var fitted mat.VecDense
+	fitted.MulVec(X, &beta)
+	m.fitted = make([]float64, m.n)
+	m.residuals = make([]float64, m.n)
+	var mean float64
+	if intercept {
+		for _, v := range y {
+			mean += v
+		}
+		mean /= float64(m.n)
+	}
+	for i, v := range y {
+		m.fitted[i] = fitted.AtVec(i)
+		m.residuals[i] = v - m.fitted[i]
+		m.rss += m.residuals[i] * m.residuals[i]
+		m.tss += (v - mean) * (v - mean)
+	}
+
+	// standard errors from the diagonal of sigma^2 (X'X)^-1
+	m.se = make([]float64, m.p)
+	var xtx, inv mat.Dense
+	xtx.Mul(X.T(), X)
+	if err := inv.Inverse(&xtx); err != nil || m.n == m.p {
+		for k := range m.se {
+			m.se[k] = math.NaN()
+		}
+		return m, nil
+	}
+	s2 := m.rss / float64(m.n-m.p)
+	for k := range m.se {
+		m.se[k] = math.Sqrt(s2 * inv.At(k, k))
+	}
+	return m, nil
+}
+
+// RSquared returns the coefficient of determination.
+func (m *linearModel) RSquared() float64 {
+	return 1 - m.rss/m.tss
+}
+
+// AdjRSquared returns the adjusted coefficient of determination.
+func (m *linearModel) AdjRSquared() float64 {
+	n, p := float64(m.n), float64(m.p)
+	if m.intercept {
+		return 1 - (1-m.RSquared())*(n-1)/(n-p)
+	}
+	return 1 - (1-m.RSquared())*n/(n-p)
+}
+
+// Sigma returns the residual standard error.
+func (m *linearModel) Sigma() float64 {
+	return math.Sqrt(m.rss / float64(m.n-m.p))
+}
+
+// FStatistic returns the F statistic and its p-value.
+func (m *linearModel) FStatistic() (float64, float64) {
+	df1 := float64(m.p)
+	if m.intercept {
+		df1--
+	}
+	df2 := float64(m.n - m.p)
+	if df1 == 0 || df2 == 0 {
+		return math.NaN(), math.NaN()
+	}
+	f := ((m.tss - m.rss) / df1) / (m.rss / df2)
+	return f, distuv.F{D1: df1, D2: df2}.Survival(f)
+}
+
+func init() {
+	RootCmd.AddCommand(fitCmd)
+
+	fitCmd.Flags().StringP("response", "y", "", "response field")
+	fitCmd.Flags().StringP("predictors", "x", "", "predictor fields")
+	fitCmd.Flags().IntP("degree", "n", 1, "polynomial degree")
+	fitCmd.Flags().BoolP("no-intercept", "", false, "fit without the intercept")
+	fitCmd.Flags().BoolP("stats", "s", false, "output model statistics instead of coefficients")
+	fitCmd.Flags().BoolP("append", "a", false, `append columns "fitted" and "residual" to each row`)
+	fitCmd.Flags().IntP("decimal-width", "w", 4, "limit floats to N decimal points")
+}
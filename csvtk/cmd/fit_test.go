@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitLinearModel(t *testing.T) {
+	// y = 1 + 2x - 0.5x^2
+	xs := [][]float64{{0}, {1}, {2}, {3}, {4}, {5}}
+	ys := make([]float64, len(xs))
+	for i, x := range xs {
+		ys[i] = 1 + 2*x[0] - 0.5*x[0]*x[0]
+	}
+
+	model, err := fitLinearModel(xs, ys, []string{"x"}, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{1, 2, -0.5}
+	for i, c := range model.coef {
+		if math.Abs(c-want[i]) > 1e-9 {
+			t.Errorf("coefficient of %s: expected %f, returned %f", model.terms[i], want[i], c)
+		}
+	}
+	if math.Abs(model.RSquared()-1) > 1e-9 {
+		t.Errorf("R-squared: expected 1, returned %f", model.RSquared())
+	}
+	if v := model.Predict([]float64{6}); math.Abs(v-(-5)) > 1e-9 {
+		t.Errorf("prediction: expected -5, returned %f", v)
+	}
+
+	if _, err = fitLinearModel(xs[:2], ys[:2], []string{"x"}, 2, true); err == nil {
+		t.Errorf("expected error for too few observations")
+	}
+}
@@ -75,7 +75,7 @@ Limitation:
 			config.Delimiter = '\t'
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -65,7 +65,7 @@ How to:
 			buf = make([][]string, 0, 1024)
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -23,11 +23,13 @@ package cmd
 import (
 	"encoding/csv"
 	"fmt"
+	"math"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/shenwei356/stable"
 	"github.com/shenwei356/util/stringutil"
 	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
@@ -41,6 +43,18 @@ var freqCmd = &cobra.Command{
 	Short: "frequencies of selected fields",
 	Long: `frequencies of selected fields
 
+Notes:
+  1. By default, keys are outputted in order of their last appearance.
+  2. With -K/--top, only the K most frequent keys are kept, and the remaining
+     keys are merged into one row labelled with --other-label. Kept keys are
+     in descending order of frequency, unless -n/--sort-by-freq, -k/--sort-by-key
+     or -r/--reverse is given.
+  3. With -g/--groups, frequencies, percentages, cumulative values and top
+     keys are computed within each group, groups are in order of appearance.
+  4. Percentages are relative to the total number of records (of the group).
+  5. With -b/--bar, an aligned table with a text bar chart is outputted
+     instead of CSV, bars are scaled to the maximum frequency (of the group).
+
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		config := getConfigs(cmd)
@@ -60,8 +74,17 @@ var freqCmd = &cobra.Command{
 		}
 
 		fuzzyFields := getFlagBool(cmd, "fuzzy-fields")
+		groupsStr := getFlagString(cmd, "groups")
+		percent := getFlagBool(cmd, "percent")
+		cumulative := getFlagBool(cmd, "cumulative")
+		topK := getFlagNonNegativeInt(cmd, "top")
+		otherLabel := getFlagString(cmd, "other-label")
+		bar := getFlagBool(cmd, "bar")
+		barWidth := getFlagPositiveInt(cmd, "bar-width")
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -80,9 +103,6 @@ var freqCmd = &cobra.Command{
 			checkError(writer.Error())
 		}()
 
-		counter := make(map[string]int, 10000)
-		orders := make(map[string]int, 10000)
-
 		file := files[0]
 		csvReader, err := newCSVReaderByConfig(config, file)
 
@@ -107,8 +127,16 @@ var freqCmd = &cobra.Command{
 			DoNotAllowDuplicatedColumnName: true,
 		})
 
-		var key string
-		var N int
+		// group -> key -> count
+		counters := make(map[string]map[string]int, 8)
+		// group -> key -> order of the last appearance
+		orders := make(map[string]map[string]int, 8)
+		groupOrder := newCategoryOrder()
+
+		var header []string
+		var groupFields []int
+		var key, group string
+		var f, N int
 
 		checkFirstLine := true
 		for record := range csvReader.Ch {
@@ -118,71 +146,194 @@ var freqCmd = &cobra.Command{
 
 			if checkFirstLine {
 				checkFirstLine = false
-				if !config.NoHeaderRow || record.IsHeaderRow {
-					if config.NoOutHeader {
-						continue
+				hasHeaderRow := !config.NoHeaderRow || record.IsHeaderRow
+				var colnames []string
+				if hasHeaderRow {
+					colnames = record.All
+				}
+				if groupsStr != "" {
+					groupFields, err = resolveFieldsOfHeader(groupsStr, colnames, len(record.All),
+						&ReadOption{FuzzyFields: fuzzyFields}, file)
+					checkError(err)
+				}
+
+				if hasHeaderRow {
+					for _, f = range groupFields {
+						header = append(header, record.All[f-1])
+					}
+					header = append(header, record.Selected...)
+					header = append(header, "frequency")
+					if percent {
+						header = append(header, "percentage")
+					}
+					if cumulative {
+						header = append(header, "cumulative_frequency")
+						if percent {
+							header = append(header, "cumulative_percentage")
+						}
 					}
-					checkError(writer.Write(append(record.Selected, "frequency")))
 					continue
 				}
 			}
 
 			N++
 
+			group = groupKey(record.All, groupFields)
+			groupOrder.Add(group)
+			if _, ok := counters[group]; !ok {
+				counters[group] = make(map[string]int, 1024)
+				orders[group] = make(map[string]int, 1024)
+			}
 			key = strings.Join(record.Selected, "_shenwei356_")
-			counter[key]++
-			orders[key] = N
+			counters[group][key]++
+			orders[group][key] = N
+		}
+
+		readerReport(&config, csvReader, file)
+
+		var tbl *stable.Table
+		var bars []string
+		if bar {
+			tbl = stable.New()
+			if header != nil && !config.NoOutHeader {
+				_, err = tbl.Header(header)
+				checkError(err)
+				bars = append(bars, "")
+			}
+		} else if header != nil && !config.NoOutHeader {
+			checkError(writer.Write(header))
 		}
 
 		var items []string
-		if sortByFreq {
-			counts := make([]stringutil.StringCount, len(counter))
-			i := 0
-			for key, count := range counter {
-				counts[i] = stringutil.StringCount{Key: key, Count: count}
-				i++
+		for _, group = range groupOrder.Keys() {
+			counter := counters[group]
+
+			keys, others := freqKeys(counter, orders[group], topK, sortByFreq, sortByKey, reverse)
+			var total int
+			for _, count := range counter {
+				total += count
 			}
-			if reverse {
-				sort.Sort(stringutil.ReversedStringCountList{counts})
-			} else {
-				sort.Sort(stringutil.StringCountList(counts))
+
+			var groupItems []string
+			if len(groupFields) > 0 {
+				groupItems = strings.Split(group, "_shenwei356_")
 			}
-			for _, count := range counts {
-				items = strings.Split(count.Key, "_shenwei356_")
-				items = append(items, strconv.Itoa(counter[count.Key]))
-				checkError(writer.Write(items))
+
+			counts := make([]int, 0, len(keys)+1)
+			rows := make([][]string, 0, len(keys)+1)
+			for _, key = range keys {
+				counts = append(counts, counter[key])
+				rows = append(rows, strings.Split(key, "_shenwei356_"))
 			}
-		} else if sortByKey {
-			keys := make([]string, len(counter))
-			i := 0
-			for key := range counter {
-				keys[i] = key
-				i++
+			if others > 0 {
+				counts = append(counts, others)
+				items = make([]string, len(rows[0]))
+				for i := range items {
+					items[i] = otherLabel
+				}
+				rows = append(rows, items)
 			}
 
-			sort.Strings(keys)
-			if reverse {
-				stringutil.ReverseStringSliceInplace(keys)
+			var maxCount int
+			for _, count := range counts {
+				if count > maxCount {
+					maxCount = count
+				}
 			}
 
-			for _, key := range keys {
-				items = strings.Split(key, "_shenwei356_")
-				items = append(items, strconv.Itoa(counter[key]))
-				checkError(writer.Write(items))
-			}
-		} else {
-			orderedKey := stringutil.SortCountOfString(orders, false)
-			for _, o := range orderedKey {
-				items = strings.Split(o.Key, "_shenwei356_")
-				items = append(items, strconv.Itoa(counter[o.Key]))
+			var cum int
+			for i, count := range counts {
+				cum += count
+				items = make([]string, 0, len(groupItems)+len(rows[i])+5)
+				items = append(items, groupItems...)
+				items = append(items, rows[i]...)
+				items = append(items, strconv.Itoa(count))
+				if percent {
+					items = append(items, fmt.Sprintf(decimalFormat, float64(count)/float64(total)*100))
+				}
+				if cumulative {
+					items = append(items, strconv.Itoa(cum))
+					if percent {
+						items = append(items, fmt.Sprintf(decimalFormat, float64(cum)/float64(total)*100))
+					}
+				}
+
+				if bar {
+					checkError(tbl.AddRowStringSlice(items))
+					bars = append(bars, textBar(float64(count)/float64(maxCount)*float64(barWidth)))
+					continue
+				}
 				checkError(writer.Write(items))
 			}
 		}
 
-		readerReport(&config, csvReader, file)
+		// bars are appended to rendered lines, as the table aligns cells by bytes
+		if bar && len(bars) > 0 {
+			style, err := prettyTableStyle("plain", "")
+			checkError(err)
+			lines := strings.Split(strings.TrimRight(string(tbl.Render(style)), "\n"), "\n")
+			for i, line := range lines {
+				if bars[i] == "" {
+					fmt.Fprintln(outfh, strings.TrimRight(line, " "))
+					continue
+				}
+				fmt.Fprintf(outfh, "%s   %s\n", line, bars[i])
+			}
+		}
 	},
 }
 
+// freqKeys returns keys in the output order, and the total count of keys
+// not in the top K. The K most frequent keys are chosen first, ties broken
+// by key, and then ordered by -n/--sort-by-freq, -k/--sort-by-key and
+// -r/--reverse, or in descending order of frequency by default.
+// Without -K/--top, keys are in order of their last appearance by default.
+func freqKeys(counter map[string]int, orders map[string]int, topK int,
+	sortByFreq, sortByKey, reverse bool) ([]string, int) {
+
+	var others int
+	if topK > 0 && len(counter) > topK {
+		top := stringutil.SortCountOfString(counter, true)
+		selected := make(map[string]int, topK)
+		for i, count := range top {
+			if i < topK {
+				selected[count.Key] = count.Count
+			} else {
+				others += count.Count
+			}
+		}
+		counter = selected
+	}
+
+	var keys []string
+	var counts stringutil.StringCountList
+	if sortByFreq || (topK > 0 && !sortByKey) {
+		// ascending with -n, otherwise descending, reversed by -r
+		counts = stringutil.SortCountOfString(counter, sortByFreq == reverse)
+	} else if sortByKey {
+		keys = make([]string, 0, len(counter))
+		for key := range counter {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if reverse {
+			stringutil.ReverseStringSliceInplace(keys)
+		}
+		return keys, others
+	} else {
+		appearance := make(map[string]int, len(counter))
+		for key := range counter {
+			appearance[key] = orders[key]
+		}
+		counts = stringutil.SortCountOfString(appearance, false)
+	}
+	keys = make([]string, len(counts))
+	for i, count := range counts {
+		keys[i] = count.Key
+	}
+	return keys, others
+}
+
 func init() {
 	RootCmd.AddCommand(freqCmd)
 	freqCmd.Flags().StringP("fields", "f", "1", `select these fields as the key. e.g -f 1,2 or -f columnA,columnB`)
@@ -191,4 +342,20 @@ func init() {
 	freqCmd.Flags().BoolP("sort-by-freq", "n", false, `sort by frequency`)
 	freqCmd.Flags().BoolP("sort-by-key", "k", false, `sort by key`)
 	freqCmd.Flags().BoolP("reverse", "r", false, `reverse order while sorting`)
+	freqCmd.Flags().StringP("groups", "g", "", `compute frequencies within groups of these fields. e.g -g 1,2 or -g columnA,columnB`)
+	freqCmd.Flags().BoolP("percent", "p", false, `add a column of percentages`)
+	freqCmd.Flags().BoolP("cumulative", "c", false, `add columns of cumulative frequencies (and cumulative percentages with -p/--percent)`)
+	freqCmd.Flags().IntP("top", "K", 0, `only keep the top K most frequent keys, others are merged into one row, 0 for all`)
+	freqCmd.Flags().StringP("other-label", "", "Other", `label of the merged row of keys not in the top K`)
+	freqCmd.Flags().BoolP("bar", "b", false, `output an aligned table with a bar chart of frequencies`)
+	freqCmd.Flags().IntP("bar-width", "", 40, `maximum width of bars`)
+	freqCmd.Flags().IntP("decimal-width", "w", 2, "limit floats to N decimal points")
+}
+
+var barBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// textBar returns a bar of a width in characters, with a precision of 1/8.
+func textBar(width float64) string {
+	eighths := int(math.Round(width * 8))
+	return strings.Repeat("█", eighths/8) + barBlocks[eighths%8]
 }
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFreqKeys(t *testing.T) {
+	// apple 4, banana 2, cherry 1, date 2, with the order of last appearance
+	counter := map[string]int{"apple": 4, "banana": 2, "cherry": 1, "date": 2}
+	orders := map[string]int{"apple": 7, "banana": 6, "cherry": 5, "date": 1}
+
+	tests := []struct {
+		topK                         int
+		sortByFreq, sortByKey, rever bool
+		keys                         []string
+		others                       int
+	}{
+		{0, false, false, false, []string{"date", "cherry", "banana", "apple"}, 0},
+		{0, true, false, false, []string{"cherry", "banana", "date", "apple"}, 0},
+		{0, true, false, true, []string{"apple", "banana", "date", "cherry"}, 0},
+		{0, false, true, true, []string{"date", "cherry", "banana", "apple"}, 0},
+
+		// the most frequent keys are kept, in descending order by default
+		{1, false, false, false, []string{"apple"}, 5},
+		{1, true, false, false, []string{"apple"}, 5},
+		{3, false, false, false, []string{"apple", "banana", "date"}, 1},
+		{3, true, false, false, []string{"banana", "date", "apple"}, 1},
+		{3, true, false, true, []string{"apple", "banana", "date"}, 1},
+		{3, false, true, false, []string{"apple", "banana", "date"}, 1},
+		{3, false, false, true, []string{"banana", "date", "apple"}, 1},
+		{4, true, false, false, []string{"cherry", "banana", "date", "apple"}, 0},
+	}
+	for i, test := range tests {
+		keys, others := freqKeys(counter, orders, test.topK, test.sortByFreq, test.sortByKey, test.rever)
+		if !reflect.DeepEqual(keys, test.keys) || others != test.others {
+			t.Errorf("case %d: expected %v, %d, returned %v, %d", i, test.keys, test.others, keys, others)
+		}
+	}
+}
@@ -82,7 +82,7 @@ Attentions:
 		printLineNumber := getFlagBool(cmd, "line-number") || config.ShowRowNumber
 		deleteMatched := getFlagBool(cmd, "delete-matched")
 
-		immediateOutput := getFlagBool(cmd, "immediate-output")
+		immediateOutput := getFlagBool(cmd, "immediate-output") || config.Follow
 
 		patternsMap := make(map[string]*regexp.Regexp)
 		for _, pattern := range patterns {
@@ -141,15 +141,15 @@ Attentions:
 
 		var writer *csv.Writer
 		var outfhStd io.Writer
-		var outfhFile *xopen.Writer
+		var outfhFile *outWriter
 		var err error
-		isstdin := isStdin(config.OutFile)
+		isstdin := isStdin(config.OutFile) && compressFormatOfFile(config, config.OutFile) == "none"
 		if isstdin {
 			outfhStd = colorable.NewColorableStdout()
 			writer = csv.NewWriter(outfhStd)
 		} else {
 			noHighlight = true
-			outfhFile, err = xopen.Wopen(config.OutFile)
+			outfhFile, err = wopenByConfig(config, config.OutFile)
 			checkError(err)
 			defer outfhFile.Close()
 			writer = csv.NewWriter(outfhFile)
@@ -298,6 +298,9 @@ Attentions:
 
 				if immediateOutput {
 					writer.Flush()
+					if outfhFile != nil {
+						checkError(outfhFile.Flush())
+					}
 				}
 			}
 
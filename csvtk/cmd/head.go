@@ -45,7 +45,7 @@ var headCmd = &cobra.Command{
 
 		number := getFlagPositiveInt(cmd, "number")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
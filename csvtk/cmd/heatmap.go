@@ -0,0 +1,664 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/palette/moreland"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// heatmapCmd represents the heatmap command
+var heatmapCmd = &cobra.Command{
+	Use:   "heatmap",
+	Short: "heatmap of a matrix",
+	Long: `heatmap of a matrix
+
+Input formats:
+  1. A wide matrix (default): the first column contains row labels,
+     and the header row contains column labels. Non-numeric values are
+     treated as missing values and drawn in gray.
+  2. Long triples: with -v/--value-field, each row contains a column label
+     (-x/--x-field), a row label (-y/--y-field) and a value. Rows and
+     columns are in order of appearance, and duplicated values are averaged.
+
+Palettes (--palette):
+  auto                  blue-red if values are both negative and positive,
+                        otherwise extended-kindlmann
+  diverging:            blue-red, blue-tan, green-purple, green-red,
+                        purple-orange. The range is symmetric around 0
+                        unless --value-min or --value-max is given.
+  sequential:           black-body, extended-black-body, kindlmann,
+                        extended-kindlmann, viridis
+
+Rows and columns can be ordered by hierarchical clustering
+(average linkage of Euclidean distances) with --cluster-rows and
+--cluster-cols.
+
+Notes:
+
+  1. Output file can be set by flag -o/--out-file.
+  2. File format is determined by the out file suffix.
+     Supported formats: eps, jpg|jpeg, pdf, png, svg, and tif|tiff
+  3. If flag -o/--out-file not set (default), image is written to stdout,
+     you can display the image by pipping to "display" command of Imagemagic
+     or just redirect to file.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		plotConfig := getPlotConfigs(cmd)
+
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		xFieldStr := getFlagString(cmd, "x-field")
+		yFieldStr := getFlagString(cmd, "y-field")
+		valueFieldStr := getFlagString(cmd, "value-field")
+		long := valueFieldStr != ""
+		if long && (xFieldStr == "" || yFieldStr == "") {
+			checkError(fmt.Errorf("flag -x/--x-field and -y/--y-field needed for long format input"))
+		}
+
+		opt := &heatmapOptions{
+			palette:        strings.ToLower(getFlagString(cmd, "palette")),
+			reversePalette: getFlagBool(cmd, "reverse-palette"),
+			min:            math.NaN(),
+			max:            math.NaN(),
+			annotate:       getFlagBool(cmd, "annotate"),
+			decimalFormat:  fmt.Sprintf("%%.%df", getFlagNonNegativeInt(cmd, "decimal-width")),
+			fontSize:       plotConfig.tickLabelSize,
+		}
+		if s := getFlagString(cmd, "value-min"); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				checkError(fmt.Errorf("value of flag --value-min should be float"))
+			}
+			opt.min = v
+		}
+		if s := getFlagString(cmd, "value-max"); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				checkError(fmt.Errorf("value of flag --value-max should be float"))
+			}
+			opt.max = v
+		}
+		clusterRows := getFlagBool(cmd, "cluster-rows")
+		clusterCols := getFlagBool(cmd, "cluster-cols")
+		plotConfig.ignoreScales("heatmap", plotConfig.xaxis, plotConfig.yaxis)
+
+		file := files[0]
+		var rows, cols []string
+		var values [][]float64
+		var xlab, ylab string
+		if long {
+			headerRow, _, data, _, _, err := parseCSVfile(cmd, config, file,
+				xFieldStr+","+yFieldStr+","+valueFieldStr, false, false)
+			checkError(err)
+			if len(headerRow) == 3 {
+				xlab, ylab = headerRow[0], headerRow[1]
+			}
+
+			colOrder, rowOrder := newCategoryOrder(), newCategoryOrder()
+			sums := make(map[[2]string]float64, len(data))
+			counts := make(map[[2]string]float64, len(data))
+			var key [2]string
+			for _, d := range data {
+				colOrder.Add(d[0])
+				rowOrder.Add(d[1])
+				v, err := strconv.ParseFloat(removeComma(d[2]), 64)
+				if err != nil || math.IsNaN(v) {
+					continue
+				}
+				key = [2]string{d[1], d[0]}
+				sums[key] += v
+				counts[key]++
+			}
+			rows, cols = rowOrder.Keys(), colOrder.Keys()
+			values = make([][]float64, len(rows))
+			for i, r := range rows {
+				values[i] = make([]float64, len(cols))
+				for j, c := range cols {
+					key = [2]string{r, c}
+					if counts[key] == 0 {
+						values[i][j] = math.NaN()
+						continue
+					}
+					values[i][j] = sums[key] / counts[key]
+				}
+			}
+		} else {
+			headerRow, _, data, _, _, err := parseCSVfile(cmd, config, file, "1-", false, false)
+			checkError(err)
+
+			var ncols int
+			if len(headerRow) > 0 {
+				ncols = len(headerRow) - 1
+				cols = headerRow[1:]
+				ylab = headerRow[0]
+			} else if len(data) > 0 {
+				ncols = len(data[0]) - 1
+				cols = make([]string, ncols)
+				for j := range cols {
+					cols[j] = strconv.Itoa(j + 2)
+				}
+			}
+			if ncols < 1 {
+				checkError(fmt.Errorf("at least two columns needed: row labels and values"))
+			}
+
+			rows = make([]string, len(data))
+			values = make([][]float64, len(data))
+			for i, d := range data {
+				rows[i] = d[0]
+				values[i] = make([]float64, ncols)
+				for j := range values[i] {
+					v, err := strconv.ParseFloat(removeComma(d[j+1]), 64)
+					if err != nil {
+						v = math.NaN()
+					}
+					values[i][j] = v
+				}
+			}
+		}
+		if len(rows) == 0 {
+			checkError(fmt.Errorf("no data to plot"))
+		}
+
+		if clusterRows && len(rows) > 2 {
+			order := clusterOrder(values)
+			rows2 := make([]string, len(rows))
+			values2 := make([][]float64, len(rows))
+			for i, k := range order {
+				rows2[i], values2[i] = rows[k], values[k]
+			}
+			rows, values = rows2, values2
+		}
+		if clusterCols && len(cols) > 2 {
+			transposed := make([][]float64, len(cols))
+			for j := range cols {
+				transposed[j] = make([]float64, len(rows))
+				for i := range rows {
+					transposed[j][i] = values[i][j]
+				}
+			}
+			order := clusterOrder(transposed)
+			cols2 := make([]string, len(cols))
+			for j, k := range order {
+				cols2[j] = cols[k]
+			}
+			for i := range rows {
+				row := make([]float64, len(cols))
+				for j, k := range order {
+					row[j] = values[i][k]
+				}
+				values[i] = row
+			}
+			cols = cols2
+		}
+
+		p, pc, err := newHeatmapPlots(rows, cols, values, opt)
+		checkError(err)
+
+		if plotConfig.xlab == "" {
+			plotConfig.xlab = xlab
+		}
+		if plotConfig.ylab == "" {
+			plotConfig.ylab = ylab
+		}
+		plotConfig.applyStyle(p)
+		pc.Y.Tick.Label.Font.Size = plotConfig.tickLabelSize
+		pc.Y.Width = plotConfig.axisWidth
+		pc.Y.Tick.Width = plotConfig.tickWidth
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, func(dc draw.Canvas) {
+				drawHeatmap(dc, p, pc)
+			}, p, pc))
+			return
+		}
+
+		checkError(saveHeatmap(config.OutFile, plotConfig, p, pc,
+			plotConfig.width*vg.Inch, plotConfig.height*vg.Inch))
+	},
+}
+
+// heatmapOptions contains options of heatmaps.
+type heatmapOptions struct {
+	palette        string
+	reversePalette bool
+	min, max       float64 // NaN for the range of data
+	annotate       bool
+	decimalFormat  string
+	fontSize       vg.Length // of annotations
+	sequential     string    // palette of "auto" for values of one sign, default extended-kindlmann
+}
+
+// heatmapGrid implements plotter.GridXYZ for a matrix,
+// the first row is placed at the top.
+type heatmapGrid [][]float64
+
+func (g heatmapGrid) Dims() (c, r int)   { return len(g[0]), len(g) }
+func (g heatmapGrid) Z(c, r int) float64 { return g[len(g)-1-r][c] }
+func (g heatmapGrid) X(c int) float64    { return float64(c) }
+func (g heatmapGrid) Y(r int) float64    { return float64(r) }
+
+// heatmapColorMap returns a color map by name, and whether it's diverging.
+func heatmapColorMap(name string) (palette.ColorMap, bool, error) {
+	switch name {
+	case "blue-red":
+		return moreland.SmoothBlueRed(), true, nil
+	case "blue-tan":
+		return moreland.SmoothBlueTan(), true, nil
+	case "green-purple":
+		return moreland.SmoothGreenPurple(), true, nil
+	case "green-red":
+		return moreland.SmoothGreenRed(), true, nil
+	case "purple-orange":
+		return moreland.SmoothPurpleOrange(), true, nil
+	case "black-body":
+		return moreland.BlackBody(), false, nil
+	case "extended-black-body":
+		return moreland.ExtendedBlackBody(), false, nil
+	case "kindlmann":
+		return moreland.Kindlmann(), false, nil
+	case "extended-kindlmann":
+		return moreland.ExtendedKindlmann(), false, nil
+	case "viridis":
+		cm, err := moreland.NewLuminance(viridisColors)
+		return cm, false, err
+	}
+	return nil, false, fmt.Errorf("invalid palette: %s. available: auto, blue-red, blue-tan, green-purple, green-red, purple-orange, black-body, extended-black-body, kindlmann, extended-kindlmann, viridis", name)
+}
+
+// viridisColors are control colors of the viridis palette, from dark purple
+// to yellow, which does not end at white like kindlmann palettes.
+var viridisColors = []color.Color{
+	color.NRGBA{0x44, 0x01, 0x54, 0xff},
+	color.NRGBA{0x48, 0x28, 0x78, 0xff},
+	color.NRGBA{0x3e, 0x4a, 0x89, 0xff},
+	color.NRGBA{0x31, 0x68, 0x8e, 0xff},
+	color.NRGBA{0x26, 0x82, 0x8e, 0xff},
+	color.NRGBA{0x1f, 0x9e, 0x89, 0xff},
+	color.NRGBA{0x35, 0xb7, 0x79, 0xff},
+	color.NRGBA{0x6d, 0xcd, 0x59, 0xff},
+	color.NRGBA{0xb4, 0xde, 0x2c, 0xff},
+	color.NRGBA{0xfd, 0xe7, 0x25, 0xff},
+}
+
+// newHeatmapPlots returns the plot of a heatmap and the plot of its color bar.
+func newHeatmapPlots(rows, cols []string, values [][]float64, opt *heatmapOptions) (*plot.Plot, *plot.Plot, error) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, row := range values {
+		for _, v := range row {
+			if math.IsNaN(v) {
+				continue
+			}
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+	}
+	if math.IsInf(min, 1) { // all NaN
+		min, max = 0, 1
+	}
+
+	cm, err := heatmapColorScale(min, max, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	min, max = cm.Min(), cm.Max()
+
+	p := plot.New()
+	hm := plotter.NewHeatMap(heatmapGrid(values), cm.Palette(255))
+	hm.Min, hm.Max = min, max
+	hm.Underflow = cm.Palette(255).Colors()[0]
+	hm.Overflow = cm.Palette(255).Colors()[254]
+	hm.NaN = color.Gray{Y: 200}
+	p.Add(hm)
+
+	n, m := len(rows), len(cols)
+	if opt.annotate {
+		xys := make(plotter.XYs, 0, n*m)
+		labels := make([]string, 0, n*m)
+		colors := make([]color.Color, 0, n*m)
+		var v float64
+		for r := 0; r < n; r++ {
+			for c := 0; c < m; c++ {
+				v = values[r][c]
+				xys = append(xys, plotter.XY{X: float64(c), Y: float64(n - 1 - r)})
+				if math.IsNaN(v) {
+					labels = append(labels, "")
+					colors = append(colors, color.Black)
+					continue
+				}
+				labels = append(labels, fmt.Sprintf(opt.decimalFormat, v))
+				colors = append(colors, contrastColor(cm, math.Max(min, math.Min(max, v))))
+			}
+		}
+		l, err := plotter.NewLabels(plotter.XYLabels{XYs: xys, Labels: labels})
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range l.TextStyle {
+			l.TextStyle[i].XAlign = text.XCenter
+			l.TextStyle[i].YAlign = text.YCenter
+			l.TextStyle[i].Color = colors[i]
+			if opt.fontSize > 0 {
+				l.TextStyle[i].Font.Size = opt.fontSize * 0.8
+			}
+		}
+		p.Add(l)
+	}
+
+	xticks := make([]plot.Tick, m)
+	yticks := make([]plot.Tick, n)
+	var maxLen int
+	for i, name := range cols {
+		xticks[i] = plot.Tick{Value: float64(i), Label: name}
+		if len(name) > maxLen {
+			maxLen = len(name)
+		}
+	}
+	for i, name := range rows {
+		yticks[i] = plot.Tick{Value: float64(n - 1 - i), Label: name}
+	}
+	p.X.Tick.Marker = plot.ConstantTicks(xticks)
+	p.Y.Tick.Marker = plot.ConstantTicks(yticks)
+	if maxLen > 3 {
+		p.X.Tick.Label.Rotation = math.Pi / 4
+		p.X.Tick.Label.XAlign = draw.XRight
+		p.X.Tick.Label.YAlign = draw.YTop
+	}
+	p.X.Min, p.X.Max = -0.5, float64(m)-0.5
+	p.Y.Min, p.Y.Max = -0.5, float64(n)-0.5
+
+	return p, newColorBarPlot(cm), nil
+}
+
+// heatmapColorScale returns the color map of values in the range of [min, max].
+// A diverging palette is centered at 0, unless the range is given in options.
+func heatmapColorScale(min, max float64, opt *heatmapOptions) (palette.ColorMap, error) {
+	name := opt.palette
+	if name == "" || name == "auto" {
+		if min < 0 && max > 0 {
+			name = "blue-red"
+		} else if opt.sequential != "" {
+			name = opt.sequential
+		} else {
+			name = "extended-kindlmann"
+		}
+	}
+	cm, diverging, err := heatmapColorMap(name)
+	if err != nil {
+		return nil, err
+	}
+	if diverging && math.IsNaN(opt.min) && math.IsNaN(opt.max) {
+		m := math.Max(math.Abs(min), math.Abs(max))
+		min, max = -m, m
+	}
+	if !math.IsNaN(opt.min) {
+		min = opt.min
+	}
+	if !math.IsNaN(opt.max) {
+		max = opt.max
+	}
+	if min >= max {
+		max = min + 1
+	}
+	if opt.reversePalette {
+		cm = palette.Reverse(cm)
+	}
+	cm.SetMin(min)
+	cm.SetMax(max)
+	return cm, nil
+}
+
+// newColorBarPlot returns the plot of a vertical color bar.
+func newColorBarPlot(cm palette.ColorMap) *plot.Plot {
+	pc := plot.New()
+	pc.Add(heatmapColorBar{cm: cm, n: 255})
+	pc.HideX()
+	pc.Y.Padding = 0
+	return pc
+}
+
+// heatmapColorBar draws a vertical color bar with rectangles. plotter.ColorBar
+// draws an image, which is not supported in EPS and PDF formats.
+type heatmapColorBar struct {
+	cm palette.ColorMap
+	n  int
+}
+
+func (b heatmapColorBar) Plot(c draw.Canvas, p *plot.Plot) {
+	trX, trY := p.Transforms(&c)
+	min, max := b.cm.Min(), b.cm.Max()
+	step := (max - min) / float64(b.n)
+	var lo, hi float64
+	for i := 0; i < b.n; i++ {
+		lo = min + float64(i)*step
+		hi = lo + step*1.5 // overlapped to avoid gaps
+		if i == b.n-1 {
+			hi = max
+		}
+		col, err := b.cm.At(lo + step/2)
+		if err != nil {
+			continue
+		}
+		pts := []vg.Point{
+			{X: trX(0), Y: trY(lo)},
+			{X: trX(1), Y: trY(lo)},
+			{X: trX(1), Y: trY(hi)},
+			{X: trX(0), Y: trY(hi)},
+		}
+		c.FillPolygon(col, c.ClipPolygonXY(pts))
+	}
+}
+
+func (b heatmapColorBar) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return 0, 1, b.cm.Min(), b.cm.Max()
+}
+
+// contrastColor returns black or white, whichever is more visible on the color of v.
+func contrastColor(cm palette.ColorMap, v float64) color.Color {
+	c, err := cm.At(v)
+	if err != nil {
+		return color.Black
+	}
+	r, g, b, _ := c.RGBA()
+	if 0.299*float64(r)+0.587*float64(g)+0.114*float64(b) > 0.5*0xffff {
+		return color.Black
+	}
+	return color.White
+}
+
+// saveHeatmap draws a heatmap and its color bar side by side, and saves the image
+// to stdout in the given format, or to a file in the format of the file extension.
+func saveHeatmap(file string, plotConfig *plotConfigs, p, pc *plot.Plot, width, height vg.Length) error {
+	return plotConfig.saveImage(file, width, height, func(dc draw.Canvas) {
+		drawHeatmap(dc, p, pc)
+	})
+}
+
+// drawHeatmap draws a heatmap and its color bar side by side.
+func drawHeatmap(dc draw.Canvas, p, pc *plot.Plot) {
+	drawWithColorBar(dc, pc, p.Draw)
+}
+
+// colorBarStripWidth is the width of the color strip of a color bar.
+const colorBarStripWidth = vg.Inch * 0.3
+
+// drawWithColorBar draws a color bar at the right side, and others with fn.
+func drawWithColorBar(dc draw.Canvas, pc *plot.Plot, fn func(dc draw.Canvas)) {
+	width, height := dc.Max.X-dc.Min.X, dc.Max.Y-dc.Min.Y
+	barWidth := colorBarWidth(pc) + vg.Points(8)
+	fn(draw.Crop(dc, 0, -barWidth, 0, -vg.Points(4)))
+	// align the color bar with the data area roughly
+	pc.Draw(draw.Crop(dc, width-barWidth+vg.Points(8), -vg.Points(4), height/8, -height/8))
+}
+
+// colorBarWidth returns the width of a color bar, i.e., the strip and the
+// Y axis with tick labels, or the title if it's wider.
+// The axis width is computed in the same way as gonum/plot does.
+func colorBarWidth(pc *plot.Plot) vg.Length {
+	a := pc.Y
+	var w vg.Length
+	if a.Label.Text != "" {
+		w += a.Label.TextStyle.FontExtents().Descent
+		w += a.Label.TextStyle.Height(a.Label.Text)
+		w += a.Label.Padding
+	}
+	var labelWidth vg.Length
+	for _, t := range a.Tick.Marker.Ticks(a.Min, a.Max) {
+		if t.IsMinor() {
+			continue
+		}
+		if lw := a.Tick.Label.Width(t.Label); lw > labelWidth {
+			labelWidth = lw
+		}
+	}
+	if labelWidth > 0 {
+		w += labelWidth + a.Label.TextStyle.Width(" ") + a.Tick.Length
+	}
+	w += a.Width/2 + a.Padding + colorBarStripWidth
+
+	if pc.Title.Text != "" {
+		if tw := pc.Title.TextStyle.Width(pc.Title.Text); tw > w {
+			return tw
+		}
+	}
+	return w
+}
+
+// clusterOrder returns the leaf order of hierarchical clustering of rows,
+// with average linkage of Euclidean distances. Distances are computed with
+// pairwise complete values and scaled to the number of all values.
+func clusterOrder(values [][]float64) []int {
+	n := len(values)
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+	}
+	var d, s float64
+	var k int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			s, k = 0, 0
+			for c, v := range values[i] {
+				if math.IsNaN(v) || math.IsNaN(values[j][c]) {
+					continue
+				}
+				d = v - values[j][c]
+				s += d * d
+				k++
+			}
+			if k == 0 {
+				d = math.MaxFloat64
+			} else {
+				d = math.Sqrt(s * float64(len(values[i])) / float64(k))
+			}
+			dist[i][j], dist[j][i] = d, d
+		}
+	}
+
+	// clusters are represented by their leaves in order
+	clusters := make([][]int, n)
+	for i := range clusters {
+		clusters[i] = []int{i}
+	}
+	active := make([]bool, n)
+	for i := range active {
+		active[i] = true
+	}
+	for merged := 0; merged < n-1; merged++ {
+		bi, bj := -1, -1
+		best := math.Inf(1)
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && dist[i][j] < best {
+					best, bi, bj = dist[i][j], i, j
+				}
+			}
+		}
+		if bi < 0 { // all remaining distances are +Inf
+			for i := 0; i < n; i++ {
+				if active[i] {
+					if bi < 0 {
+						bi = i
+					} else if bj < 0 {
+						bj = i
+					}
+				}
+			}
+		}
+
+		// average linkage (UPGMA)
+		ni, nj := float64(len(clusters[bi])), float64(len(clusters[bj]))
+		for k := 0; k < n; k++ {
+			if !active[k] || k == bi || k == bj {
+				continue
+			}
+			d = (dist[bi][k]*ni + dist[bj][k]*nj) / (ni + nj)
+			dist[bi][k], dist[k][bi] = d, d
+		}
+		clusters[bi] = append(clusters[bi], clusters[bj]...)
+		active[bj] = false
+	}
+	for i := range active {
+		if active[i] {
+			return clusters[i]
+		}
+	}
+	return nil
+}
+
+func init() {
+	plotCmd.AddCommand(heatmapCmd)
+
+	heatmapCmd.Flags().StringP("x-field", "x", "", `column index or column name of column labels, for long format input`)
+	heatmapCmd.Flags().StringP("y-field", "y", "", `column index or column name of row labels, for long format input`)
+	heatmapCmd.Flags().StringP("value-field", "v", "", `column index or column name of values, for long format input`)
+	heatmapCmd.Flags().StringP("palette", "", "auto", `color palette, see the help message`)
+	heatmapCmd.Flags().BoolP("reverse-palette", "", false, "reverse the color palette")
+	heatmapCmd.Flags().StringP("value-min", "", "", "minimum value of the color scale")
+	heatmapCmd.Flags().StringP("value-max", "", "", "maximum value of the color scale")
+	heatmapCmd.Flags().BoolP("annotate", "", false, "show values in cells")
+	heatmapCmd.Flags().IntP("decimal-width", "w", 2, "limit floats of annotations to N decimal points")
+	heatmapCmd.Flags().BoolP("cluster-rows", "", false, "order rows by hierarchical clustering")
+	heatmapCmd.Flags().BoolP("cluster-cols", "", false, "order columns by hierarchical clustering")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+func TestClusterOrder(t *testing.T) {
+	tests := []struct {
+		values [][]float64
+		groups [][]int // rows expected to be adjacent
+	}{
+		{
+			values: [][]float64{{0, 0}, {10, 10}, {0.1, 0.2}, {10.2, 9.9}},
+			groups: [][]int{{0, 2}, {1, 3}},
+		},
+		{
+			values: [][]float64{{1, math.NaN(), 1}, {5, 5, 5}, {1.1, 1, math.NaN()}, {5, 5.2, 4.9}, {1, 0.9, 1.2}},
+			groups: [][]int{{0, 2, 4}, {1, 3}},
+		},
+	}
+	for i, test := range tests {
+		order := clusterOrder(test.values)
+		if len(order) != len(test.values) {
+			t.Fatalf("test #%d: expected %d rows, returned %d", i, len(test.values), len(order))
+		}
+		pos := make(map[int]int, len(order))
+		for p, k := range order {
+			pos[k] = p
+		}
+		for _, g := range test.groups {
+			min, max := len(order), -1
+			for _, k := range g {
+				if pos[k] < min {
+					min = pos[k]
+				}
+				if pos[k] > max {
+					max = pos[k]
+				}
+			}
+			if max-min+1 != len(g) {
+				t.Errorf("test #%d: rows %v not adjacent in order %v", i, g, order)
+			}
+		}
+	}
+}
+
+func TestHeatmapColorBarWidth(t *testing.T) {
+	for _, max := range []float64{1, 9800, 279600} {
+		values := [][]float64{{3, max / 2}, {max / 3, max}}
+		opt := &heatmapOptions{min: math.NaN(), max: math.NaN(), decimalFormat: "%.0f"}
+		p, pc, err := newHeatmapPlots([]string{"r1", "r2"}, []string{"c1", "c2"}, values, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		width, height := 4*vg.Inch, 3*vg.Inch
+		c := vgimg.New(width, height)
+		drawHeatmap(draw.New(c), p, pc)
+
+		// the middle of the color strip, at the right side
+		dpi := c.DPI() / float64(vg.Inch)
+		x := int(float64(width-vg.Points(4)-colorBarStripWidth/2) * dpi)
+		y := int(float64(height/2) * dpi)
+		r, g, b, _ := c.Image().At(x, y).RGBA()
+		if r == 0xffff && g == 0xffff && b == 0xffff {
+			t.Errorf("max %v: the color strip should be visible", max)
+		}
+	}
+}
+
+func TestHeatmapColorScaleSequential(t *testing.T) {
+	white := func(c color.Color) bool {
+		r, g, b, _ := c.RGBA()
+		return r > 0xf000 && g > 0xf000 && b > 0xf000
+	}
+	tests := []struct {
+		sequential string
+		whiteAtMax bool
+	}{
+		{"", true}, // extended-kindlmann
+		{"viridis", false},
+	}
+	for _, test := range tests {
+		opt := &heatmapOptions{palette: "auto", min: math.NaN(), max: math.NaN(), sequential: test.sequential}
+		cm, err := heatmapColorScale(3, 9, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := cm.At(9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if white(c) != test.whiteAtMax {
+			t.Errorf("sequential palette %q: color of the maximum value: %v", test.sequential, c)
+		}
+	}
+
+	// values of both signs use a diverging palette
+	opt := &heatmapOptions{palette: "auto", min: math.NaN(), max: math.NaN(), sequential: "viridis"}
+	cm, err := heatmapColorScale(-2, 1, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Min() != -2 || cm.Max() != 2 {
+		t.Errorf("diverging palette should be symmetric around 0: [%v, %v]", cm.Min(), cm.Max())
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shenwei356/breader"
 	"github.com/shenwei356/util/stringutil"
@@ -118,6 +119,9 @@ func getFileListFromArgsAndFile(cmd *cobra.Command, args []string, checkFileFrom
 		}
 		files = append(files, _files...)
 	}
+	if len(files) > 1 && getFlagBool(cmd, "follow") {
+		checkError(fmt.Errorf("flag --follow only supports one input file"))
+	}
 	return files
 }
 
@@ -261,10 +265,15 @@ type Config struct {
 
 	ShowRowNumber bool
 
-	OutFile string
+	OutFile       string
+	OutCompress   string // compression format of output, "" for deciding by file suffix
+	CompressLevel int
 
 	IgnoreEmptyRow   bool
 	IgnoreIllegalRow bool
+
+	Follow         bool
+	FollowInterval time.Duration
 }
 
 func isTrue(s string) bool {
@@ -275,6 +284,9 @@ func isTrue(s string) bool {
 	return true
 }
 
+// commands supporting the global flag --follow, which emit records as they arrive
+var followCommands = []string{"cut", "filter2", "grep", "watch"}
+
 func getConfigs(cmd *cobra.Command) Config {
 	var val string
 
@@ -301,6 +313,40 @@ func getConfigs(cmd *cobra.Command) Config {
 		verbose = !getFlagBool(cmd, "quiet")
 	}
 
+	outCompress := strings.ToLower(getFlagString(cmd, "out-compress"))
+	if outCompress != "" {
+		var ok bool
+		for _, format := range compressFormats {
+			if outCompress == format {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			checkError(fmt.Errorf("invalid value of flag --out-compress: %s, available values: %s",
+				outCompress, strings.Join(compressFormats, ", ")))
+		}
+	}
+	level := getFlagInt(cmd, "compress-level")
+	if _, err := compressLevel(outCompress, level); err != nil {
+		checkError(err)
+	}
+
+	follow := getFlagBool(cmd, "follow")
+	if follow {
+		var ok bool
+		for _, name := range followCommands {
+			if cmd.Name() == name {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			checkError(fmt.Errorf("flag --follow is not supported by csvtk %s, supported commands: %s",
+				cmd.Name(), strings.Join(followCommands, ", ")))
+		}
+	}
+
 	threads := getFlagPositiveInt(cmd, "num-cpus")
 	if threads >= 1000 {
 		checkError(fmt.Errorf("are your seriously? %d threads? It will exhaust your RAM", threads))
@@ -325,15 +371,26 @@ func getConfigs(cmd *cobra.Command) Config {
 
 		ShowRowNumber: getFlagBool(cmd, "show-row-number"),
 
-		OutFile: getFlagString(cmd, "out-file"),
+		OutFile:       getFlagString(cmd, "out-file"),
+		OutCompress:   outCompress,
+		CompressLevel: level,
 
 		IgnoreEmptyRow:   getFlagBool(cmd, "ignore-empty-row"),
 		IgnoreIllegalRow: getFlagBool(cmd, "ignore-illegal-row"),
+
+		Follow:         follow,
+		FollowInterval: time.Duration(getFlagPositiveFloat64(cmd, "follow-interval") * float64(time.Second)),
 	}
 }
 
 func newCSVReaderByConfig(config Config, file string) (*CSVReader, error) {
-	reader, err := NewCSVReader(file)
+	var reader *CSVReader
+	var err error
+	if config.Follow {
+		reader, err = NewCSVFollowReader(file, config.FollowInterval, !config.NoHeaderRow, config.CommentChar)
+	} else {
+		reader, err = NewCSVReader(file)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -354,7 +411,7 @@ func newCSVReaderByConfig(config Config, file string) (*CSVReader, error) {
 
 // NewCSVWriterChanByConfig returns a chanel which you can send record to write
 func NewCSVWriterChanByConfig(config Config) (chan []string, error) {
-	outfh, err := xopen.Wopen(config.OutFile)
+	outfh, err := wopenByConfig(config, config.OutFile)
 	if err != nil {
 		return nil, err
 	}
@@ -22,7 +22,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"math"
 	"runtime"
 	"sort"
 	"strconv"
@@ -32,7 +32,6 @@ import (
 	"gonum.org/v1/gonum/stat"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 )
 
@@ -72,8 +71,14 @@ Notes:
 			naMap[strings.ToLower(na)] = struct{}{}
 		}
 
+		facets := getPlotFacets(cmd)
+		fieldStr := plotConfig.fieldStr
+		if facets.Enabled() {
+			fieldStr += "," + facets.field
+		}
+
 		file := files[0]
-		headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, plotConfig.fieldStr, false, false)
+		headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, fieldStr, false, false)
 
 		if err != nil {
 			// if err == xopen.ErrNoContent {
@@ -83,17 +88,23 @@ Notes:
 			checkError(err)
 		}
 
+		headerRow, rowFacets := facets.Split(headerRow, data)
+
 		// =======================================
 
 		if plotConfig.groupFieldStr != "" {
 			log.Warning("flag -g (--group-field) ignored for command hist")
 		}
+		if plotConfig.yaxis.trans != "" {
+			log.Warning("flag --y-trans ignored for command hist")
+			plotConfig.yaxis.trans = ""
+		}
 		if plotConfig.ylab == "" {
 			plotConfig.ylab = "Count"
 		}
 
 		if plotConfig.xlab == "" && plotConfig.groupFieldStr == "" && len(headerRow) > 0 {
-			plotConfig.xlab = headerRow[0]
+			plotConfig.xlab = plotConfig.xaxis.Label(headerRow[0])
 		}
 
 		bins := getFlagPositiveInt(cmd, "bins")
@@ -102,10 +113,10 @@ Notes:
 			checkError(fmt.Errorf("unsupported color index"))
 		}
 
-		v := make(plotter.Values, 0, len(data))
+		values := make([]plotter.Values, facets.N())
 		var f float64
 		var ok bool
-		for _, d := range data {
+		for r, d := range data {
 			if skipNA {
 				if _, ok = naMap[strings.ToLower(d[0])]; ok {
 					continue
@@ -119,65 +130,85 @@ Notes:
 					checkError(fmt.Errorf("fail to parse data: %s at column: %d. please choose the right column by flag -f (--data-field)", d[0], fields[0]))
 				}
 			}
-			v = append(v, f)
+			if f, ok = plotConfig.xaxis.Value(f); !ok {
+				continue
+			}
+			values[rowFacets[r]] = append(values[rowFacets[r]], f)
 		}
+		plotConfig.xaxis.WarnRemoved()
 
 		percentiles := getFlagBool(cmd, "percentiles")
-		if percentiles {
-			sort.Float64s(v)
-			plotConfig.xlab = fmt.Sprintf("%s\nP99=%.3f P95=%.3f\nMEAN=%.3f STDDEV=%.3f\n", plotConfig.xlab, getPercentile(0.99, v), getPercentile(0.95, v), getPercentile(0.5, v), stat.StdDev(v, nil))
-		}
+		xlab := plotConfig.xlab
 
-		p := plot.New()
+		plots := make([]*plot.Plot, len(values))
+		var n int // non-empty plots
+		for i, v := range values {
+			if len(v) == 0 {
+				continue
+			}
 
-		h, err := plotter.NewHist(v, bins)
-		if err != nil {
-			checkError(err)
-		}
+			if percentiles {
+				sort.Float64s(v)
+				plotConfig.xlab = fmt.Sprintf("%s\nP99=%.3f P95=%.3f\nMEAN=%.3f STDDEV=%.3f\n", xlab, getPercentile(0.99, v), getPercentile(0.95, v), getPercentile(0.5, v), stat.StdDev(v, nil))
+			}
+
+			p := plotConfig.newPlot()
 
-		// h.Normalize(1)
-		h.FillColor = plotutil.Color(colorIndex - 1)
-		p.Add(h)
-
-		p.Title.Text = plotConfig.title
-		p.Title.TextStyle.Font.Size = plotConfig.titleSize
-		p.X.Label.Text = plotConfig.xlab
-		p.Y.Label.Text = plotConfig.ylab
-		p.X.Label.TextStyle.Font.Size = plotConfig.labelSize
-		p.Y.Label.TextStyle.Font.Size = plotConfig.labelSize
-		p.X.Width = plotConfig.axisWidth
-		p.Y.Width = plotConfig.axisWidth
-		p.X.Tick.Width = plotConfig.tickWidth
-		p.Y.Tick.Width = plotConfig.tickWidth
-		p.X.Tick.Label.Font.Size = plotConfig.tickLabelSize
-		p.Y.Tick.Label.Font.Size = plotConfig.tickLabelSize
-
-		if plotConfig.xminStr != "" {
-			p.X.Min = plotConfig.xmin
+			var h *plotter.Histogram
+			if plotConfig.xaxis.log { // bins of equal widths in log scale
+				logv := make(plotter.Values, len(v))
+				for i, f := range v {
+					logv[i] = math.Log10(f)
+				}
+				h, err = plotter.NewHist(logv, bins)
+				checkError(err)
+				for i := range h.Bins {
+					h.Bins[i].Min = math.Pow(10, h.Bins[i].Min)
+					h.Bins[i].Max = math.Pow(10, h.Bins[i].Max)
+				}
+			} else {
+				h, err = plotter.NewHist(v, bins)
+				checkError(err)
+			}
+
+			// h.Normalize(1)
+			h.FillColor = plotConfig.color(colorIndex - 1)
+			p.Add(h)
+
+			plotConfig.applyStyle(p)
+			plotConfig.applyLimits(p)
+			if plotConfig.yaxis.log && plotConfig.yminStr == "" {
+				// bins with a count of 0 are not visible
+				p.Y.Min = math.Inf(1)
+				for _, b := range h.Bins {
+					if b.Weight > 0 && b.Weight < p.Y.Min {
+						p.Y.Min = b.Weight
+					}
+				}
+				p.Y.Min /= 2
+			}
+			plots[i] = p
+			n++
 		}
-		if plotConfig.xmaxStr != "" {
-			p.X.Max = plotConfig.xmax
+		if n == 0 {
+			checkError(fmt.Errorf("no valid data to plot"))
 		}
-		if plotConfig.yminStr != "" {
-			p.Y.Min = plotConfig.ymin
+
+		if facets.Enabled() {
+			checkError(facets.Save(plots, plotConfig, config.OutFile))
+			return
 		}
-		if plotConfig.ymaxStr != "" {
-			p.Y.Max = plotConfig.ymax
+
+		p := plots[0]
+		plotConfig.applyScales(p)
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
 		}
 
 		// Save image
-		if isStdin(config.OutFile) {
-			fh, err := p.WriterTo(plotConfig.width*vg.Inch,
-				plotConfig.height*vg.Inch,
-				plotConfig.format)
-			checkError(err)
-			_, err = fh.WriteTo(os.Stdout)
-			checkError(err)
-		} else {
-			checkError(p.Save(plotConfig.width*vg.Inch,
-				plotConfig.height*vg.Inch,
-				config.OutFile))
-		}
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
 	},
 }
 
@@ -193,4 +224,8 @@ func init() {
 	histCmd.Flags().IntP("color-index", "", 1, `color index, 1-7`)
 	histCmd.Flags().BoolP("percentiles", "", false, `calculate percentiles`)
 
+	histCmd.Flags().StringP("facet", "", "", `column index or column name of facets, for plotting a sub-plot for each value`)
+	histCmd.Flags().IntP("facet-cols", "", 0, `number of columns of sub-plots, 0 for automatic`)
+	histCmd.Flags().StringP("facet-scales", "", "fixed", `axis ranges of sub-plots: fixed, free, free_x, or free_y`)
+
 }
@@ -0,0 +1,146 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of bits for register indexes,
+// the relative standard error is 1.04/sqrt(2^14) = 0.81%.
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct values with bounded memory.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// hashString returns a 64-bit hash of s, FNV-1a is followed by
+// the splitmix64 finalizer for better distribution of the high bits.
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Add adds a value.
+func (h *hyperLogLog) Add(s string) {
+	x := hashString(s)
+	i := x >> (64 - hllPrecision)
+	w := x<<hllPrecision | 1<<(hllPrecision-1)
+	rho := uint8(bits.LeadingZeros64(w)) + 1
+	if rho > h.registers[i] {
+		h.registers[i] = rho
+	}
+}
+
+// Merge merges another sketch into h.
+func (h *hyperLogLog) Merge(o *hyperLogLog) {
+	for i, r := range o.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+}
+
+// Count returns the estimated number of distinct values.
+func (h *hyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 { // small range correction, i.e., linear counting
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(e + 0.5)
+}
+
+// RelativeError returns the relative standard error of the estimate.
+func (h *hyperLogLog) RelativeError() float64 {
+	return 1.04 / math.Sqrt(float64(len(h.registers)))
+}
+
+// distinctCounter counts distinct values exactly until maxExact values are seen,
+// and then switches to HyperLogLog.
+type distinctCounter struct {
+	maxExact int
+	exact    map[string]struct{}
+	hll      *hyperLogLog
+}
+
+func newDistinctCounter(maxExact int) *distinctCounter {
+	return &distinctCounter{maxExact: maxExact, exact: make(map[string]struct{}, 64)}
+}
+
+// Add adds a value.
+func (c *distinctCounter) Add(s string) {
+	if c.hll != nil {
+		c.hll.Add(s)
+		return
+	}
+	c.exact[s] = struct{}{}
+	if len(c.exact) > c.maxExact {
+		c.hll = newHyperLogLog()
+		for v := range c.exact {
+			c.hll.Add(v)
+		}
+		c.exact = nil
+	}
+}
+
+// Count returns the number of distinct values and whether it's exact.
+func (c *distinctCounter) Count() (uint64, bool) {
+	if c.hll != nil {
+		return c.hll.Count(), false
+	}
+	return uint64(len(c.exact)), true
+}
+
+// Values returns the distinct values if they are counted exactly.
+func (c *distinctCounter) Values() []string {
+	if c.hll != nil {
+		return nil
+	}
+	vs := make([]string, 0, len(c.exact))
+	for v := range c.exact {
+		vs = append(vs, v)
+	}
+	return vs
+}
@@ -0,0 +1,185 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// kllK is the default size of the top compactor of KLL sketches,
+// the normalized rank error is about 1.33%.
+const kllK = 200
+
+// kllSketch estimates quantiles with bounded memory, following
+// Karnin, Lang and Liberty, "Optimal Quantile Approximation in Streams" (2016).
+// Values are kept exactly until the first compaction, i.e., for no more than k values.
+type kllSketch struct {
+	k          int
+	n          int64
+	compactors [][]float64
+	size       int // number of retained values
+	maxSize    int
+	min, max   float64
+}
+
+func newKLLSketch(k int) *kllSketch {
+	s := &kllSketch{k: k, min: math.Inf(1), max: math.Inf(-1)}
+	s.grow()
+	return s
+}
+
+// capacity returns the capacity of the compactor at level h,
+// lower levels have smaller capacities.
+func (s *kllSketch) capacity(h int) int {
+	depth := len(s.compactors) - h - 1
+	c := int(math.Ceil(float64(s.k) * math.Pow(2.0/3.0, float64(depth))))
+	if c < 2 {
+		return 2
+	}
+	return c
+}
+
+func (s *kllSketch) grow() {
+	s.compactors = append(s.compactors, make([]float64, 0, s.k))
+	s.maxSize = 0
+	for h := range s.compactors {
+		s.maxSize += s.capacity(h)
+	}
+}
+
+// Add adds a value.
+func (s *kllSketch) Add(x float64) {
+	s.n++
+	if x < s.min {
+		s.min = x
+	}
+	if x > s.max {
+		s.max = x
+	}
+	s.compactors[0] = append(s.compactors[0], x)
+	s.size++
+	if s.size > s.maxSize {
+		s.compress()
+	}
+}
+
+// compress compacts the lowest full compactor, half of its values
+// are promoted to the upper level with doubled weights.
+func (s *kllSketch) compress() {
+	for h := 0; h < len(s.compactors); h++ {
+		c := s.compactors[h]
+		if len(c) < s.capacity(h) {
+			continue
+		}
+		if h+1 == len(s.compactors) {
+			s.grow()
+		}
+		sort.Float64s(c)
+		m := len(c) &^ 1
+		for i := rand.Intn(2); i < m; i += 2 {
+			s.compactors[h+1] = append(s.compactors[h+1], c[i])
+		}
+		s.size -= m / 2
+		s.compactors[h] = append(c[:0], c[m:]...)
+		if s.size <= s.maxSize {
+			return
+		}
+	}
+}
+
+// Exact tells whether all values are kept.
+func (s *kllSketch) Exact() bool {
+	return len(s.compactors) == 1
+}
+
+// Count returns the number of values.
+func (s *kllSketch) Count() int64 {
+	return s.n
+}
+
+// Quantile returns the estimated q-quantile (0 <= q <= 1).
+// It's the same as percentileValue() if all values are kept.
+func (s *kllSketch) Quantile(q float64) float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	if s.Exact() {
+		sorted := append([]float64{}, s.compactors[0]...)
+		sort.Float64s(sorted)
+		return percentileValue(sorted, q)
+	}
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	type item struct {
+		v float64
+		w int64
+	}
+	items := make([]item, 0, s.size)
+	for h, c := range s.compactors {
+		for _, v := range c {
+			items = append(items, item{v, 1 << uint(h)})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].v < items[j].v })
+
+	var total int64
+	for _, it := range items {
+		total += it.w
+	}
+	target := q * float64(total)
+	var cum int64
+	for _, it := range items {
+		cum += it.w
+		if float64(cum) >= target {
+			return it.v
+		}
+	}
+	return s.max
+}
+
+// RankError returns the normalized rank error of quantiles with 99% confidence,
+// 0 is returned if all values are kept. The empirical formula is from Apache DataSketches.
+func (s *kllSketch) RankError() float64 {
+	if s.Exact() {
+		return 0
+	}
+	return 2.296 / math.Pow(float64(s.k), 0.9723)
+}
+
+// Items returns retained values and their weights, e.g., for histograms.
+func (s *kllSketch) Items() ([]float64, []float64) {
+	values := make([]float64, 0, s.size)
+	weights := make([]float64, 0, s.size)
+	for h, c := range s.compactors {
+		for _, v := range c {
+			values = append(values, v)
+			weights = append(weights, float64(int64(1)<<uint(h)))
+		}
+	}
+	return values, weights
+}
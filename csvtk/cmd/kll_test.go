@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestKLLSketch(t *testing.T) {
+	s := newKLLSketch(kllK)
+	exact := []float64{5, 1, 4, 2, 3}
+	for _, v := range exact {
+		s.Add(v)
+	}
+	sort.Float64s(exact)
+	for _, q := range []float64{0, 0.25, 0.5, 0.9, 1} {
+		if v, e := s.Quantile(q), percentileValue(exact, q); v != e {
+			t.Errorf("quantile %v of few values: expected %v, returned %v", q, e, v)
+		}
+	}
+
+	n := 100000
+	s = newKLLSketch(kllK)
+	r := rand.New(rand.NewSource(1))
+	for _, i := range r.Perm(n) {
+		s.Add(float64(i))
+	}
+	if s.Exact() {
+		t.Errorf("sketch should be compacted")
+	}
+	if s.size > 3*kllK {
+		t.Errorf("too many values retained: %d", s.size)
+	}
+	eps := s.RankError()
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99} {
+		rank := s.Quantile(q) / float64(n)
+		if math.Abs(rank-q) > eps {
+			t.Errorf("quantile %v: rank error %v > %v", q, math.Abs(rank-q), eps)
+		}
+	}
+}
+
+func TestKLLSketchExactLimit(t *testing.T) {
+	s := newKLLSketch(kllK)
+	for i := 0; i < kllK; i++ {
+		s.Add(float64(i))
+	}
+	if !s.Exact() {
+		t.Errorf("sketch of %d values should be exact", kllK)
+	}
+	s.Add(kllK)
+	if s.Exact() {
+		t.Errorf("sketch of %d values should be compacted", kllK+1)
+	}
+}
@@ -22,7 +22,8 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"image/color"
+	"math"
 	"runtime"
 	"sort"
 	"strconv"
@@ -31,9 +32,12 @@ import (
 	"github.com/shenwei356/util/stringutil"
 	"github.com/spf13/cobra"
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/text"
 	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
 )
 
 // lineCmd represents the line command
@@ -50,6 +54,27 @@ Notes:
   3. If flag -o/--out-file not set (default), image is written to stdout,
      you can display the image by pipping to "display" command of Imagemagic
      or just redirect to file.
+  4. Points can be colored by values of a numeric column (--color-field)
+     in a continuous palette, with a color bar. Available palettes are the
+     same as those of "csvtk plot heatmap", but "auto" uses viridis rather
+     than extended-kindlmann for values of one sign, as the latter ends at
+     white. Groups (-g) are then shown in different shapes.
+  5. Point sizes can be mapped to values of a numeric column (--size-field),
+     areas of points are proportional to values.
+  6. Points can be labelled with values of a column (--label-field), empty
+     values and NA values (--na-values) are not shown. Use --label-values
+     to only label some points, or prepare a column with "csvtk mutate2".
+  7. Trend lines can be drawn for each group, a polynomial fitted by least
+     squares (--fit-degree, 1 for a linear regression), or a LOESS curve
+     (--loess) with local linear regressions.
+  8. Multiple columns can be given to -y, each one is plotted as a series.
+     Error bars can be drawn from columns of errors (--y-err) or of lower
+     and upper bounds (--y-low and --y-high), or as shaded ribbons (--ribbon).
+     Columns of these flags should be given one by one, with the same number
+     and order as those of -y.
+  9. Series on a different scale can be plotted along a secondary Y axis
+     on the right (--y2-field), values are linearly mapped to the left axis.
+     Flags --y-min, --y-max, --y-trans and --y-log only apply to the left axis.
 
 `,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -65,6 +90,9 @@ Notes:
 		lineWidth := vg.Points(getFlagPositiveFloat64(cmd, "line-width"))
 		pointSize := vg.Length(getFlagPositiveFloat64(cmd, "point-size"))
 		scatter := getFlagBool(cmd, "scatter")
+		fitDegree := getFlagNonNegativeInt(cmd, "fit-degree")
+		loess := getFlagBool(cmd, "loess")
+		loessSpan := getFlagPositiveFloat64(cmd, "loess-span")
 		colorIndex := getFlagPositiveInt(cmd, "color-index")
 		if colorIndex > 7 {
 			checkError(fmt.Errorf("unsupported color index"))
@@ -85,10 +113,7 @@ Notes:
 		if dataFieldYStr == "" {
 			checkError(fmt.Errorf("flag -y (--data-field-y) needed"))
 		}
-		if strings.Contains(dataFieldYStr, ",") {
-			checkError(fmt.Errorf("only one field allowed for flag -y (--data-field-y)"))
-		}
-		if dataFieldXStr[0] == '-' {
+		if dataFieldYStr[0] == '-' {
 			checkError(fmt.Errorf("unselect not allowed for flag -y (--data-field-y)"))
 		}
 
@@ -105,6 +130,108 @@ Notes:
 			plotConfig.fieldStr = dataFieldXStr + "," + dataFieldYStr
 		}
 
+		// optional fields following X, Y and the group. Indexes are relative
+		// to the group, as the number of Y fields is known after parsing.
+		colorFieldStr := getFlagString(cmd, "color-field")
+		sizeFieldStr := getFlagString(cmd, "size-field")
+		labelFieldStr := getFlagString(cmd, "label-field")
+		colorIdx, sizeIdx, labelIdx := -1, -1, -1
+		idx := 0
+		if groupFieldStr != "" {
+			idx++
+		}
+		for _, f := range []struct {
+			flag  string
+			value string
+			idx   *int
+		}{
+			{"color-field", colorFieldStr, &colorIdx},
+			{"size-field", sizeFieldStr, &sizeIdx},
+			{"label-field", labelFieldStr, &labelIdx},
+		} {
+			if f.value == "" {
+				continue
+			}
+			checkPlotField(f.flag, f.value)
+			plotConfig.fieldStr += "," + f.value
+			*f.idx = idx
+			idx++
+		}
+
+		// fields of errors and the secondary Y axis, given one by one
+		yErrFieldStr := getFlagString(cmd, "y-err")
+		yLowFieldStr := getFlagString(cmd, "y-low")
+		yHighFieldStr := getFlagString(cmd, "y-high")
+		y2FieldStr := getFlagString(cmd, "y2-field")
+		ribbon := getFlagBool(cmd, "ribbon")
+		if yErrFieldStr != "" && (yLowFieldStr != "" || yHighFieldStr != "") {
+			checkError(fmt.Errorf("flag --y-err and --y-low/--y-high are incompatible"))
+		}
+		if (yLowFieldStr == "") != (yHighFieldStr == "") {
+			checkError(fmt.Errorf("flag --y-low and --y-high should be both given"))
+		}
+		var errorFieldStrs []string // fields of errors, or lower bounds followed by upper bounds
+		if yErrFieldStr != "" {
+			errorFieldStrs = []string{yErrFieldStr}
+		} else if yLowFieldStr != "" {
+			errorFieldStrs = []string{yLowFieldStr, yHighFieldStr}
+		}
+		if ribbon && errorFieldStrs == nil {
+			checkError(fmt.Errorf("flag --y-err or --y-low/--y-high needed for --ribbon"))
+		}
+		errIdx, y2Idx := -1, -1
+		var nErr, nY2 int
+		for i, v := range errorFieldStrs {
+			n := len(strings.Split(v, ","))
+			if i > 0 && n != nErr {
+				checkError(fmt.Errorf("numbers of fields of --y-low (%d) and --y-high (%d) do not match", nErr, n))
+			}
+			nErr = n
+			if v[0] == '-' {
+				checkError(fmt.Errorf("unselect not allowed for flag --y-err, --y-low and --y-high"))
+			}
+			plotConfig.fieldStr += "," + v
+			if errIdx < 0 {
+				errIdx = idx
+			}
+			idx += n
+		}
+		if y2FieldStr != "" {
+			if y2FieldStr[0] == '-' {
+				checkError(fmt.Errorf("unselect not allowed for flag --y2-field"))
+			}
+			if plotConfig.yaxis.log {
+				checkError(fmt.Errorf("flag --y2-field is incompatible with --y-log"))
+			}
+			nY2 = len(strings.Split(y2FieldStr, ","))
+			plotConfig.fieldStr += "," + y2FieldStr
+			y2Idx = idx
+			idx += nY2
+		}
+
+		heatmapOpt := &heatmapOptions{
+			palette:        strings.ToLower(getFlagString(cmd, "palette")),
+			reversePalette: getFlagBool(cmd, "reverse-palette"),
+			min:            math.NaN(),
+			max:            math.NaN(),
+			sequential:     "viridis", // points of the maximum value are visible on white
+		}
+		if colorIdx >= 0 {
+			if _, _, err := heatmapColorMap(heatmapOpt.palette); err != nil && heatmapOpt.palette != "auto" {
+				checkError(err)
+			}
+		}
+		sizeMin := vg.Length(getFlagPositiveFloat64(cmd, "size-min"))
+		sizeMax := vg.Length(getFlagPositiveFloat64(cmd, "size-max"))
+		if sizeMin > sizeMax {
+			checkError(fmt.Errorf("value of --size-min should not be greater than --size-max"))
+		}
+		labelValues := getFlagStringSlice(cmd, "label-values")
+		labelValuesMap := make(map[string]interface{}, len(labelValues))
+		for _, v := range labelValues {
+			labelValuesMap[v] = struct{}{}
+		}
+
 		skipNA := getFlagBool(cmd, "skip-na")
 		naValues := getFlagStringSlice(cmd, "na-values")
 		if skipNA && len(naValues) == 0 {
@@ -115,8 +242,17 @@ Notes:
 			naMap[strings.ToLower(na)] = struct{}{}
 		}
 
+		facets := getPlotFacets(cmd)
+		if facets.Enabled() && nY2 > 0 {
+			checkError(fmt.Errorf("flag --y2-field is incompatible with --facet"))
+		}
+		fieldStr := plotConfig.fieldStr
+		if facets.Enabled() {
+			fieldStr += "," + facets.field
+		}
+
 		file := files[0]
-		headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, plotConfig.fieldStr, false, false)
+		headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, fieldStr, false, false)
 
 		if err != nil {
 			// if err == xopen.ErrNoContent {
@@ -126,15 +262,69 @@ Notes:
 			checkError(err)
 		}
 
+		headerRow, rowFacets := facets.Split(headerRow, data)
+
+		// indexes of fields: X, Y..., [group], [color], [size], [label], [errors], [Y2]
+		nY := len(fields) - 1 - idx
+		if facets.Enabled() {
+			nY--
+		}
+		if len(fields) > 0 && nY < 1 {
+			checkError(fmt.Errorf("flag -y (--data-field-y) needed"))
+		}
+		if nErr > 0 && nErr != nY {
+			checkError(fmt.Errorf("numbers of fields of -y (%d) and --y-err or --y-low/--y-high (%d) do not match", nY, nErr))
+		}
+		groupIdx := 1 + nY
+		for _, i := range []*int{&colorIdx, &sizeIdx, &labelIdx, &errIdx, &y2Idx} {
+			if *i >= 0 {
+				*i += groupIdx
+			}
+		}
+		yCols := make([]int, 0, nY+nY2) // columns of all series, Y2 fields following Y fields
+		for j := 0; j < nY; j++ {
+			yCols = append(yCols, 1+j)
+		}
+		for j := 0; j < nY2; j++ {
+			yCols = append(yCols, y2Idx+j)
+		}
+		colName := func(col int) string {
+			if len(headerRow) > col {
+				return headerRow[col]
+			}
+			return fmt.Sprintf("column %d", fields[col])
+		}
+		// points are distinguished by shapes and colors in the legend
+		multiSeries := groupFieldStr != "" || len(yCols) > 1
+
 		// =======================================
 
-		groups := make(map[string]plotter.XYs)
+		groups := make([]map[string]*linePoints, facets.N())
+		for i := range groups {
+			groups[i] = make(map[string]*linePoints)
+		}
 		groupOrderMap := make(map[string]int)
-		var x, y float64
+		secondary := make(map[string]bool)
+		var x, y, yRaw, c, z, lo, hi float64
 		var ok bool
 		var order int
-		var groupName string
-		for _, d := range data {
+		var groupName, seriesName, label, v string
+		var pts *linePoints
+		colorMin, colorMax := math.Inf(1), math.Inf(-1)
+		sizeValueMin, sizeValueMax := math.Inf(1), math.Inf(-1)
+		yMin, yMax := math.Inf(1), math.Inf(-1)   // range of the left axis
+		y2Min, y2Max := math.Inf(1), math.Inf(-1) // range of the right axis
+		parseBound := func(v string, flag string) float64 {
+			if _, ok := naMap[strings.ToLower(v)]; ok || v == "" {
+				return math.NaN()
+			}
+			b, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				checkError(fmt.Errorf("fail to parse value: %s. please choose the right column by flag %s", v, flag))
+			}
+			return b
+		}
+		for r, d := range data {
 			if skipNA {
 				if _, ok = naMap[strings.ToLower(d[0])]; ok {
 					continue
@@ -149,40 +339,161 @@ Notes:
 					checkError(fmt.Errorf("fail to parse X value: %s at column: %d. please choose the right column by flag --data-field-x", d[0], fields[0]))
 				}
 			}
-			if len(d) > 1 {
+
+			if colorIdx >= 0 {
 				if skipNA {
-					if _, ok = naMap[strings.ToLower(d[1])]; ok {
+					if _, ok = naMap[strings.ToLower(d[colorIdx])]; ok {
 						continue
 					}
 				}
-				y, err = strconv.ParseFloat(d[1], 64)
-			} else {
-				y, err = strconv.ParseFloat(d[0], 64)
+				c, err = strconv.ParseFloat(d[colorIdx], 64)
+				if err != nil {
+					checkError(fmt.Errorf("fail to parse color value: %s. please choose the right column by flag --color-field", d[colorIdx]))
+				}
 			}
-			if err != nil {
-				if len(headerRow) > 0 {
-					checkError(fmt.Errorf("fail to parse Y value: %s at column: %s. please choose the right column by flag --data-field-y", d[1], headerRow[1]))
-				} else {
-					checkError(fmt.Errorf("fail to parse Y value: %s at column: %d. please choose the right column by flag --data-field-y", d[1], fields[1]))
+			if sizeIdx >= 0 {
+				if skipNA {
+					if _, ok = naMap[strings.ToLower(d[sizeIdx])]; ok {
+						continue
+					}
+				}
+				z, err = strconv.ParseFloat(d[sizeIdx], 64)
+				if err != nil {
+					checkError(fmt.Errorf("fail to parse size value: %s. please choose the right column by flag --size-field", d[sizeIdx]))
 				}
 			}
 
-			if len(d) > 2 {
-				groupName = d[2]
+			x, ok = plotConfig.xaxis.Value(x)
+			if !ok {
+				continue
+			}
+
+			if groupFieldStr != "" {
+				groupName = d[groupIdx]
 			} else {
 				groupName = ""
 			}
-			if _, ok = groups[groupName]; !ok {
-				groups[groupName] = make(plotter.XYs, 0)
+			if labelIdx >= 0 {
+				label = d[labelIdx]
+				if _, ok = naMap[strings.ToLower(label)]; ok {
+					label = ""
+				} else if len(labelValuesMap) > 0 {
+					if _, ok = labelValuesMap[label]; !ok {
+						label = ""
+					}
+				}
+			}
+
+			for j, col := range yCols {
+				v = d[col]
+				if skipNA {
+					if _, ok = naMap[strings.ToLower(v)]; ok {
+						continue
+					}
+				}
+				yRaw, err = strconv.ParseFloat(v, 64)
+				if err != nil {
+					flag := "--data-field-y"
+					if j >= nY {
+						flag = "--y2-field"
+					}
+					checkError(fmt.Errorf("fail to parse Y value: %s at column: %s. please choose the right column by flag %s", v, colName(col), flag))
+				}
+
+				if j < nY {
+					y, ok = plotConfig.yaxis.Value(yRaw)
+					if !ok {
+						continue
+					}
+					yMin, yMax = math.Min(yMin, y), math.Max(yMax, y)
+				} else {
+					y = yRaw
+					if math.IsNaN(y) || math.IsInf(y, 0) {
+						continue
+					}
+					y2Min, y2Max = math.Min(y2Min, y), math.Max(y2Max, y)
+				}
+
+				switch {
+				case len(yCols) == 1:
+					seriesName = groupName
+				case groupFieldStr != "":
+					seriesName = groupName + ": " + colName(col)
+				default:
+					seriesName = colName(col)
+				}
+				if j >= nY {
+					seriesName += " (right)"
+					secondary[seriesName] = true
+				}
+
+				if pts, ok = groups[rowFacets[r]][seriesName]; !ok {
+					pts = &linePoints{}
+					groups[rowFacets[r]][seriesName] = pts
+				}
+				pts.xys = append(pts.xys, plotter.XY{X: x, Y: y})
+				if colorIdx >= 0 {
+					pts.colors = append(pts.colors, c)
+					colorMin, colorMax = math.Min(colorMin, c), math.Max(colorMax, c)
+				}
+				if sizeIdx >= 0 {
+					pts.sizes = append(pts.sizes, z)
+					sizeValueMin, sizeValueMax = math.Min(sizeValueMin, z), math.Max(sizeValueMax, z)
+				}
+				if labelIdx >= 0 {
+					pts.labels = append(pts.labels, label)
+				}
+				if errIdx >= 0 && j < nY {
+					if yErrFieldStr != "" {
+						lo = parseBound(d[errIdx+j], "--y-err")
+						lo, hi = yRaw-lo, yRaw+lo
+					} else {
+						lo = parseBound(d[errIdx+j], "--y-low")
+						hi = parseBound(d[errIdx+nErr+j], "--y-high")
+					}
+					lo, hi = plotConfig.yaxis.Bound(lo), plotConfig.yaxis.Bound(hi)
+					if lo > hi { // e.g., -log10
+						lo, hi = hi, lo
+					}
+					if !math.IsNaN(lo) {
+						yMin = math.Min(yMin, lo)
+					}
+					if !math.IsNaN(hi) {
+						yMax = math.Max(yMax, hi)
+					}
+					pts.lows = append(pts.lows, lo)
+					pts.highs = append(pts.highs, hi)
+				}
+
+				if _, ok = groupOrderMap[seriesName]; !ok {
+					groupOrderMap[seriesName] = order
+					order++
+				}
+			}
+		}
+
+		// values of the secondary axis are mapped to the range of the left axis
+		var y2Axis *lineSecondaryAxis
+		if len(secondary) > 0 {
+			y2Axis = newLineSecondaryAxis(y2Min, y2Max, yMin, yMax)
+			y2Axis.label = getFlagString(cmd, "y2lab")
+			if y2Axis.label == "" {
+				names := make([]string, nY2)
+				for j := range names {
+					names[j] = colName(y2Idx + j)
+				}
+				y2Axis.label = strings.Join(names, ", ")
 			}
-			groups[groupName] = append(groups[groupName], struct{ X, Y float64 }{X: x, Y: y})
-			if _, ok = groupOrderMap[groupName]; !ok {
-				groupOrderMap[groupName] = order
-				order++
+			for name := range secondary {
+				xys := groups[0][name].xys
+				for i := range xys {
+					xys[i].Y = y2Axis.toLeft(xys[i].Y)
+				}
 			}
 		}
 
-		p := plot.New()
+		plotConfig.xaxis.WarnRemoved()
+		plotConfig.yaxis.WarnRemoved()
 
 		var groupOrders []stringutil.StringCount
 		for g := range groupOrderMap {
@@ -190,102 +501,501 @@ Notes:
 		}
 		sort.Sort(stringutil.StringCountList(groupOrders))
 
-		i := colorIndex - 1
-		for _, gor := range groupOrders {
-			v := groups[gor.Key]
-			g := gor.Key
-			if !scatter {
-				lines, points, err := plotter.NewLinePoints(v)
-				checkError(err)
-				lines.Color = plotutil.Color(i)
-				lines.LineStyle.Dashes = plotutil.Dashes(i)
-				lines.LineStyle.Width = lineWidth
-				points.Shape = plotutil.Shape(i)
-				points.Color = plotutil.Color(i)
-				points.Radius = pointSize
-				p.Add(lines, points)
-				p.Legend.Add(g, lines, points)
-			} else {
-				points, err := plotter.NewScatter(v)
-				checkError(err)
+		var cm palette.ColorMap
+		var colorBar *plot.Plot
+		if colorIdx >= 0 && !math.IsInf(colorMin, 1) {
+			cm, err = heatmapColorScale(colorMin, colorMax, heatmapOpt)
+			checkError(err)
+			colorBar = newColorBarPlot(cm)
+			if len(headerRow) > colorIdx {
+				colorBar.Title.Text = headerRow[colorIdx]
+			}
+			colorBar.Title.TextStyle.Font.Size = plotConfig.tickLabelSize
+			colorBar.Y.Tick.Label.Font.Size = plotConfig.tickLabelSize
+			colorBar.Y.Width = plotConfig.axisWidth
+			colorBar.Y.Tick.Width = plotConfig.tickWidth
+		}
+		pointRadius := func(v float64) vg.Length {
+			if sizeValueMax <= sizeValueMin {
+				return (sizeMin + sizeMax) / 2
+			}
+			return sizeMin + (sizeMax-sizeMin)*vg.Length(math.Sqrt((v-sizeValueMin)/(sizeValueMax-sizeValueMin)))
+		}
+
+		plots := make([]*plot.Plot, len(groups))
+		for k := range groups {
+			if len(groups[k]) == 0 {
+				continue
+			}
+			p := plotConfig.newPlot()
+
+			// colors are decided by the order of groups in all data
+			for j, gor := range groupOrders {
+				pts := groups[k][gor.Key]
+				if pts == nil {
+					continue
+				}
+				v := pts.xys
+				g := gor.Key
+				i := colorIndex - 1 + j
+
+				var thumbnails []plot.Thumbnailer
+				if pts.lows != nil {
+					if ribbon {
+						poly, err := plotter.NewPolygon(lineRibbon(v, pts.lows, pts.highs))
+						checkError(err)
+						r, g, b, _ := plotConfig.color(i).RGBA()
+						poly.Color = color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0x50}
+						poly.LineStyle.Width = 0
+						p.Add(poly)
+						thumbnails = append(thumbnails, poly)
+					} else {
+						bars, err := plotter.NewYErrorBars(lineErrors(v, pts.lows, pts.highs))
+						checkError(err)
+						bars.Color = plotConfig.color(i)
+						bars.LineStyle.Width = lineWidth
+						bars.CapWidth = pointSize * 2
+						p.Add(bars)
+					}
+				}
+
+				var lines *plotter.Line
+				var points *plotter.Scatter
+				if !scatter {
+					lines, points, err = plotter.NewLinePoints(v)
+					checkError(err)
+					lines.Color = plotConfig.color(i)
+					lines.LineStyle.Dashes = plotutil.Dashes(i)
+					lines.LineStyle.Width = lineWidth
+					p.Add(lines)
+				} else {
+					points, err = plotter.NewScatter(v)
+					checkError(err)
+				}
 				points.Shape = plotutil.Shape(i)
-				points.Color = plotutil.Color(i)
+				points.Color = plotConfig.color(i)
 				points.Radius = pointSize
+				if cm != nil || sizeIdx >= 0 {
+					if !multiSeries {
+						points.Shape = draw.CircleGlyph{}
+					}
+					if cm != nil { // groups are only distinguished by shapes in the legend
+						points.Color = color.Black
+					}
+					points.GlyphStyleFunc = func(n int) draw.GlyphStyle {
+						style := points.GlyphStyle
+						if cm != nil {
+							style.Color, _ = cm.At(math.Max(cm.Min(), math.Min(cm.Max(), pts.colors[n])))
+						}
+						if sizeIdx >= 0 {
+							style.Radius = pointRadius(pts.sizes[n])
+						}
+						return style
+					}
+				}
 				p.Add(points)
-				p.Legend.Add(g, points)
+				if lines != nil {
+					thumbnails = append(thumbnails, lines)
+				}
+				if lines != nil || multiSeries || (cm == nil && sizeIdx < 0) {
+					p.Legend.Add(g, append(thumbnails, points)...)
+				}
+
+				if labelIdx >= 0 {
+					l, err := pointLabels(v, pts.labels)
+					checkError(err)
+					if l != nil {
+						for n := range l.TextStyle {
+							l.TextStyle[n].Font.Size = plotConfig.tickLabelSize * 0.8
+							l.TextStyle[n].YAlign = text.YCenter
+						}
+						l.Offset = vg.Point{X: pointSize + vg.Points(2)}
+						if sizeIdx >= 0 {
+							l.Offset.X = sizeMax/2 + vg.Points(2)
+						}
+						p.Add(l)
+					}
+				}
+
+				if fitDegree > 0 {
+					line, err := fittedLine(v, fitDegree)
+					if err != nil {
+						log.Warningf("fail to fit a line for group %s: %s", g, err)
+					} else {
+						line.Color = plotConfig.color(i)
+						line.LineStyle.Width = lineWidth
+						line.LineStyle.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+						p.Add(line)
+					}
+				}
+				if loess {
+					curve, err := loessCurve(v, loessSpan, 100)
+					if err != nil {
+						log.Warningf("fail to fit a LOESS curve for group %s: %s", g, err)
+					} else {
+						line, err := plotter.NewLine(curve)
+						checkError(err)
+						line.Color = plotConfig.color(i)
+						line.LineStyle.Width = lineWidth
+						line.LineStyle.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+						p.Add(line)
+					}
+				}
 			}
 
-			i++
-		}
-		if lineWidth > pointSize {
-			p.Legend.Padding = vg.Length(lineWidth)
-		} else {
-			p.Legend.Padding = vg.Length(pointSize)
+			legendPadding := pointSize
+			if lineWidth > legendPadding {
+				legendPadding = lineWidth
+			}
+			if sizeIdx >= 0 && !math.IsInf(sizeValueMin, 1) {
+				// sizes of the minimum, median and maximum values
+				var name string
+				if len(headerRow) > sizeIdx {
+					name = headerRow[sizeIdx] + ": "
+				}
+				for _, v := range []float64{sizeValueMin, (sizeValueMin + sizeValueMax) / 2, sizeValueMax} {
+					points, err := plotter.NewScatter(plotter.XYs{{}})
+					checkError(err)
+					points.Color = color.Black
+					points.Radius = pointRadius(v)
+					if !multiSeries {
+						points.Shape = draw.CircleGlyph{}
+					}
+					v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'g', 4, 64), 64) // 4 significant digits
+					p.Legend.Add(name+strconv.FormatFloat(v, 'f', -1, 64), points)
+					if sizeValueMax <= sizeValueMin {
+						break
+					}
+				}
+				legendPadding = sizeMax / 2
+			}
+			p.Legend.Padding = legendPadding
+			p.Legend.Top = getFlagBool(cmd, "legend-top")
+			p.Legend.Left = getFlagBool(cmd, "legend-left")
+			plots[k] = p
 		}
-		p.Legend.Top = getFlagBool(cmd, "legend-top")
-		p.Legend.Left = getFlagBool(cmd, "legend-left")
 
 		if plotConfig.ylab == "" {
-			if len(headerRow) > 1 {
-				plotConfig.ylab = headerRow[1]
+			if nY > 1 {
+				plotConfig.ylab = plotConfig.yaxis.Label("Values")
+			} else if len(headerRow) > 1 {
+				plotConfig.ylab = plotConfig.yaxis.Label(headerRow[1])
 			} else {
-				plotConfig.ylab = "Y Values"
+				plotConfig.ylab = plotConfig.yaxis.Label("Y Values")
 			}
 		}
 		if plotConfig.xlab == "" {
 			if len(headerRow) > 0 {
-				plotConfig.xlab = headerRow[0]
+				plotConfig.xlab = plotConfig.xaxis.Label(headerRow[0])
 			} else {
-				plotConfig.xlab = "X Values"
+				plotConfig.xlab = plotConfig.xaxis.Label("X Values")
 			}
 		}
 
-		p.Title.Text = plotConfig.title
-		p.Title.TextStyle.Font.Size = plotConfig.titleSize
-		p.X.Label.Text = plotConfig.xlab
-		p.Y.Label.Text = plotConfig.ylab
-		p.X.Label.TextStyle.Font.Size = plotConfig.labelSize
-		p.Y.Label.TextStyle.Font.Size = plotConfig.labelSize
-		p.X.Width = plotConfig.axisWidth
-		p.Y.Width = plotConfig.axisWidth
-		p.X.Tick.Width = plotConfig.tickWidth
-		p.Y.Tick.Width = plotConfig.tickWidth
-		p.X.Tick.Label.Font.Size = plotConfig.tickLabelSize
-		p.Y.Tick.Label.Font.Size = plotConfig.tickLabelSize
+		for _, p := range plots {
+			if p != nil {
+				plotConfig.applyStyle(p)
+				plotConfig.applyLimits(p)
+			}
+		}
 
-		if plotConfig.xminStr != "" {
-			p.X.Min = plotConfig.xmin
+		if facets.Enabled() {
+			facets.colorBar = colorBar
+			checkError(facets.Save(plots, plotConfig, config.OutFile))
+			return
 		}
-		if plotConfig.xmaxStr != "" {
-			p.X.Max = plotConfig.xmax
+
+		p := plots[0]
+		if p == nil {
+			p = plotConfig.newPlot()
 		}
-		if plotConfig.yminStr != "" {
-			p.Y.Min = plotConfig.ymin
+		plotConfig.applyScales(p)
+
+		if colorBar != nil || y2Axis != nil {
+			fn := p.Draw
+			if y2Axis != nil {
+				fn = func(dc draw.Canvas) {
+					y2Axis.draw(dc, p)
+				}
+			}
+			if colorBar != nil {
+				drawPlot := fn
+				fn = func(dc draw.Canvas) {
+					drawWithColorBar(dc, colorBar, drawPlot)
+				}
+			}
+			if plotConfig.term {
+				plots := []*plot.Plot{p}
+				if colorBar != nil {
+					plots = append(plots, colorBar)
+				}
+				checkError(plotConfig.saveTerm(config.OutFile, fn, plots...))
+			} else {
+				checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, fn))
+			}
+			return
 		}
-		if plotConfig.ymaxStr != "" {
-			p.Y.Max = plotConfig.ymax
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
 		}
 
 		// Save image
-		if isStdin(config.OutFile) {
-			fh, err := p.WriterTo(plotConfig.width*vg.Inch,
-				plotConfig.height*vg.Inch,
-				plotConfig.format)
-			checkError(err)
-			_, err = fh.WriteTo(os.Stdout)
-			checkError(err)
-		} else {
-			checkError(p.Save(plotConfig.width*vg.Inch,
-				plotConfig.height*vg.Inch,
-				config.OutFile))
-		}
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
 	},
 }
 
+// fittedLine returns the polynomial least squares line of points, over the range of X.
+func fittedLine(xys plotter.XYs, degree int) (*plotter.Line, error) {
+	xs := make([][]float64, len(xys))
+	ys := make([]float64, len(xys))
+	xmin, xmax := math.Inf(1), math.Inf(-1)
+	for i, xy := range xys {
+		xs[i] = []float64{xy.X}
+		ys[i] = xy.Y
+		xmin = math.Min(xmin, xy.X)
+		xmax = math.Max(xmax, xy.X)
+	}
+	model, err := fitLinearModel(xs, ys, []string{"x"}, degree, true)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 100
+	if degree == 1 {
+		n = 2
+	}
+	line := make(plotter.XYs, n)
+	step := (xmax - xmin) / float64(n-1)
+	for i := range line {
+		line[i].X = xmin + float64(i)*step
+		line[i].Y = model.Predict([]float64{line[i].X})
+	}
+	return plotter.NewLine(line)
+}
+
+// linePoints contains points of a series, and optional values of points
+// mapped to colors and sizes, labels, and lower and upper bounds of errors.
+type linePoints struct {
+	xys    plotter.XYs
+	colors []float64
+	sizes  []float64
+	labels []string
+	lows   []float64 // NaN for none
+	highs  []float64
+}
+
+// lineErrors returns points with errors for plotter.NewYErrorBars,
+// missing bounds are replaced with Y values.
+func lineErrors(xys plotter.XYs, lows, highs []float64) interface {
+	plotter.XYer
+	plotter.YErrorer
+} {
+	errs := make(plotter.YErrors, len(xys))
+	for i, xy := range xys {
+		if !math.IsNaN(lows[i]) {
+			errs[i].Low = xy.Y - lows[i]
+		}
+		if !math.IsNaN(highs[i]) {
+			errs[i].High = highs[i] - xy.Y
+		}
+	}
+	return struct {
+		plotter.XYs
+		plotter.YErrors
+	}{xys, errs}
+}
+
+// lineRibbon returns the outline of a ribbon between lower and upper bounds,
+// i.e., upper bounds in the order of points, followed by lower bounds in the
+// reversed order. Missing bounds are replaced with Y values.
+func lineRibbon(xys plotter.XYs, lows, highs []float64) plotter.XYs {
+	n := len(xys)
+	ring := make(plotter.XYs, 2*n)
+	for i, xy := range xys {
+		ring[i] = xy
+		if !math.IsNaN(highs[i]) {
+			ring[i].Y = highs[i]
+		}
+		ring[2*n-1-i] = xy
+		if !math.IsNaN(lows[i]) {
+			ring[2*n-1-i].Y = lows[i]
+		}
+	}
+	return ring
+}
+
+// lineSecondaryAxis is a secondary Y axis on the right. Its values are
+// linearly mapped to the left axis, i.e., left = a * right + b.
+type lineSecondaryAxis struct {
+	label string
+	a, b  float64
+}
+
+// newLineSecondaryAxis maps the range of values of the right axis to the
+// range of the left axis.
+func newLineSecondaryAxis(min, max, leftMin, leftMax float64) *lineSecondaryAxis {
+	ax := &lineSecondaryAxis{a: 1}
+	if max > min && leftMax > leftMin {
+		ax.a = (leftMax - leftMin) / (max - min)
+	}
+	if !math.IsInf(min, 0) && !math.IsInf(leftMin, 0) {
+		ax.b = leftMin - ax.a*min
+	}
+	return ax
+}
+
+func (ax *lineSecondaryAxis) toLeft(v float64) float64 {
+	return ax.a*v + ax.b
+}
+
+func (ax *lineSecondaryAxis) fromLeft(v float64) float64 {
+	return (v - ax.b) / ax.a
+}
+
+// draw draws the plot, leaving space on the right for the secondary axis,
+// which shares the styles of the left axis.
+func (ax *lineSecondaryAxis) draw(dc draw.Canvas, p *plot.Plot) {
+	min, max := ax.fromLeft(p.Y.Min), ax.fromLeft(p.Y.Max)
+	if min > max {
+		min, max = max, min
+	}
+	ticks := plot.DefaultTicks{}.Ticks(min, max)
+
+	tickStyle := p.Y.Tick.Label
+	tickStyle.XAlign = draw.XLeft
+	var labelWidth vg.Length
+	for _, t := range ticks {
+		if !t.IsMinor() {
+			labelWidth = vg.Length(math.Max(float64(labelWidth), float64(tickStyle.Width(t.Label))))
+		}
+	}
+	labelStyle := p.Y.Label.TextStyle
+	labelStyle.Rotation -= math.Pi / 2
+
+	width := p.Y.Padding + p.Y.Width/2 + p.Y.Tick.Length + tickStyle.Width(" ") + labelWidth
+	if ax.label != "" {
+		width += p.Y.Label.Padding + labelStyle.Height(ax.label) + labelStyle.FontExtents().Descent
+	}
+
+	c := draw.Crop(dc, 0, -width, 0, 0)
+	p.Draw(c)
+	da := p.DataCanvas(c)
+
+	x := c.Max.X + p.Y.Padding + p.Y.Width/2
+	dc.StrokeLine2(p.Y.LineStyle, x, da.Min.Y, x, da.Max.Y)
+	descent := tickStyle.FontExtents().Descent
+	var y vg.Length
+	for _, t := range ticks {
+		y = da.Y(p.Y.Norm(ax.toLeft(t.Value)))
+		if !da.ContainsY(y) {
+			continue
+		}
+		if t.IsMinor() {
+			dc.StrokeLine2(p.Y.Tick.LineStyle, x, y, x+p.Y.Tick.Length/2, y)
+			continue
+		}
+		dc.StrokeLine2(p.Y.Tick.LineStyle, x, y, x+p.Y.Tick.Length, y)
+		dc.FillText(tickStyle, vg.Point{X: x + p.Y.Tick.Length + tickStyle.Width(" "), Y: y + descent}, t.Label)
+	}
+	if ax.label != "" {
+		x = dc.Max.X - labelStyle.Height(ax.label)
+		dc.FillText(labelStyle, vg.Point{X: x + labelStyle.FontExtents().Descent, Y: da.Center().Y}, ax.label)
+	}
+}
+
+// pointLabels returns labels of points with non-empty labels, or nil for none.
+func pointLabels(xys plotter.XYs, labels []string) (*plotter.Labels, error) {
+	var data plotter.XYLabels
+	for i, label := range labels {
+		if label == "" {
+			continue
+		}
+		data.XYs = append(data.XYs, xys[i])
+		data.Labels = append(data.Labels, label)
+	}
+	if len(data.Labels) == 0 {
+		return nil, nil
+	}
+	return plotter.NewLabels(data)
+}
+
+// loessCurve returns n points of a LOESS curve over the range of X, with local
+// linear regressions weighted by the tricube function. Span is the fraction
+// of points used in each local regression.
+func loessCurve(xys plotter.XYs, span float64, n int) (plotter.XYs, error) {
+	N := len(xys)
+	if N < 3 {
+		return nil, fmt.Errorf("at least 3 points needed")
+	}
+	sorted := make(plotter.XYs, N)
+	copy(sorted, xys)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	xmin, xmax := sorted[0].X, sorted[N-1].X
+	if xmin == xmax {
+		return nil, fmt.Errorf("all X values are the same")
+	}
+
+	k := int(math.Ceil(span * float64(N)))
+	if k < 3 {
+		k = 3
+	}
+	if k > N {
+		k = N
+	}
+
+	curve := make(plotter.XYs, n)
+	var l int // start of the window of k nearest points
+	var x0, d, dist, w, sw, swx, swy, swxx, swxy float64
+	for i := range curve {
+		x0 = xmin + (xmax-xmin)*float64(i)/float64(n-1)
+		for l+k < N && x0-sorted[l].X > sorted[l+k].X-x0 {
+			l++
+		}
+		d = math.Max(x0-sorted[l].X, sorted[l+k-1].X-x0)
+		if span > 1 { // enlarge the neighbourhood like R
+			d *= span
+		}
+
+		sw, swx, swy, swxx, swxy = 0, 0, 0, 0, 0
+		for _, p := range sorted[l : l+k] {
+			dist = math.Abs(p.X - x0)
+			if d > 0 {
+				w = 1 - math.Pow(dist/d, 3)
+				w = w * w * w
+			} else {
+				w = 1
+			}
+			if w <= 0 {
+				continue
+			}
+			sw += w
+			swx += w * p.X
+			swy += w * p.Y
+			swxx += w * p.X * p.X
+			swxy += w * p.X * p.Y
+		}
+		curve[i].X = x0
+		if sw == 0 {
+			curve[i].Y = math.NaN()
+			continue
+		}
+		denom := sw*swxx - swx*swx
+		if math.Abs(denom) < 1e-12*sw*swxx { // all X values are the same, use the weighted mean
+			curve[i].Y = swy / sw
+			continue
+		}
+		b := (sw*swxy - swx*swy) / denom
+		curve[i].Y = (swy-b*swx)/sw + b*x0
+	}
+	return curve, nil
+}
+
 func init() {
 	plotCmd.AddCommand(lineCmd)
 	lineCmd.Flags().StringP("data-field-x", "x", "", `column index or column name of X for command line`)
-	lineCmd.Flags().StringP("data-field-y", "y", "", `column index or column name of Y for command line`)
+	lineCmd.Flags().StringP("data-field-y", "y", "", `column indexes or column names of Y for command line, multiple columns are plotted as series`)
 
 	lineCmd.Flags().BoolP("legend-top", "", false, "locate legend along the top edge of the plot")
 	lineCmd.Flags().BoolP("legend-left", "", false, "locate legend along the left edge of the plot")
@@ -294,4 +1004,27 @@ func init() {
 	lineCmd.Flags().Float64P("line-width", "", 1.5, "line width")
 	lineCmd.Flags().Float64P("point-size", "", 3, "point size")
 	lineCmd.Flags().IntP("color-index", "", 1, `color index, 1-7`)
+	lineCmd.Flags().IntP("fit-degree", "", 0, `draw a fitted polynomial line of degree N for each group, 0 for none`)
+	lineCmd.Flags().BoolP("loess", "", false, `draw a LOESS curve for each group`)
+	lineCmd.Flags().Float64P("loess-span", "", 0.75, `fraction of points used in local regressions of LOESS, i.e., smoothness`)
+
+	lineCmd.Flags().StringP("color-field", "", "", `column index or column name of numeric values mapped to colors of points`)
+	lineCmd.Flags().StringP("palette", "", "auto", `color palette for --color-field, see the help message of "csvtk plot heatmap"`)
+	lineCmd.Flags().BoolP("reverse-palette", "", false, "reverse the color palette")
+	lineCmd.Flags().StringP("size-field", "", "", `column index or column name of numeric values mapped to sizes of points`)
+	lineCmd.Flags().Float64P("size-min", "", 1.5, `point size of the minimum value of --size-field`)
+	lineCmd.Flags().Float64P("size-max", "", 8, `point size of the maximum value of --size-field`)
+	lineCmd.Flags().StringP("label-field", "", "", `column index or column name of labels of points`)
+	lineCmd.Flags().StringSliceP("label-values", "", []string{}, `only label points with these values of --label-field`)
+
+	lineCmd.Flags().StringP("y-err", "", "", `column indexes or column names of errors of Y, for error bars of Y +/- error`)
+	lineCmd.Flags().StringP("y-low", "", "", `column indexes or column names of lower bounds of Y, for error bars`)
+	lineCmd.Flags().StringP("y-high", "", "", `column indexes or column names of upper bounds of Y, for error bars`)
+	lineCmd.Flags().BoolP("ribbon", "", false, `draw errors as shaded ribbons instead of error bars`)
+	lineCmd.Flags().StringP("y2-field", "", "", `column indexes or column names of Y plotted along a secondary Y axis on the right`)
+	lineCmd.Flags().StringP("y2lab", "", "", `label of the secondary Y axis`)
+
+	lineCmd.Flags().StringP("facet", "", "", `column index or column name of facets, for plotting a sub-plot for each value`)
+	lineCmd.Flags().IntP("facet-cols", "", 0, `number of columns of sub-plots, 0 for automatic`)
+	lineCmd.Flags().StringP("facet-scales", "", "fixed", `axis ranges of sub-plots: fixed, free, free_x, or free_y`)
 }
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+func TestLoessCurve(t *testing.T) {
+	// local linear regressions reproduce a line
+	xys := make(plotter.XYs, 20)
+	for i := range xys {
+		xys[i].X = float64(19 - i)
+		xys[i].Y = 2*xys[i].X + 1
+	}
+	for _, span := range []float64{0.3, 0.75, 2} {
+		curve, err := loessCurve(xys, span, 10)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(curve) != 10 || curve[0].X != 0 || curve[9].X != 19 {
+			t.Errorf("span: %g, unexpected range of the curve: %v", span, curve)
+			continue
+		}
+		for _, p := range curve {
+			if math.Abs(p.Y-(2*p.X+1)) > 1e-9 {
+				t.Errorf("span: %g, expected %g at %g, returned %g", span, 2*p.X+1, p.X, p.Y)
+			}
+		}
+	}
+
+	if _, err := loessCurve(xys[:2], 0.75, 10); err == nil {
+		t.Errorf("expected an error for 2 points")
+	}
+	if _, err := loessCurve(plotter.XYs{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 1, Y: 3}}, 0.75, 10); err == nil {
+		t.Errorf("expected an error for constant X values")
+	}
+}
+
+func TestLineRibbon(t *testing.T) {
+	xys := plotter.XYs{{X: 1, Y: 2}, {X: 2, Y: 3}, {X: 3, Y: 4}}
+	lows := []float64{1, math.NaN(), 3}
+	highs := []float64{3, 5, math.NaN()}
+	expected := plotter.XYs{{X: 1, Y: 3}, {X: 2, Y: 5}, {X: 3, Y: 4}, {X: 3, Y: 3}, {X: 2, Y: 3}, {X: 1, Y: 1}}
+	ring := lineRibbon(xys, lows, highs)
+	if len(ring) != len(expected) {
+		t.Fatalf("expected %v, returned %v", expected, ring)
+	}
+	for i := range ring {
+		if ring[i] != expected[i] {
+			t.Errorf("expected %v, returned %v", expected, ring)
+			break
+		}
+	}
+}
+
+func TestLineSecondaryAxis(t *testing.T) {
+	tests := []struct {
+		min, max, leftMin, leftMax float64
+		v, left                    float64
+	}{
+		{20, 30, 0, 1, 25, 0.5},
+		{20, 30, 0, 1, 20, 0},
+		{-1, 1, 10, 20, 1, 20},
+		{5, 5, 0, 1, 5, 0}, // constant values
+	}
+	for _, test := range tests {
+		ax := newLineSecondaryAxis(test.min, test.max, test.leftMin, test.leftMax)
+		if left := ax.toLeft(test.v); math.Abs(left-test.left) > 1e-12 {
+			t.Errorf("%v: expected %g, returned %g", test, test.left, left)
+		}
+		if v := ax.fromLeft(ax.toLeft(test.v)); math.Abs(v-test.v) > 1e-12 {
+			t.Errorf("%v: expected %g, returned %g", test, test.v, v)
+		}
+	}
+}
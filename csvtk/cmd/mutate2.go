@@ -105,7 +105,7 @@ Custom functions:
 			checkError(fmt.Errorf("falg -n (--name) needed"))
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -139,7 +139,7 @@ type mutate3Opts struct {
 }
 
 func doMutate3(config Config, opts mutate3Opts) {
-	outfh, err := xopen.Wopen(config.OutFile)
+	outfh, err := wopenByConfig(config, config.OutFile)
 	checkError(err)
 	defer outfh.Close()
 
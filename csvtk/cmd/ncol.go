@@ -45,7 +45,7 @@ var ncolCmd = &cobra.Command{
 
 		printFileName := getFlagBool(cmd, "file-name")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
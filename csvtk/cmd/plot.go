@@ -22,10 +22,13 @@ package cmd
 
 import (
 	"fmt"
+	"image/color"
+	"math"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/vg"
 )
 
@@ -45,6 +48,37 @@ Notes:
   3. If flag -o/--out-file not set (default), image is written to stdout,
      you can display the image by pipping to "display" command of Imagemagic
      or just redirect to file.
+  4. Values can be transformed with --x-trans and --y-trans before plotting,
+     available: log10, -log10, log2, ln, sqrt. Axis limits (--x-min, etc.)
+     are in the transformed scale.
+  5. On log axes (--x-log, --y-log), non-positive values are removed.
+  6. Plots can be drawn in the terminal with --term, using Unicode braille
+     and block characters, with ANSI colors if stdout is a terminal.
+     Plots are sized to the terminal, unless --term-width or --term-height
+     is given. Flags --width, --height, --format and font sizes are ignored.
+  7. Styles can be set with a theme (--theme), a built-in one or a YAML/JSON
+     file, which sets fonts, font sizes, the palette, background, grid lines,
+     width, height and DPI of figures, and the legend position. Values of
+     flags given in the command line are preferred. Built-in themes:
+       default, minimal, colorblind, talk
+     An example theme file:
+
+       font: sans          # serif, sans, or mono
+       titleSize: 16
+       labelSize: 14
+       tickLabelSize: 12
+       axisWidth: 1
+       tickWidth: 1
+       lineWidth: 1.5
+       pointSize: 3
+       palette: ["#1b9e77", "#d95f02", "#7570b3", "#e7298a"]
+       background: "#ffffff"  # or transparent
+       grid: true
+       gridColor: "#d9d9d9"
+       width: 6
+       height: 4.5
+       dpi: 300
+       legend: top-left    # top-right, top-left, bottom-right, or bottom-left
 
 `,
 }
@@ -64,6 +98,13 @@ func init() {
 	plotCmd.PersistentFlags().StringP("y-min", "", "", `minimum value of Y axis`)
 	plotCmd.PersistentFlags().StringP("y-max", "", "", `maximum value of Y axis`)
 
+	plotCmd.PersistentFlags().BoolP("x-log", "", false, `log10 scale of X axis`)
+	plotCmd.PersistentFlags().BoolP("y-log", "", false, `log10 scale of Y axis`)
+	plotCmd.PersistentFlags().BoolP("x-reverse", "", false, `reverse X axis`)
+	plotCmd.PersistentFlags().BoolP("y-reverse", "", false, `reverse Y axis`)
+	plotCmd.PersistentFlags().StringP("x-trans", "", "", `transform X values: log10, -log10, log2, ln, or sqrt`)
+	plotCmd.PersistentFlags().StringP("y-trans", "", "", `transform Y values: log10, -log10, log2, ln, or sqrt`)
+
 	plotCmd.PersistentFlags().Float64P("width", "", 6, "Figure width")
 	plotCmd.PersistentFlags().Float64P("height", "", 4.5, "Figure height")
 
@@ -73,8 +114,15 @@ func init() {
 	plotCmd.PersistentFlags().Float64P("tick-width", "", 1.5, "axis tick width")
 	plotCmd.PersistentFlags().IntP("tick-label-size", "", 12, "tick label font size")
 
+	plotCmd.PersistentFlags().IntP("dpi", "", 96, "resolution of images in png, jpg and tif formats")
+	plotCmd.PersistentFlags().StringP("theme", "", "", `a built-in theme (default, minimal, colorblind, talk), or a YAML/JSON theme file`)
+
 	plotCmd.PersistentFlags().StringP("format", "", "png", `image format for stdout when flag -o/--out-file not given. available values: eps, jpg|jpeg, pdf, png, svg, and tif|tiff.`)
 
+	plotCmd.PersistentFlags().BoolP("term", "", false, `draw the plot in the terminal with Unicode characters, instead of an image`)
+	plotCmd.PersistentFlags().IntP("term-width", "", 0, `width of the plot in characters for --term, 0 for the terminal width`)
+	plotCmd.PersistentFlags().IntP("term-height", "", 0, `height of the plot in lines for --term, 0 for the terminal height minus 1`)
+
 	plotCmd.PersistentFlags().StringSliceP("na-values", "", []string{"", "NA", "N/A"}, `NA values, case ignored`)
 	plotCmd.PersistentFlags().BoolP("skip-na", "", false, "skip NA values in --na-values")
 
@@ -83,6 +131,16 @@ func init() {
 func getPlotConfigs(cmd *cobra.Command) *plotConfigs {
 	config := new(plotConfigs)
 
+	// the theme sets defaults of flags, so it's read first
+	if name := getFlagString(cmd, "theme"); name != "" {
+		theme, err := readPlotTheme(name)
+		checkError(err)
+		checkError(theme.setFlagDefaults(cmd))
+		checkError(theme.apply(config))
+	}
+	config.dpi = getFlagPositiveInt(cmd, "dpi")
+	config.global = getConfigs(cmd)
+
 	config.dataFieldStr = getFlagString(cmd, "data-field")
 	if strings.Contains(config.dataFieldStr, ",") {
 		checkError(fmt.Errorf("only one field allowed for flag --data-field"))
@@ -146,6 +204,24 @@ func getPlotConfigs(cmd *cobra.Command) *plotConfigs {
 		}
 	}
 
+	config.xaxis = &plotAxisConfig{
+		name:    "X",
+		log:     getFlagBool(cmd, "x-log"),
+		reverse: getFlagBool(cmd, "x-reverse"),
+		trans:   strings.ToLower(getFlagString(cmd, "x-trans")),
+	}
+	config.yaxis = &plotAxisConfig{
+		name:    "Y",
+		log:     getFlagBool(cmd, "y-log"),
+		reverse: getFlagBool(cmd, "y-reverse"),
+		trans:   strings.ToLower(getFlagString(cmd, "y-trans")),
+	}
+	for _, a := range []*plotAxisConfig{config.xaxis, config.yaxis} {
+		if _, ok := plotTransforms[a.trans]; !ok {
+			checkError(fmt.Errorf("invalid value of flag --%s-trans: %s. available: log10, -log10, log2, ln, sqrt", strings.ToLower(a.name), a.trans))
+		}
+	}
+
 	config.format = getFlagString(cmd, "format")
 	switch strings.ToLower(config.format) {
 	case "eps", "jpg", "jpeg", "pdf", "png", "svg", "tif", "tiff":
@@ -153,9 +229,43 @@ func getPlotConfigs(cmd *cobra.Command) *plotConfigs {
 		checkError(fmt.Errorf("invalid image format. available format: eps, jpg|jpeg, pdf, png, svg, and tif|tiff"))
 	}
 
+	config.term = getFlagBool(cmd, "term")
+	config.termWidth = getFlagNonNegativeInt(cmd, "term-width")
+	config.termHeight = getFlagNonNegativeInt(cmd, "term-height")
+	if config.term {
+		// the size of the canvas is used in computing sizes of elements, e.g., bar widths
+		if config.termWidth == 0 || config.termHeight == 0 {
+			w, h := termSize()
+			if config.termWidth == 0 {
+				config.termWidth = w
+			}
+			if config.termHeight == 0 {
+				config.termHeight = h - 1 // leave a line for the prompt
+			}
+		}
+		if config.termWidth < 20 || config.termHeight < 5 {
+			checkError(fmt.Errorf("terminal too small for plotting: %dx%d", config.termWidth, config.termHeight))
+		}
+		config.width = vg.Length(config.termWidth) * termCellWidth / vg.Inch
+		config.height = vg.Length(config.termHeight) * termCellHeight / vg.Inch
+		config.titleSize = termFontSize
+		config.labelSize = termFontSize
+		config.tickLabelSize = termFontSize
+	}
+
 	return config
 }
 
+// checkPlotField checks the value of a flag of a single field.
+func checkPlotField(flag string, value string) {
+	if strings.Contains(value, ",") {
+		checkError(fmt.Errorf("only one field allowed for flag --%s", flag))
+	}
+	if value[0] == '-' {
+		checkError(fmt.Errorf("unselect not allowed for flag --%s", flag))
+	}
+}
+
 type plotConfigs struct {
 	dataFieldStr, groupFieldStr, fieldStr string
 	title, xlab, ylab                     string
@@ -164,4 +274,293 @@ type plotConfigs struct {
 	xmin, xmax, ymin, ymax                float64
 	xminStr, xmaxStr, yminStr, ymaxStr    string
 	format                                string
+	dpi                                   int
+	palette                               []color.Color
+	background, gridColor                 color.Color
+	term                                  bool
+	termWidth, termHeight                 int
+	xaxis, yaxis                          *plotAxisConfig
+
+	global Config // global flags, for writing output files
+}
+
+// applyStyle sets the title, axis labels, font sizes and line widths of a plot.
+func (config *plotConfigs) applyStyle(p *plot.Plot) {
+	p.Title.Text = config.title
+	p.Title.TextStyle.Font.Size = config.titleSize
+	p.X.Label.Text = config.xlab
+	p.Y.Label.Text = config.ylab
+	p.X.Label.TextStyle.Font.Size = config.labelSize
+	p.Y.Label.TextStyle.Font.Size = config.labelSize
+	p.X.Width = config.axisWidth
+	p.Y.Width = config.axisWidth
+	p.X.Tick.Width = config.tickWidth
+	p.Y.Tick.Width = config.tickWidth
+	p.X.Tick.Label.Font.Size = config.tickLabelSize
+	p.Y.Tick.Label.Font.Size = config.tickLabelSize
+	if config.background != nil {
+		p.BackgroundColor = config.background
+	}
+}
+
+// applyLimits sets axis ranges given by --x-min, --x-max, --y-min and --y-max.
+func (config *plotConfigs) applyLimits(p *plot.Plot) {
+	if config.xminStr != "" {
+		p.X.Min = config.xmin
+	}
+	if config.xmaxStr != "" {
+		p.X.Max = config.xmax
+	}
+	if config.yminStr != "" {
+		p.Y.Min = config.ymin
+	}
+	if config.ymaxStr != "" {
+		p.Y.Max = config.ymax
+	}
+}
+
+// applyScales sets log scales and reversed axes,
+// it should be called after all plotters are added and axis ranges are set.
+func (config *plotConfigs) applyScales(p *plot.Plot) {
+	config.xaxis.apply(&p.X)
+	config.yaxis.apply(&p.Y)
+}
+
+// ignoreScales warns about axis flags not supported by a command.
+func (config *plotConfigs) ignoreScales(command string, axes ...*plotAxisConfig) {
+	for _, a := range axes {
+		name := strings.ToLower(a.name)
+		if a.log {
+			log.Warningf("flag --%s-log ignored for command %s", name, command)
+		}
+		if a.reverse {
+			log.Warningf("flag --%s-reverse ignored for command %s", name, command)
+		}
+		if a.trans != "" {
+			log.Warningf("flag --%s-trans ignored for command %s", name, command)
+		}
+	}
+}
+
+var plotTransforms = map[string]func(float64) float64{
+	"":       nil,
+	"log10":  math.Log10,
+	"-log10": func(x float64) float64 { return -math.Log10(x) },
+	"log2":   math.Log2,
+	"ln":     math.Log,
+	"sqrt":   math.Sqrt,
+}
+
+// plotAxisConfig contains the transform and scale of an axis.
+type plotAxisConfig struct {
+	name    string // X or Y
+	log     bool
+	reverse bool
+	trans   string
+
+	removed int // number of values not plottable
+}
+
+// Value transforms a value, and tells whether it can be plotted,
+// i.e., it's finite, and positive for a log axis.
+func (a *plotAxisConfig) Value(v float64) (float64, bool) {
+	if f := plotTransforms[a.trans]; f != nil {
+		v = f(v)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) || (a.log && v <= 0) {
+		a.removed++
+		return v, false
+	}
+	return v, true
+}
+
+// Bound transforms a value like Value(), e.g., a bound of an error bar,
+// but values not plottable are returned as NaN and not counted.
+func (a *plotAxisConfig) Bound(v float64) float64 {
+	if f := plotTransforms[a.trans]; f != nil {
+		v = f(v)
+	}
+	if math.IsInf(v, 0) || (a.log && v <= 0) {
+		return math.NaN()
+	}
+	return v
+}
+
+// Label returns the label of a column after transformation, e.g., -log10(pvalue).
+func (a *plotAxisConfig) Label(label string) string {
+	if a.trans == "" || label == "" {
+		return label
+	}
+	return a.trans + "(" + label + ")"
+}
+
+// WarnRemoved reports the number of values removed by Value().
+func (a *plotAxisConfig) WarnRemoved() {
+	if a.removed == 0 {
+		return
+	}
+	if a.log {
+		log.Warningf("%d non-finite or non-positive values removed for log scale of %s axis", a.removed, a.name)
+	} else {
+		log.Warningf("%d non-finite values removed after transformation of %s axis", a.removed, a.name)
+	}
+}
+
+func (a *plotAxisConfig) apply(axis *plot.Axis) {
+	if a.log {
+		if axis.Max <= 0 {
+			axis.Max = 1
+		}
+		if axis.Min <= 0 {
+			axis.Min = axis.Max / 10
+		}
+		axis.Scale = plotLogScale{}
+		axis.Tick.Marker = plotLogTicks{}
+	}
+	if a.reverse {
+		axis.Scale = plot.InvertedScale{Normalizer: axis.Scale}
+	}
+}
+
+// plotLogScale is a log scale which places non-positive values at the minimum,
+// e.g., bottoms of histogram bars, while plot.LogScale panics.
+type plotLogScale struct{}
+
+func (plotLogScale) Normalize(min, max, x float64) float64 {
+	if x <= 0 {
+		x = math.Min(min, max)
+	}
+	logMin := math.Log(min)
+	return (math.Log(x) - logMin) / (math.Log(max) - logMin)
+}
+
+// plotLogTicks places major ticks at powers of 10, and falls back to
+// default ticks if the range is less than a decade.
+type plotLogTicks struct{}
+
+func (plotLogTicks) Ticks(min, max float64) []plot.Tick {
+	lo := int(math.Floor(math.Log10(min)))
+	hi := int(math.Ceil(math.Log10(max)))
+	step := 1 // of labelled decades
+	for (hi-lo)/step > 8 {
+		step++
+	}
+
+	ticks := make([]plot.Tick, 0, (hi-lo+1)*9)
+	var n int // labelled ticks
+	var v float64
+	for e := lo; e <= hi; e++ {
+		for i := 1; i < 10; i++ {
+			v = float64(i) * math.Pow10(e)
+			if v < min || v > max {
+				continue
+			}
+			if i == 1 && e%step == 0 {
+				ticks = append(ticks, plot.Tick{Value: v, Label: logTickLabel(e)})
+				n++
+			} else if step == 1 || (i == 1 && hi-lo <= 30) {
+				ticks = append(ticks, plot.Tick{Value: v})
+			}
+		}
+	}
+	if n >= 2 {
+		return ticks
+	}
+
+	ticks = plot.DefaultTicks{}.Ticks(min, max)
+	filtered := ticks[:0]
+	for _, t := range ticks {
+		if t.Value > 0 {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// logTickLabel returns the label of 10^e.
+func logTickLabel(e int) string {
+	if e >= -4 && e <= 5 {
+		return strconv.FormatFloat(math.Pow10(e), 'f', -1, 64)
+	}
+	return fmt.Sprintf("1e%d", e)
+}
+
+// plotValueGroups contains numeric columns of groups.
+type plotValueGroups struct {
+	header []string      // names of value columns, empty for no header row
+	names  []string      // group names, in order of appearance
+	values [][][]float64 // group -> column -> values
+}
+
+// readPlotValueGroups reads numeric values of columns in fieldStr, grouped by
+// the group field (-g). Values are transformed with corresponding axes (nil for none),
+// and values not plottable or in --na-values (with --skip-na) are skipped.
+func readPlotValueGroups(cmd *cobra.Command, config Config, plotConfig *plotConfigs, file string,
+	fieldStr string, axes []*plotAxisConfig) *plotValueGroups {
+	skipNA := getFlagBool(cmd, "skip-na")
+	naValues := getFlagStringSlice(cmd, "na-values")
+	if skipNA && len(naValues) == 0 {
+		log.Errorf("the value of --na-values should not be empty when using --skip-na")
+	}
+	naMap := make(map[string]interface{}, len(naValues))
+	for _, na := range naValues {
+		naMap[strings.ToLower(na)] = struct{}{}
+	}
+
+	ncols := len(axes)
+	hasGroup := plotConfig.groupFieldStr != ""
+	if hasGroup {
+		fieldStr += "," + plotConfig.groupFieldStr
+	}
+	headerRow, fields, data, _, _, err := parseCSVfile(cmd, config, file, fieldStr, false, false)
+	checkError(err)
+
+	g := &plotValueGroups{}
+	if len(headerRow) >= ncols {
+		g.header = headerRow[:ncols]
+	}
+	index := make(map[string]int)
+	var name string
+	var i int
+	var ok bool
+	var f float64
+	for _, d := range data {
+		if hasGroup {
+			name = d[len(d)-1]
+		}
+		if i, ok = index[name]; !ok {
+			i = len(g.names)
+			index[name] = i
+			g.names = append(g.names, name)
+			g.values = append(g.values, make([][]float64, ncols))
+		}
+
+		for j := 0; j < ncols; j++ {
+			if skipNA {
+				if _, ok = naMap[strings.ToLower(d[j])]; ok {
+					continue
+				}
+			}
+			f, err = strconv.ParseFloat(d[j], 64)
+			if err != nil {
+				if len(headerRow) > 0 {
+					checkError(fmt.Errorf("fail to parse data: %s at column: %s", d[j], headerRow[j]))
+				} else {
+					checkError(fmt.Errorf("fail to parse data: %s at column: %d", d[j], fields[j]))
+				}
+			}
+			if axes[j] != nil {
+				if f, ok = axes[j].Value(f); !ok {
+					continue
+				}
+			}
+			g.values[i][j] = append(g.values[i][j], f)
+		}
+	}
+	for _, a := range axes {
+		if a != nil {
+			a.WarnRemoved()
+		}
+	}
+	return g
 }
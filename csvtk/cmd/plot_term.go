@@ -0,0 +1,584 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Layouts of plots are computed by gonum/plot with all fonts in termFontSize,
+// and a character cell is as wide as a digit and as high as a line of text.
+// So tick labels and other texts fit in cells.
+const (
+	termFontSize   = vg.Length(10)
+	termCellWidth  = termFontSize * 0.556
+	termCellHeight = termCellWidth * 2
+)
+
+// termCanvas is a vg.Canvas drawing on a grid of characters.
+// Lines, areas and glyphs are drawn with braille dots (2x4 dots per cell),
+// a cell with all dots set is drawn as a full block, and texts are written
+// in cells directly. Each cell has one color, i.e., the last one drawn.
+type termCanvas struct {
+	cols, rows int
+
+	dots   [][]uint8 // bits of braille dots
+	texts  [][]rune  // 0 for no text, -1 for the right half of a wide character
+	colors [][]int   // 256-color codes, -1 for the default color
+
+	termCanvasState
+	stack []termCanvasState
+}
+
+type termCanvasState struct {
+	color      int
+	invisible  bool
+	lineWidth  vg.Length
+	dashes     []vg.Length
+	dashOffset vg.Length
+	m          [6]float64 // affine transformation: x' = m0*x + m2*y + m4, y' = m1*x + m3*y + m5
+}
+
+func newTermCanvas(cols, rows int) *termCanvas {
+	c := &termCanvas{cols: cols, rows: rows}
+	c.dots = make([][]uint8, rows)
+	c.texts = make([][]rune, rows)
+	c.colors = make([][]int, rows)
+	for r := 0; r < rows; r++ {
+		c.dots[r] = make([]uint8, cols)
+		c.texts[r] = make([]rune, cols)
+		c.colors[r] = make([]int, cols)
+		for i := range c.colors[r] {
+			c.colors[r][i] = -1
+		}
+	}
+	c.color = -1
+	c.lineWidth = 1
+	c.m = [6]float64{1, 0, 0, 1, 0, 0}
+	return c
+}
+
+// Size returns the size of the canvas in points.
+func (c *termCanvas) Size() (w, h vg.Length) {
+	return vg.Length(c.cols) * termCellWidth, vg.Length(c.rows) * termCellHeight
+}
+
+func (c *termCanvas) SetLineWidth(w vg.Length) {
+	c.lineWidth = w
+}
+
+func (c *termCanvas) SetLineDash(pattern []vg.Length, offset vg.Length) {
+	c.dashes = pattern
+	c.dashOffset = offset
+}
+
+func (c *termCanvas) SetColor(clr color.Color) {
+	if clr == nil {
+		clr = color.Black
+	}
+	c.color, c.invisible = termColor(clr)
+}
+
+func (c *termCanvas) Rotate(rad float64) {
+	sin, cos := math.Sincos(rad)
+	m := c.m
+	c.m[0], c.m[1] = m[0]*cos+m[2]*sin, m[1]*cos+m[3]*sin
+	c.m[2], c.m[3] = m[2]*cos-m[0]*sin, m[3]*cos-m[1]*sin
+}
+
+func (c *termCanvas) Translate(pt vg.Point) {
+	x, y := float64(pt.X), float64(pt.Y)
+	c.m[4] += c.m[0]*x + c.m[2]*y
+	c.m[5] += c.m[1]*x + c.m[3]*y
+}
+
+func (c *termCanvas) Scale(x, y float64) {
+	c.m[0] *= x
+	c.m[1] *= x
+	c.m[2] *= y
+	c.m[3] *= y
+}
+
+func (c *termCanvas) Push() {
+	c.stack = append(c.stack, c.termCanvasState)
+}
+
+func (c *termCanvas) Pop() {
+	if len(c.stack) == 0 {
+		return
+	}
+	c.termCanvasState = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// transform returns the position of a point in dots,
+// with the origin at the top left corner.
+func (c *termCanvas) transform(pt vg.Point) (float64, float64) {
+	x := c.m[0]*float64(pt.X) + c.m[2]*float64(pt.Y) + c.m[4]
+	y := c.m[1]*float64(pt.X) + c.m[3]*float64(pt.Y) + c.m[5]
+	return x / float64(termCellWidth/2), float64(c.rows*4) - y/float64(termCellHeight/4)
+}
+
+// setDot sets the dot at the given column and row of dots.
+func (c *termCanvas) setDot(x, y int) {
+	if x < 0 || y < 0 || x >= c.cols*2 || y >= c.rows*4 {
+		return
+	}
+	col, row := x/2, y/4
+	c.dots[row][col] |= termBrailleBits[y%4][x%2]
+	if c.texts[row][col] == 0 {
+		c.colors[row][col] = c.color
+	}
+}
+
+// bits of braille dots, https://en.wikipedia.org/wiki/Braille_Patterns
+var termBrailleBits = [4][2]uint8{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// flatten converts a path to polylines in dots.
+func (c *termCanvas) flatten(p vg.Path) [][][2]float64 {
+	var lines [][][2]float64
+	var line [][2]float64
+	var cur, start vg.Point
+	add := func(pt vg.Point) {
+		x, y := c.transform(pt)
+		line = append(line, [2]float64{x, y})
+		cur = pt
+	}
+	for _, comp := range p {
+		switch comp.Type {
+		case vg.MoveComp:
+			if len(line) > 0 {
+				lines = append(lines, line)
+			}
+			line = nil
+			add(comp.Pos)
+			start = comp.Pos
+		case vg.LineComp:
+			add(comp.Pos)
+		case vg.ArcComp:
+			n := int(math.Ceil(math.Abs(comp.Angle) * float64(comp.Radius) / 0.5))
+			if n < 8 {
+				n = 8
+			}
+			for i := 0; i <= n; i++ {
+				sin, cos := math.Sincos(comp.Start + comp.Angle*float64(i)/float64(n))
+				pt := vg.Point{
+					X: comp.Pos.X + comp.Radius*vg.Length(cos),
+					Y: comp.Pos.Y + comp.Radius*vg.Length(sin),
+				}
+				if len(line) == 0 {
+					start = pt
+				}
+				add(pt)
+			}
+		case vg.CurveComp:
+			p0 := cur
+			n := 16
+			for i := 1; i <= n; i++ {
+				t := vg.Length(float64(i) / float64(n))
+				var pt vg.Point
+				switch len(comp.Control) {
+				case 1:
+					p1 := comp.Control[0]
+					pt = p0.Scale((1 - t) * (1 - t)).Add(p1.Scale(2 * (1 - t) * t)).Add(comp.Pos.Scale(t * t))
+				case 2:
+					p1, p2 := comp.Control[0], comp.Control[1]
+					pt = p0.Scale((1 - t) * (1 - t) * (1 - t)).Add(p1.Scale(3 * (1 - t) * (1 - t) * t)).
+						Add(p2.Scale(3 * (1 - t) * t * t)).Add(comp.Pos.Scale(t * t * t))
+				default:
+					pt = comp.Pos
+				}
+				add(pt)
+			}
+		case vg.CloseComp:
+			add(start)
+			lines = append(lines, line)
+			line = nil
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (c *termCanvas) Stroke(p vg.Path) {
+	if c.invisible || c.lineWidth <= 0 {
+		return
+	}
+	var d float64 // distance from the start of a line, for dashes
+	for _, line := range c.flatten(p) {
+		d = float64(c.dashOffset / (termCellWidth / 2))
+		if len(line) == 1 {
+			c.setDot(int(math.Floor(line[0][0])), int(math.Floor(line[0][1])))
+			continue
+		}
+		for i := 1; i < len(line); i++ {
+			x0, y0 := line[i-1][0], line[i-1][1]
+			dx, dy := line[i][0]-x0, line[i][1]-y0
+			l := math.Hypot(dx, dy)
+			if math.IsNaN(l) || math.IsInf(l, 0) {
+				continue
+			}
+			n := int(math.Ceil(l / 0.5))
+			for j := 0; j <= n; j++ {
+				t := 0.0
+				if n > 0 {
+					t = float64(j) / float64(n)
+				}
+				if c.dashOn(d + t*l) {
+					c.setDot(int(math.Floor(x0+t*dx)), int(math.Floor(y0+t*dy)))
+				}
+			}
+			d += l
+		}
+	}
+}
+
+// dashOn tells whether the position (in dots) of a line is in a dash.
+func (c *termCanvas) dashOn(d float64) bool {
+	if len(c.dashes) == 0 {
+		return true
+	}
+	var total float64
+	for _, l := range c.dashes {
+		total += float64(l / (termCellWidth / 2))
+	}
+	if total <= 0 {
+		return true
+	}
+	d = math.Mod(d, total)
+	for i, l := range c.dashes {
+		d -= float64(l / (termCellWidth / 2))
+		if d < 0 {
+			return i%2 == 0
+		}
+	}
+	return true
+}
+
+// Fill fills dots with centers inside the path, by the even-odd rule.
+// For paths smaller than a dot, e.g., glyphs, the dot at the center is set.
+func (c *termCanvas) Fill(p vg.Path) {
+	if c.invisible {
+		return
+	}
+	polygons := c.flatten(p)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, polygon := range polygons {
+		for _, pt := range polygon {
+			minX, maxX = math.Min(minX, pt[0]), math.Max(maxX, pt[0])
+			minY, maxY = math.Min(minY, pt[1]), math.Max(maxY, pt[1])
+		}
+	}
+	if math.IsInf(minX, 0) || math.IsInf(maxX, 0) || math.IsInf(minY, 0) || math.IsInf(maxY, 0) {
+		return
+	}
+
+	var n int
+	xs := make([]float64, 0, 8)
+	y0 := int(math.Max(math.Floor(minY), 0))
+	y1 := int(math.Min(math.Ceil(maxY), float64(c.rows*4)))
+	for y := y0; y < y1; y++ {
+		yc := float64(y) + 0.5
+		xs = xs[:0]
+		for _, polygon := range polygons {
+			for i := range polygon {
+				a, b := polygon[i], polygon[(i+1)%len(polygon)]
+				if (a[1] <= yc) != (b[1] <= yc) {
+					xs = append(xs, a[0]+(yc-a[1])/(b[1]-a[1])*(b[0]-a[0]))
+				}
+			}
+		}
+		sort.Float64s(xs)
+		for k := 0; k+1 < len(xs); k += 2 {
+			x1 := math.Min(xs[k+1], float64(c.cols*2))
+			for x := int(math.Max(math.Ceil(xs[k]-0.5), 0)); float64(x)+0.5 < x1; x++ {
+				c.setDot(x, y)
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		c.setDot(int(math.Floor((minX+maxX)/2)), int(math.Floor((minY+maxY)/2)))
+	}
+}
+
+// FillString writes a text in cells, centered at the center of the text.
+// Vertical texts, e.g., Y axis labels, are written from top to bottom.
+func (c *termCanvas) FillString(f font.Face, pt vg.Point, text string) {
+	if f.Font.Size == 0 || c.invisible {
+		return
+	}
+	ext := f.Extents()
+	x, y := c.transform(vg.Point{
+		X: pt.X + f.Width(text)/2,
+		Y: pt.Y + (ext.Ascent-ext.Descent)/2,
+	})
+	col, row := x/2, y/4
+
+	runes := []rune(text)
+	if math.Abs(c.m[1]) > math.Abs(c.m[0]) {
+		col0 := int(math.Floor(col))
+		row0 := int(math.Round(row - float64(len(runes))/2))
+		for i, r := range runes {
+			c.setText(col0, row0+i, r)
+		}
+		return
+	}
+	row0 := int(math.Floor(row))
+	col0 := int(math.Round(col - float64(runewidth.StringWidth(text))/2))
+	for _, r := range runes {
+		col0 += c.setText(col0, row0, r)
+	}
+}
+
+// setText writes a character in a cell, and returns its width.
+func (c *termCanvas) setText(col, row int, r rune) int {
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		return 0
+	}
+	if col < 0 || row < 0 || col+w > c.cols || row >= c.rows {
+		return w
+	}
+	c.texts[row][col] = r
+	c.colors[row][col] = c.color
+	if w == 2 {
+		c.texts[row][col+1] = -1
+	}
+	return w
+}
+
+// DrawImage draws an image with dots, colors of cells are sampled from the image.
+func (c *termCanvas) DrawImage(rect vg.Rectangle, img image.Image) {
+	x0, y0 := c.transform(rect.Min)
+	x1, y1 := c.transform(rect.Max)
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	if x1-x0 <= 0 || y1-y0 <= 0 {
+		return
+	}
+	b := img.Bounds()
+	state := c.termCanvasState
+	for y := int(math.Max(math.Floor(y0), 0)); float64(y) < math.Min(y1, float64(c.rows*4)); y++ {
+		py := b.Min.Y + int((float64(y)+0.5-y0)/(y1-y0)*float64(b.Dy()))
+		for x := int(math.Max(math.Floor(x0), 0)); float64(x) < math.Min(x1, float64(c.cols*2)); x++ {
+			px := b.Min.X + int((float64(x)+0.5-x0)/(x1-x0)*float64(b.Dx()))
+			c.SetColor(img.At(px, py))
+			if !c.invisible {
+				c.setDot(x, y)
+			}
+		}
+	}
+	c.termCanvasState = state
+}
+
+// write writes the canvas as lines of text, with ANSI 256 colors or not.
+// Trailing spaces are removed.
+func (c *termCanvas) write(w io.Writer, colored bool) error {
+	var buf strings.Builder
+	var ch rune
+	var cur, last int
+	for row := 0; row < c.rows; row++ {
+		buf.Reset()
+		last = -1
+		for col := 0; col < c.cols; col++ {
+			if c.texts[row][col] != 0 || c.dots[row][col] != 0 {
+				last = col
+			}
+		}
+		cur = -1
+		for col := 0; col <= last; col++ {
+			ch = c.texts[row][col]
+			switch {
+			case ch == -1:
+				continue
+			case ch != 0:
+			case c.dots[row][col] == 0xff:
+				ch = '█'
+			case c.dots[row][col] != 0:
+				ch = rune(0x2800 + int(c.dots[row][col]))
+			default:
+				buf.WriteByte(' ')
+				continue
+			}
+			if colored && c.colors[row][col] != cur {
+				cur = c.colors[row][col]
+				if cur < 0 {
+					buf.WriteString("\x1b[0m")
+				} else {
+					buf.WriteString("\x1b[38;5;" + strconv.Itoa(cur) + "m")
+				}
+			}
+			buf.WriteRune(ch)
+		}
+		if cur >= 0 {
+			buf.WriteString("\x1b[0m")
+		}
+		buf.WriteByte('\n')
+		if _, err := io.WriteString(w, buf.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// termColor returns the closest code of ANSI 256 colors, and whether the color is invisible.
+// Dark colors, e.g., black texts and axes, are in the default color of terminals (-1).
+func termColor(clr color.Color) (int, bool) {
+	c := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	if c.A == 0 {
+		return -1, true
+	}
+	r, g, b := int(c.R), int(c.G), int(c.B)
+	if r < 64 && g < 64 && b < 64 {
+		return -1, false
+	}
+
+	// 6x6x6 color cube
+	levels := [6]int{0, 95, 135, 175, 215, 255}
+	level := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	lr, lg, lb := level(r), level(g), level(b)
+	code := 16 + 36*lr + 6*lg + lb
+	dist := termColorDistance(r, g, b, levels[lr], levels[lg], levels[lb])
+
+	// grayscale ramp
+	avg := (r + g + b) / 3
+	gi := (avg - 3) / 10
+	if gi < 0 {
+		gi = 0
+	} else if gi > 23 {
+		gi = 23
+	}
+	gv := 8 + 10*gi
+	if d := termColorDistance(r, g, b, gv, gv, gv); d < dist {
+		code = 232 + gi
+	}
+	return code, false
+}
+
+func termColorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+// termSize returns the size of the terminal, or 80x24 if not available.
+func termSize() (cols, rows int) {
+	for _, f := range []*os.File{os.Stdout, os.Stderr, os.Stdin} {
+		if w, h, err := term.GetSize(int(f.Fd())); err == nil && w > 0 && h > 0 {
+			return w, h
+		}
+	}
+	cols, rows = 80, 24
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		cols = w
+	}
+	if h, err := strconv.Atoi(os.Getenv("LINES")); err == nil && h > 0 {
+		rows = h
+	}
+	return cols, rows
+}
+
+// termStyle sets all fonts of a plot in termFontSize, and removes the background.
+// Tick marks are lengthened to keep tick labels away from axis lines.
+func termStyle(p *plot.Plot) {
+	p.BackgroundColor = color.Transparent
+	if p.X.Tick.Length < termCellHeight/2 {
+		p.X.Tick.Length = termCellHeight / 2
+	}
+	if p.Y.Tick.Length < termCellWidth/2 {
+		p.Y.Tick.Length = termCellWidth / 2
+	}
+	p.Title.TextStyle.Font.Size = termFontSize
+	p.X.Label.TextStyle.Font.Size = termFontSize
+	p.Y.Label.TextStyle.Font.Size = termFontSize
+	p.X.Tick.Label.Font.Size = termFontSize
+	p.Y.Tick.Label.Font.Size = termFontSize
+	p.Legend.TextStyle.Font.Size = termFontSize
+}
+
+// saveTerm draws plots with fn on a canvas of characters, and writes the text to
+// stdout, with ANSI colors if stdout is a terminal, or to a file.
+func (config *plotConfigs) saveTerm(file string, fn func(dc draw.Canvas), plots ...*plot.Plot) (err error) {
+	for _, p := range plots {
+		if p != nil {
+			termStyle(p)
+		}
+	}
+
+	c := newTermCanvas(config.termWidth, config.termHeight)
+	fn(draw.New(c))
+
+	if isStdin(file) && compressFormatOfFile(config.global, file) == "none" {
+		colored := term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+		w := bufio.NewWriter(colorable.NewColorableStdout())
+		if err = c.write(w, colored); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	outfh, err := wopenByConfig(config.global, file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := outfh.Close()
+		if err == nil {
+			err = e
+		}
+	}()
+	return c.write(outfh, false)
+}
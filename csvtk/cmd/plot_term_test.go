@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shenwei356/xopen"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+func TestTermColor(t *testing.T) {
+	tests := []struct {
+		c         color.Color
+		code      int
+		invisible bool
+	}{
+		{color.Black, -1, false},
+		{color.Transparent, -1, true},
+		{color.White, 231, false},
+		{color.RGBA{R: 255, A: 255}, 196, false},
+		{color.RGBA{R: 128, G: 128, B: 128, A: 255}, 244, false},
+	}
+	for _, test := range tests {
+		code, invisible := termColor(test.c)
+		if code != test.code || invisible != test.invisible {
+			t.Errorf("color: %v, expected (%d, %v), returned (%d, %v)",
+				test.c, test.code, test.invisible, code, invisible)
+		}
+	}
+}
+
+func TestTermCanvas(t *testing.T) {
+	c := newTermCanvas(4, 2)
+	w, h := c.Size()
+
+	// a horizontal line in the middle, and a filled cell at the bottom left
+	var line vg.Path
+	line.Move(vg.Point{X: 0, Y: h/2 + 1})
+	line.Line(vg.Point{X: w, Y: h/2 + 1})
+	c.Stroke(line)
+
+	var rect vg.Path
+	rect.Move(vg.Point{X: 0, Y: 0})
+	rect.Line(vg.Point{X: termCellWidth, Y: 0})
+	rect.Line(vg.Point{X: termCellWidth, Y: termCellHeight})
+	rect.Line(vg.Point{X: 0, Y: termCellHeight})
+	rect.Close()
+	c.Fill(rect)
+
+	var buf strings.Builder
+	if err := c.write(&buf, false); err != nil {
+		t.Error(err)
+	}
+	expected := "⣀⣀⣀⣀\n█\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, returned %q", expected, buf.String())
+	}
+}
+
+func TestSaveTermCompressed(t *testing.T) {
+	config := &plotConfigs{termWidth: 4, termHeight: 2,
+		global: Config{OutCompress: "zstd", CompressLevel: -1}}
+	file := filepath.Join(t.TempDir(), "plot.txt")
+	fill := func(dc draw.Canvas) { dc.Fill(dc.Rectangle.Path()) }
+	if err := config.saveTerm(file, fill); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(raw, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		t.Errorf("output should be compressed with zstd")
+	}
+
+	fh, err := xopen.Ropen(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	data, err := io.ReadAll(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "████\n████\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, returned %q", expected, data)
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestPlotLogTicks(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		labels   []string
+	}{
+		{0.003, 250, []string{"0.01", "0.1", "1", "10", "100"}},
+		{1e-12, 1, []string{"1e-12", "1e-10", "1e-8", "1e-6", "0.0001", "0.01", "1"}},
+	}
+	for _, test := range tests {
+		var labels []string
+		for _, tick := range (plotLogTicks{}).Ticks(test.min, test.max) {
+			if tick.Value < test.min || tick.Value > test.max {
+				t.Errorf("[%g, %g]: tick out of range: %g", test.min, test.max, tick.Value)
+			}
+			if tick.Label != "" {
+				labels = append(labels, tick.Label)
+			}
+		}
+		if len(labels) != len(test.labels) {
+			t.Errorf("[%g, %g]: expected %v, returned %v", test.min, test.max, test.labels, labels)
+			continue
+		}
+		for i, l := range labels {
+			if l != test.labels[i] {
+				t.Errorf("[%g, %g]: expected %v, returned %v", test.min, test.max, test.labels, labels)
+				break
+			}
+		}
+	}
+}
+
+func TestPlotAxisValue(t *testing.T) {
+	a := &plotAxisConfig{name: "Y", log: true, trans: "-log10"}
+	if v, ok := a.Value(0.001); !ok || v != 3 {
+		t.Errorf("expected 3, returned %g", v)
+	}
+	for _, x := range []float64{1, 10, 0} { // 0, -1, +Inf
+		if _, ok := a.Value(x); ok {
+			t.Errorf("value %g should be removed", x)
+		}
+	}
+	if a.removed != 3 {
+		t.Errorf("expected 3 removed values, returned %d", a.removed)
+	}
+	if l := a.Label("pvalue"); l != "-log10(pvalue)" {
+		t.Errorf("unexpected label: %s", l)
+	}
+}
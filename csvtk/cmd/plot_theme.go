@@ -0,0 +1,247 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"gopkg.in/yaml.v3"
+)
+
+// plotTheme contains styles of plots, read from a YAML or JSON file.
+// Sizes, dimensions and the legend position are defaults of the
+// corresponding flags, which are still preferred if given.
+type plotTheme struct {
+	Font          string   `json:"font" yaml:"font"` // serif, sans, or mono
+	TitleSize     float64  `json:"titleSize" yaml:"titleSize"`
+	LabelSize     float64  `json:"labelSize" yaml:"labelSize"`
+	TickLabelSize float64  `json:"tickLabelSize" yaml:"tickLabelSize"`
+	AxisWidth     float64  `json:"axisWidth" yaml:"axisWidth"`
+	TickWidth     float64  `json:"tickWidth" yaml:"tickWidth"`
+	LineWidth     float64  `json:"lineWidth" yaml:"lineWidth"`
+	PointSize     float64  `json:"pointSize" yaml:"pointSize"`
+	Palette       []string `json:"palette" yaml:"palette"`
+	Background    string   `json:"background" yaml:"background"`
+	Grid          bool     `json:"grid" yaml:"grid"`
+	GridColor     string   `json:"gridColor" yaml:"gridColor"`
+	Width         float64  `json:"width" yaml:"width"`
+	Height        float64  `json:"height" yaml:"height"`
+	DPI           int      `json:"dpi" yaml:"dpi"`
+	Legend        string   `json:"legend" yaml:"legend"` // top-right, top-left, bottom-right, or bottom-left
+}
+
+var plotThemes = map[string]*plotTheme{
+	"default": {},
+	"minimal": {
+		Font:      "sans",
+		AxisWidth: 1,
+		TickWidth: 1,
+		Grid:      true,
+	},
+	"colorblind": { // Okabe and Ito (2008)
+		Palette: []string{"#E69F00", "#56B4E9", "#009E73", "#F0E442",
+			"#0072B2", "#D55E00", "#CC79A7", "#000000"},
+	},
+	"talk": {
+		Font:          "sans",
+		TitleSize:     22,
+		LabelSize:     18,
+		TickLabelSize: 16,
+		AxisWidth:     2,
+		TickWidth:     2,
+		LineWidth:     2.5,
+		PointSize:     4,
+		Width:         8,
+		Height:        6,
+	},
+}
+
+// plotThemeNames returns names of built-in themes.
+func plotThemeNames() []string {
+	names := make([]string, 0, len(plotThemes))
+	for name := range plotThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readPlotTheme returns a built-in theme, or reads a theme file.
+func readPlotTheme(name string) (*plotTheme, error) {
+	if theme, ok := plotThemes[strings.ToLower(name)]; ok {
+		return theme, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("theme not found: %s. built-in themes: %s, or give a YAML/JSON file",
+				name, strings.Join(plotThemeNames(), ", "))
+		}
+		return nil, fmt.Errorf("read theme file: %s", err)
+	}
+	theme := new(plotTheme)
+	if strings.HasSuffix(strings.ToLower(name), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(theme)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(theme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse theme file %s: %s", name, err)
+	}
+	return theme, nil
+}
+
+// setFlagDefaults sets values of flags not given in the command line,
+// flags not supported by the command are ignored.
+func (theme *plotTheme) setFlagDefaults(cmd *cobra.Command) error {
+	values := make(map[string]string, 12)
+	for flag, v := range map[string]float64{
+		"title-size":      theme.TitleSize,
+		"label-size":      theme.LabelSize,
+		"tick-label-size": theme.TickLabelSize,
+		"axis-width":      theme.AxisWidth,
+		"tick-width":      theme.TickWidth,
+		"line-width":      theme.LineWidth,
+		"point-size":      theme.PointSize,
+		"width":           theme.Width,
+		"height":          theme.Height,
+	} {
+		if v > 0 {
+			values[flag] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	if theme.DPI > 0 {
+		values["dpi"] = strconv.Itoa(theme.DPI)
+	}
+	switch theme.Legend {
+	case "":
+	case "top-right", "top-left", "bottom-right", "bottom-left":
+		values["legend-top"] = strconv.FormatBool(strings.HasPrefix(theme.Legend, "top"))
+		values["legend-left"] = strconv.FormatBool(strings.HasSuffix(theme.Legend, "left"))
+	default:
+		return fmt.Errorf("invalid legend position in theme: %s. available: top-right, top-left, bottom-right, bottom-left", theme.Legend)
+	}
+
+	for name, v := range values {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(v); err != nil {
+			return fmt.Errorf("invalid value of %s in theme: %s", name, v)
+		}
+	}
+	return nil
+}
+
+// apply sets the font, palette, background and grid lines of plots.
+func (theme *plotTheme) apply(config *plotConfigs) error {
+	if theme.Font != "" {
+		variant, ok := map[string]font.Variant{"serif": "Serif", "sans": "Sans", "mono": "Mono"}[strings.ToLower(theme.Font)]
+		if !ok {
+			return fmt.Errorf("invalid font in theme: %s. available: serif, sans, mono", theme.Font)
+		}
+		plot.DefaultFont = font.Font{Typeface: "Liberation", Variant: variant}
+		plotter.DefaultFont = plot.DefaultFont
+	}
+
+	var err error
+	config.palette = make([]color.Color, len(theme.Palette))
+	for i, s := range theme.Palette {
+		if config.palette[i], err = parsePlotColor(s); err != nil {
+			return err
+		}
+	}
+	if theme.Background != "" {
+		if config.background, err = parsePlotColor(theme.Background); err != nil {
+			return err
+		}
+	}
+	if theme.Grid {
+		config.gridColor = color.Gray{Y: 0xd9}
+		if theme.GridColor != "" {
+			if config.gridColor, err = parsePlotColor(theme.GridColor); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parsePlotColor parses a color in hex format, i.e., #RGB, #RRGGBB or #RRGGBBAA,
+// or "transparent".
+func parsePlotColor(s string) (color.Color, error) {
+	if strings.ToLower(s) == "transparent" {
+		return color.Transparent, nil
+	}
+	h := strings.TrimPrefix(s, "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if len(h) != 8 || err != nil {
+		return nil, fmt.Errorf("invalid color: %s, hex format like #1f77b4 expected", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// color returns the i-th color of the palette of the theme,
+// or of the default palette.
+func (config *plotConfigs) color(i int) color.Color {
+	if len(config.palette) > 0 {
+		return config.palette[i%len(config.palette)]
+	}
+	return plotutil.Color(i)
+}
+
+// newPlot returns a plot with grid lines if required by the theme.
+func (config *plotConfigs) newPlot() *plot.Plot {
+	p := plot.New()
+	if config.gridColor != nil && !config.term {
+		grid := plotter.NewGrid()
+		grid.Vertical.Color = config.gridColor
+		grid.Horizontal.Color = config.gridColor
+		grid.Vertical.Width = vg.Points(0.5)
+		grid.Horizontal.Width = vg.Points(0.5)
+		p.Add(grid)
+	}
+	return p
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParsePlotColor(t *testing.T) {
+	tests := []struct {
+		s     string
+		color color.Color
+		err   bool
+	}{
+		{"#1f77b4", color.NRGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}, false},
+		{"1F77B4", color.NRGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}, false},
+		{"#fff", color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, false},
+		{"#00000080", color.NRGBA{A: 0x80}, false},
+		{"transparent", color.Transparent, false},
+		{"#12345", nil, true},
+		{"red", nil, true},
+	}
+	for _, test := range tests {
+		c, err := parsePlotColor(test.s)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error: %v", test.s, err)
+			continue
+		}
+		if c != test.color {
+			t.Errorf("%s: expected %v, returned %v", test.s, test.color, c)
+		}
+	}
+}
+
+func TestPlotThemeFlagDefaults(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "theme.yaml")
+	data := "width: 8\ntitleSize: 20\nlegend: top-left\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err := readPlotTheme(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Float64("width", 6, "")
+	cmd.Flags().Int("title-size", 16, "")
+	cmd.Flags().Bool("legend-top", false, "")
+	cmd.Flags().Parse([]string{"--title-size", "12"})
+	if err = theme.setFlagDefaults(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cmd.Flags().GetFloat64("width"); v != 8 {
+		t.Errorf("width: expected 8 from the theme, returned %g", v)
+	}
+	if v, _ := cmd.Flags().GetInt("title-size"); v != 12 {
+		t.Errorf("title-size: expected 12 from the command line, returned %d", v)
+	}
+	if v, _ := cmd.Flags().GetBool("legend-top"); !v {
+		t.Errorf("legend-top: expected true from the theme")
+	}
+
+	if err = os.WriteFile(file, []byte("widht: 8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readPlotTheme(file); err == nil {
+		t.Errorf("expected an error for an unknown field")
+	}
+}
@@ -164,7 +164,7 @@ Styles:
 			checkError(fmt.Errorf("the value of flag -x/--wrap-delimiter should be a single character: %s", wrapDelimiter))
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -187,37 +187,13 @@ Styles:
 			ShowRowNumber: config.ShowRowNumber,
 		})
 
-		styles := map[string]*stable.TableStyle{
-			"default": &stable.TableStyle{
-				Name:            "default",
-				LineBelowHeader: stable.LineStyle{"", "-", separator, ""},
-
-				HeaderRow: stable.RowStyle{"", separator, ""},
-				DataRow:   stable.RowStyle{"", separator, ""},
-				Padding:   "",
-			},
-			"plain":  stable.StylePlain,
-			"simple": stable.StyleSimple,
-			"3line":  stable.StyleThreeLine,
-			"grid":   stable.StyleGrid,
-			"light":  stable.StyleLight,
-			"bold":   stable.StyleBold,
-			"double": stable.StyleDouble,
-		}
-
-		if style == "" {
-			style = "default"
-		}
-
 		tbl := stable.New()
 
 		tbl.WrapDelimiter(rune(wrapDelimiter[0]))
 
-		if _style, ok := styles[strings.ToLower(style)]; ok {
-			tbl.Style(_style)
-		} else {
-			checkError(fmt.Errorf("style not available: %s. available vaules: default, plain, simple, 3line, grid, light, bold, double", style))
-		}
+		_style, err := prettyTableStyle(style, separator)
+		checkError(err)
+		tbl.Style(_style)
 
 		if minWidth > 0 {
 			tbl.MinWidth(minWidth)
@@ -357,3 +333,32 @@ func init() {
 	prettyCmd.Flags().BoolP("clip", "", false, "clip longer cell instead of wrapping")
 	prettyCmd.Flags().StringP("clip-mark", "", "...", "clip mark")
 }
+
+// prettyTableStyle returns a preset table style, separator is only used for the default style.
+func prettyTableStyle(style string, separator string) (*stable.TableStyle, error) {
+	styles := map[string]*stable.TableStyle{
+		"default": &stable.TableStyle{
+			Name:            "default",
+			LineBelowHeader: stable.LineStyle{"", "-", separator, ""},
+
+			HeaderRow: stable.RowStyle{"", separator, ""},
+			DataRow:   stable.RowStyle{"", separator, ""},
+			Padding:   "",
+		},
+		"plain":  stable.StylePlain,
+		"simple": stable.StyleSimple,
+		"3line":  stable.StyleThreeLine,
+		"grid":   stable.StyleGrid,
+		"light":  stable.StyleLight,
+		"bold":   stable.StyleBold,
+		"double": stable.StyleDouble,
+	}
+
+	if style == "" {
+		style = "default"
+	}
+	if _style, ok := styles[strings.ToLower(style)]; ok {
+		return _style, nil
+	}
+	return nil, fmt.Errorf("style not available: %s. available vaules: default, plain, simple, 3line, grid, light, bold, double", style)
+}
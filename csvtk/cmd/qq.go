@@ -0,0 +1,260 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/gonum/stat/distuv"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// qqCmd represents the qq command
+var qqCmd = &cobra.Command{
+	Use:   "qq",
+	Short: "quantile-quantile plot",
+	Long: `quantile-quantile plot
+
+Sample quantiles of the data field (-f) are plotted against:
+  1. Theoretical quantiles of the standard normal distribution (default),
+     with probabilities (i-a)/(n+1-2a), a = 3/8 if n <= 10 else 0.5,
+     like qqnorm() in R. The reference line passes through the first
+     and third quartiles.
+  2. Quantiles of another column (-x/--data-field-x), like qqplot() in R.
+     The longer sample is linearly interpolated to the size of the
+     shorter one. The reference line is y = x.
+
+Notes:
+
+  1. Output file can be set by flag -o/--out-file.
+  2. File format is determined by the out file suffix.
+     Supported formats: eps, jpg|jpeg, pdf, png, svg, and tif|tiff
+  3. If flag -o/--out-file not set (default), image is written to stdout,
+     you can display the image by pipping to "display" command of Imagemagic
+     or just redirect to file.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		plotConfig := getPlotConfigs(cmd)
+
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		dataFieldXStr := getFlagString(cmd, "data-field-x")
+		if strings.Contains(dataFieldXStr, ",") {
+			checkError(fmt.Errorf("only one field allowed for flag -x (--data-field-x)"))
+		}
+		if dataFieldXStr != "" && dataFieldXStr[0] == '-' {
+			checkError(fmt.Errorf("unselect not allowed for flag -x (--data-field-x)"))
+		}
+		twoSamples := dataFieldXStr != ""
+		if !twoSamples && (plotConfig.xaxis.log || plotConfig.xaxis.trans != "") {
+			checkError(fmt.Errorf("flag --x-log and --x-trans are only supported with -x/--data-field-x"))
+		}
+
+		refLine := !getFlagBool(cmd, "no-ref-line")
+		lineWidth := vg.Points(getFlagPositiveFloat64(cmd, "line-width"))
+		pointSize := vg.Length(getFlagPositiveFloat64(cmd, "point-size"))
+		colorIndex := getFlagPositiveInt(cmd, "color-index")
+		if colorIndex > 7 {
+			checkError(fmt.Errorf("unsupported color index"))
+		}
+
+		fieldStr := plotConfig.dataFieldStr
+		axes := []*plotAxisConfig{plotConfig.yaxis}
+		if twoSamples {
+			fieldStr += "," + dataFieldXStr
+			axes = append(axes, plotConfig.xaxis)
+		}
+		groups := readPlotValueGroups(cmd, config, plotConfig, files[0], fieldStr, axes)
+
+		p := plotConfig.newPlot()
+		xmin, xmax := math.Inf(1), math.Inf(-1)
+		for i, name := range groups.names {
+			var xys plotter.XYs
+			var slope, intercept float64
+			if twoSamples {
+				xys = sampleQQ(groups.values[i][1], groups.values[i][0])
+				slope, intercept = 1, 0
+			} else {
+				xys, slope, intercept = normalQQ(groups.values[i][0])
+			}
+			if len(xys) == 0 {
+				continue
+			}
+
+			points, err := plotter.NewScatter(xys)
+			checkError(err)
+			points.Shape = plotutil.Shape(colorIndex - 1 + i)
+			points.Color = plotConfig.color(colorIndex - 1 + i)
+			points.Radius = pointSize
+			p.Add(points)
+			if plotConfig.groupFieldStr != "" {
+				p.Legend.Add(name, points)
+			}
+
+			xmin = math.Min(xmin, xys[0].X)
+			xmax = math.Max(xmax, xys[len(xys)-1].X)
+			if refLine && !twoSamples {
+				line, err := plotter.NewLine(plotter.XYs{
+					{X: xys[0].X, Y: intercept + slope*xys[0].X},
+					{X: xys[len(xys)-1].X, Y: intercept + slope*xys[len(xys)-1].X},
+				})
+				checkError(err)
+				line.Color = plotConfig.color(colorIndex - 1 + i)
+				line.LineStyle.Width = lineWidth
+				line.LineStyle.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+				p.Add(line)
+			}
+		}
+		if refLine && twoSamples && xmin < xmax {
+			line, err := plotter.NewLine(plotter.XYs{{X: xmin, Y: xmin}, {X: xmax, Y: xmax}})
+			checkError(err)
+			line.LineStyle.Width = lineWidth
+			line.LineStyle.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+			p.Add(line)
+		}
+		if lineWidth > pointSize {
+			p.Legend.Padding = vg.Length(lineWidth)
+		} else {
+			p.Legend.Padding = vg.Length(pointSize)
+		}
+		p.Legend.Top = getFlagBool(cmd, "legend-top")
+		p.Legend.Left = getFlagBool(cmd, "legend-left")
+
+		if plotConfig.ylab == "" {
+			if len(groups.header) > 0 {
+				plotConfig.ylab = plotConfig.yaxis.Label(groups.header[0])
+			} else {
+				plotConfig.ylab = "Sample quantiles"
+			}
+		}
+		if plotConfig.xlab == "" {
+			if twoSamples && len(groups.header) > 1 {
+				plotConfig.xlab = plotConfig.xaxis.Label(groups.header[1])
+			} else {
+				plotConfig.xlab = "Theoretical quantiles"
+			}
+		}
+
+		plotConfig.applyStyle(p)
+		plotConfig.applyLimits(p)
+		plotConfig.applyScales(p)
+
+		if plotConfig.term {
+			checkError(plotConfig.saveTerm(config.OutFile, p.Draw, p))
+			return
+		}
+
+		// Save image
+		checkError(plotConfig.saveImage(config.OutFile, plotConfig.width*vg.Inch, plotConfig.height*vg.Inch, p.Draw))
+	},
+}
+
+// ppoints returns probabilities for theoretical quantiles, the same as ppoints() in R.
+func ppoints(n int) []float64 {
+	a := 0.5
+	if n <= 10 {
+		a = 3.0 / 8
+	}
+	p := make([]float64, n)
+	for i := range p {
+		p[i] = (float64(i+1) - a) / (float64(n) + 1 - 2*a)
+	}
+	return p
+}
+
+// normalQQ returns points of sorted values against standard normal quantiles,
+// and the slope and intercept of the line through the first and third quartiles.
+func normalQQ(y []float64) (plotter.XYs, float64, float64) {
+	if len(y) == 0 {
+		return nil, 0, 0
+	}
+	sorted := append([]float64{}, y...)
+	sort.Float64s(sorted)
+
+	norm := distuv.UnitNormal
+	xys := make(plotter.XYs, len(sorted))
+	for i, p := range ppoints(len(sorted)) {
+		xys[i].X = norm.Quantile(p)
+		xys[i].Y = sorted[i]
+	}
+
+	x1, x2 := norm.Quantile(0.25), norm.Quantile(0.75)
+	y1, y2 := percentileValue(sorted, 0.25), percentileValue(sorted, 0.75)
+	slope := (y2 - y1) / (x2 - x1)
+	return xys, slope, y1 - slope*x1
+}
+
+// sampleQQ returns points of sorted y against sorted x, the longer one is
+// linearly interpolated to the size of the shorter one, like qqplot() in R.
+func sampleQQ(x, y []float64) plotter.XYs {
+	if len(x) == 0 || len(y) == 0 {
+		return nil
+	}
+	sx := append([]float64{}, x...)
+	sy := append([]float64{}, y...)
+	sort.Float64s(sx)
+	sort.Float64s(sy)
+
+	n := len(sx)
+	if len(sy) < n {
+		n = len(sy)
+	}
+	xys := make(plotter.XYs, n)
+	for i := range xys {
+		if len(sx) == n {
+			xys[i].X = sx[i]
+		} else {
+			xys[i].X = percentileValue(sx, float64(i)/float64(n-1))
+		}
+		if len(sy) == n {
+			xys[i].Y = sy[i]
+		} else {
+			xys[i].Y = percentileValue(sy, float64(i)/float64(n-1))
+		}
+	}
+	return xys
+}
+
+func init() {
+	plotCmd.AddCommand(qqCmd)
+
+	qqCmd.Flags().StringP("data-field-x", "x", "", `column index or column name of another sample for X axis, the standard normal distribution is used if not given`)
+	qqCmd.Flags().BoolP("no-ref-line", "", false, "do not draw the reference line")
+
+	qqCmd.Flags().BoolP("legend-top", "", false, "locate legend along the top edge of the plot")
+	qqCmd.Flags().BoolP("legend-left", "", false, "locate legend along the left edge of the plot")
+	qqCmd.Flags().Float64P("line-width", "", 1.5, "line width")
+	qqCmd.Flags().Float64P("point-size", "", 3, "point size")
+	qqCmd.Flags().IntP("color-index", "", 1, `color index, 1-7`)
+}
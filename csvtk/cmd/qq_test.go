@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalQQ(t *testing.T) {
+	// qqnorm(c(5, 1, 3)) in R, ppoints(3) = 0.1923077 0.5 0.8076923
+	xys, slope, intercept := normalQQ([]float64{5, 1, 3})
+	expected := []float64{-0.8694238, 0, 0.8694238}
+	for i, xy := range xys {
+		if math.Abs(xy.X-expected[i]) > 1e-6 || xy.Y != float64(2*i+1) {
+			t.Errorf("point %d: expected (%f, %d), returned (%f, %f)", i, expected[i], 2*i+1, xy.X, xy.Y)
+		}
+	}
+	// qqline: quartiles 2 and 4
+	if math.Abs(slope-2/(2*0.6744898)) > 1e-6 || math.Abs(intercept-3) > 1e-9 {
+		t.Errorf("unexpected reference line: slope %f, intercept %f", slope, intercept)
+	}
+}
+
+func TestSampleQQ(t *testing.T) {
+	// qqplot(c(1, 2, 3), c(10, 40, 20, 30, 50), plot.it = FALSE) in R
+	xys := sampleQQ([]float64{1, 2, 3}, []float64{10, 40, 20, 30, 50})
+	expected := [][2]float64{{1, 10}, {2, 30}, {3, 50}}
+	if len(xys) != len(expected) {
+		t.Fatalf("expected %d points, returned %d", len(expected), len(xys))
+	}
+	for i, xy := range xys {
+		if xy.X != expected[i][0] || xy.Y != expected[i][1] {
+			t.Errorf("point %d: expected %v, returned (%g, %g)", i, expected[i], xy.X, xy.Y)
+		}
+	}
+}
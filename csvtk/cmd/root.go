@@ -142,11 +142,17 @@ func init() {
 	RootCmd.PersistentFlags().BoolP("no-header-row", "H", false, `specifies that the input CSV file does not have header row`)
 	RootCmd.PersistentFlags().BoolP("delete-header", "U", false, `do not output header row`)
 	RootCmd.PersistentFlags().StringP("out-file", "o", "-", `out file ("-" for stdout, suffix .gz for gzipped out)`)
+	RootCmd.PersistentFlags().StringP("out-compress", "", "", `compression format of output: gzip, zstd, xz, bz2 or none. `+
+		`By default, it's decided by the suffix of the output file (.gz, .zst, .xz or .bz2)`)
+	RootCmd.PersistentFlags().IntP("compress-level", "", -1, `compression level, -1 for the default level of the format. `+
+		`gzip: 1-9, zstd: 1-22, xz: 0-9, bz2: 1-9`)
 
 	RootCmd.PersistentFlags().BoolP("show-row-number", "Z", false, `show row number as the first column, with header row skipped`)
 
 	RootCmd.PersistentFlags().BoolP("ignore-empty-row", "E", false, `ignore empty rows`)
 	RootCmd.PersistentFlags().BoolP("ignore-illegal-row", "I", false, `ignore illegal rows. You can also use 'csvtk fix' to fix files with different numbers of columns in rows`)
+	RootCmd.PersistentFlags().BoolP("follow", "", false, `keep reading the input file as it grows, like "tail -F". Truncated or rotated files are read from the beginning, with the header row skipped. Supported commands: cut, filter2, grep, watch`)
+	RootCmd.PersistentFlags().Float64P("follow-interval", "", 1, `interval (seconds) of checking for new data, for --follow`)
 	RootCmd.PersistentFlags().StringP("infile-list", "X", "", "file of input files list (one file per line), if given, they are appended to files from cli arguments")
 
 	RootCmd.CompletionOptions.DisableDefaultCmd = true
@@ -59,7 +59,7 @@ var sampleCmd = &cobra.Command{
 		seed := getFlagInt64(cmd, "rand-seed")
 		rand.Seed(seed)
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -0,0 +1,234 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	GroupID: "info",
+
+	Use:   "schema",
+	Short: "infer column types and profile columns",
+	Long: `infer column types and profile columns
+
+Column types:
+
+  bool          true/false, case ignored
+  int           integers, thousands separators allowed
+  float         floating-point numbers, including scientific notation
+  date          dates, the detected layout is reported in the column "format"
+  datetime      dates with clock time
+  categorical   text with few distinct values, see -m/--max-categories
+  string        other text, or columns with only NA values
+
+Output columns:
+
+  column        column name, or field number for data without header row
+  type          inferred column type
+  format        Go time layout for date/datetime, or "mixed" for multiple layouts
+  nullable      whether NA values exist, see --na-values
+  count         number of values
+  missing       number of NA values
+  distinct      number of distinct non-NA values. It's exact for no more than
+                65536 values, or estimated with HyperLogLog (error ~0.81%)
+  min, max      minimum and maximum values, compared as numbers or dates
+                for numeric or date columns, and as text for others
+
+The same type inference is used by type selectors in field syntax (e.g., -f @numeric),
+"csvtk csv2json -n" and "csvtk csv2xlsx -f".
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		fieldStr := getFlagString(cmd, "fields")
+		if fieldStr == "" {
+			checkError(fmt.Errorf("flag -f (--fields) needed"))
+		}
+		fuzzyFields := getFlagBool(cmd, "fuzzy-fields")
+		sampleRows := getFlagNonNegativeInt(cmd, "sample-rows")
+		maxCategories := getFlagNonNegativeInt(cmd, "max-categories")
+		naMap := naValuesMap(getFlagStringSlice(cmd, "na-values"))
+
+		format := strings.ToLower(getFlagString(cmd, "format"))
+		switch format {
+		case "csv", "json":
+		default:
+			checkError(fmt.Errorf("invalid value of flag --format: %s. available: csv, json", format))
+		}
+
+		file := files[0]
+		csvReader, err := newCSVReaderByConfig(config, file)
+		if err != nil {
+			if err == xopen.ErrNoContent {
+				if config.Verbose {
+					log.Warningf("csvtk schema: skipping empty input file: %s", file)
+				}
+				return
+			}
+			checkError(err)
+		}
+
+		csvReader.Read(ReadOption{
+			FieldStr:    fieldStr,
+			FuzzyFields: fuzzyFields,
+		})
+
+		var profiles []*columnProfile
+		var i, n int
+		var v string
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				profiles = make([]*columnProfile, len(record.Fields))
+				if !config.NoHeaderRow || record.IsHeaderRow {
+					for i, v = range record.Selected {
+						profiles[i] = newColumnProfile(v, naMap, maxCategories)
+					}
+					continue
+				}
+				for i, f := range record.Fields {
+					profiles[i] = newColumnProfile(strconv.Itoa(f), naMap, maxCategories)
+				}
+			}
+
+			for i, v = range record.Selected {
+				profiles[i].Add(v)
+			}
+
+			n++
+			if sampleRows > 0 && n == sampleRows {
+				break
+			}
+		}
+
+		readerReport(&config, csvReader, file)
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+		defer outfh.Close()
+
+		if format == "json" {
+			columns := make([]columnSchema, len(profiles))
+			for i, p := range profiles {
+				columns[i] = newColumnSchema(p)
+			}
+			data, err := json.MarshalIndent(columns, "", "  ")
+			checkError(err)
+			outfh.Write(data)
+			outfh.WriteString("\n")
+			return
+		}
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+
+		if !config.NoOutHeader {
+			checkError(writer.Write([]string{"column", "type", "format", "nullable", "count", "missing", "distinct", "min", "max"}))
+		}
+		for _, p := range profiles {
+			s := newColumnSchema(p)
+			checkError(writer.Write([]string{
+				s.Column,
+				s.Type,
+				s.Format,
+				strconv.FormatBool(s.Nullable),
+				strconv.FormatInt(s.Count, 10),
+				strconv.FormatInt(s.Missing, 10),
+				strconv.FormatUint(s.Distinct, 10),
+				s.Min,
+				s.Max,
+			}))
+		}
+		writer.Flush()
+		checkError(writer.Error())
+	},
+}
+
+// columnSchema is the profile of a column for output
+type columnSchema struct {
+	Column        string `json:"column"`
+	Type          string `json:"type"`
+	Format        string `json:"format,omitempty"`
+	Nullable      bool   `json:"nullable"`
+	Count         int64  `json:"count"`
+	Missing       int64  `json:"missing"`
+	Distinct      uint64 `json:"distinct"`
+	DistinctExact bool   `json:"distinct_exact"`
+	Min           string `json:"min,omitempty"`
+	Max           string `json:"max,omitempty"`
+}
+
+func newColumnSchema(p *columnProfile) columnSchema {
+	distinct, exact := p.Distinct()
+	return columnSchema{
+		Column:        p.Name,
+		Type:          p.Type(),
+		Format:        p.Format(),
+		Nullable:      p.NA > 0,
+		Count:         p.N,
+		Missing:       p.NA,
+		Distinct:      distinct,
+		DistinctExact: exact,
+		Min:           p.Min(),
+		Max:           p.Max(),
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(schemaCmd)
+	schemaCmd.Flags().StringP("fields", "f", "1-", `select only these fields. type "csvtk cut -h" for examples`)
+	schemaCmd.Flags().BoolP("fuzzy-fields", "F", false, `using fuzzy fields, e.g., -F -f "*name" or -F -f "id123*"`)
+	schemaCmd.Flags().IntP("sample-rows", "n", 0, "only scan the first N data rows, 0 for all")
+	schemaCmd.Flags().IntP("max-categories", "m", defaultMaxCategories, "maximum number of distinct values of a categorical column")
+	schemaCmd.Flags().StringSliceP("na-values", "", defaultNAValues, `NA values, case ignored`)
+	schemaCmd.Flags().StringP("format", "", "csv", `output format: csv, json`)
+}
@@ -0,0 +1,441 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Extended field selectors, in addition to field numbers, ranges and names:
+
+	/regexp/       columns with names matching the regular expression
+	@type          columns of a type inferred from sampled rows, see colTypeClasses
+	colA:colF      columns from colA to colF in the header row, "colA:" and ":colF" are open ranges
+	-selector      remove columns matched by the selector
+
+Positive selectors are unioned in order, and negative ones are then removed.
+If only negative selectors are given, they are removed from all columns.
+*/
+
+// defaultTypeSampleRows is the number of data rows used to guess column types
+const defaultTypeSampleRows = 1000
+
+type fieldSelectorKind int
+
+const (
+	selectorName fieldSelectorKind = iota
+	selectorIndex
+	selectorRegexp
+	selectorType
+	selectorRange
+)
+
+type fieldSelector struct {
+	raw      string
+	negative bool
+	kind     fieldSelectorKind
+
+	name       string // name, type, or the full name for a range
+	start, end string // range by names
+	first      int    // index range
+	last       int    // 0 for open range
+
+	re *regexp.Regexp
+}
+
+// splitFieldSelectors splits field string by the separator,
+// separators inside a /regexp/ are kept.
+func splitFieldSelectors(fieldsStr string, sep string) []string {
+	if !strings.Contains(fieldsStr, "/") {
+		return strings.Split(fieldsStr, sep)
+	}
+	items := make([]string, 0, 8)
+	var inRegexp, itemStart bool
+	var j int
+	itemStart = true
+	for i := 0; i < len(fieldsStr); i++ {
+		if inRegexp {
+			if fieldsStr[i] == '\\' {
+				i++
+			} else if fieldsStr[i] == '/' {
+				inRegexp = false
+			}
+			continue
+		}
+		if strings.HasPrefix(fieldsStr[i:], sep) {
+			items = append(items, fieldsStr[j:i])
+			i += len(sep) - 1
+			j = i + 1
+			itemStart = true
+			continue
+		}
+		if itemStart {
+			if fieldsStr[i] == '-' && i+1 < len(fieldsStr) && fieldsStr[i+1] == '/' {
+				i++
+			}
+			if fieldsStr[i] == '/' {
+				inRegexp = true
+			}
+			itemStart = false
+		}
+	}
+	items = append(items, fieldsStr[j:])
+	return items
+}
+
+// parseFieldSelectors parses the field string, and reports whether
+// extended selectors are used. Plain field numbers, ranges and names
+// are still handled by parseFields when no extended selectors are found.
+func parseFieldSelectors(fieldsStr string, sep string, ignoreCase bool) ([]*fieldSelector, bool, error) {
+	items := splitFieldSelectors(fieldsStr, sep)
+	if !isExtendedFieldSelectors(items) {
+		return nil, false, nil
+	}
+	selectors, err := parseFieldSelectorItems(fieldsStr, items, ignoreCase)
+	if err != nil {
+		return nil, false, err
+	}
+	return selectors, true, nil
+}
+
+// parseFieldSelectorItems parses items of a field string into selectors.
+func parseFieldSelectorItems(fieldsStr string, items []string, ignoreCase bool) ([]*fieldSelector, error) {
+	selectors := make([]*fieldSelector, 0, len(items))
+
+	var s *fieldSelector
+	var item string
+	var err error
+	for i, raw := range items {
+		if raw == "" || raw == "-" {
+			return nil, fmt.Errorf(`%s filed should not be empty: %s`, nth(i+1), fieldsStr)
+		}
+		s = &fieldSelector{raw: raw}
+		item = raw
+		if item[0] == '-' {
+			s.negative = true
+			item = item[1:]
+		}
+
+		switch {
+		case isRegexpSelector(item):
+			s.kind = selectorRegexp
+			expr := item[1 : len(item)-1]
+			if ignoreCase {
+				expr = "(?i)" + expr
+			}
+			s.re, err = regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression in field: %s: %s", raw, err)
+			}
+		case isTypeSelector(item):
+			s.kind = selectorType
+			s.name = strings.ToLower(item[1:])
+		case strings.Contains(item, ":"):
+			s.kind = selectorRange
+			s.name = item
+			k := strings.Index(item, ":")
+			s.start, s.end = item[:k], item[k+1:]
+		case reIntegerRange.MatchString(item):
+			s.kind = selectorIndex
+			found := reIntegerRange.FindStringSubmatch(item)
+			s.first, err = strconv.Atoi(found[1])
+			if err != nil || s.first <= 0 {
+				return nil, fmt.Errorf("invalid field range: %s", raw)
+			}
+			if found[2] != "" {
+				s.last, err = strconv.Atoi(found[2])
+				if err != nil || s.last < s.first {
+					return nil, fmt.Errorf("invalid field range: %s", raw)
+				}
+			}
+		case reIntegers.MatchString(item):
+			s.kind = selectorIndex
+			s.first, err = strconv.Atoi(item)
+			if err != nil || s.first <= 0 {
+				return nil, fmt.Errorf("invalid field: %s", raw)
+			}
+			s.last = s.first
+		default:
+			s.kind = selectorName
+			s.name = item
+		}
+		selectors = append(selectors, s)
+	}
+
+	return selectors, nil
+}
+
+func isRegexpSelector(item string) bool {
+	return len(item) >= 2 && item[0] == '/' && item[len(item)-1] == '/'
+}
+
+func isTypeSelector(item string) bool {
+	if len(item) < 2 || item[0] != '@' {
+		return false
+	}
+	_, ok := colTypeClasses[strings.ToLower(item[1:])]
+	return ok
+}
+
+// isExtendedFieldSelectors checks if any extended selector is used,
+// mixing positive and negative column names is also treated as a set difference.
+func isExtendedFieldSelectors(items []string) bool {
+	var hasPositive, hasNegative, hasName bool
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		if item[0] == '-' {
+			hasNegative = true
+			item = item[1:]
+		} else {
+			hasPositive = true
+		}
+		if isRegexpSelector(item) || isTypeSelector(item) || strings.Contains(item, ":") {
+			return true
+		}
+		if !reIntegers.MatchString(item) && !reIntegerRange.MatchString(item) {
+			hasName = true
+		}
+	}
+	return hasPositive && hasNegative && hasName
+}
+
+func hasTypeSelector(selectors []*fieldSelector) bool {
+	for _, s := range selectors {
+		if s.kind == selectorType {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveFieldsOfHeader resolves fields of flags other than -f/--fields, e.g., -g/--groups,
+// in the same way as -f/--fields, except type selectors. header is nil for data
+// without header row, where only field numbers and ranges are allowed.
+func resolveFieldsOfHeader(fieldsStr string, header []string, ncols int, opt *ReadOption, file string) ([]int, error) {
+	selectors, err := parseFieldSelectorItems(fieldsStr, splitFieldSelectors(fieldsStr, ","), opt.IgnoreFieldCase)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range selectors {
+		if s.kind == selectorType {
+			return nil, fmt.Errorf("type selectors are not supported: %s", s.raw)
+		}
+		if header == nil && s.kind != selectorIndex {
+			return nil, fmt.Errorf("field number (positive integer) expected for data without header row: %s", s.raw)
+		}
+	}
+	if header == nil {
+		header = make([]string, ncols)
+	}
+	fields, _, err := resolveFieldSelectors(selectors, header, nil, opt, file)
+	return fields, err
+}
+
+// resolveFieldSelectors returns the selected fields (1-based),
+// and fields matched by each selector.
+func resolveFieldSelectors(selectors []*fieldSelector, header []string, sample [][]string,
+	opt *ReadOption, file string) ([]int, [][]int, error) {
+
+	var colnames []string
+	if opt.IgnoreFieldCase {
+		colnames = make([]string, len(header))
+		for i, col := range header {
+			colnames[i] = strings.ToLower(col)
+		}
+	} else {
+		colnames = header
+	}
+	colnames2fields := make(map[string][]int, len(colnames))
+	for i, col := range colnames {
+		colnames2fields[col] = append(colnames2fields[col], i+1)
+	}
+
+	var types []string
+	if hasTypeSelector(selectors) {
+		types = inferColumnTypes(len(header), sample, naValuesMap(defaultNAValues))
+	}
+
+	byName := func(name string) ([]int, error) {
+		if opt.IgnoreFieldCase {
+			name = strings.ToLower(name)
+		}
+		if opt.FuzzyFields {
+			re := fuzzyField2Regexp(name)
+			matched := make([]int, 0, 1)
+			for i, col := range colnames {
+				if re.MatchString(col) {
+					matched = append(matched, i+1)
+				}
+			}
+			return matched, nil
+		}
+		fields, ok := colnames2fields[name]
+		if !ok {
+			if opt.AllowMissingColumn {
+				return nil, nil
+			}
+			return nil, fmt.Errorf(`column "%s" not existed in file: %s`, name, file)
+		}
+		if opt.DoNotAllowDuplicatedColumnName && len(fields) > 1 {
+			return nil, fmt.Errorf("the selected colname is duplicated in the input data: %s", name)
+		}
+		return fields, nil
+	}
+
+	selected := make([][]int, len(selectors))
+	var matched []int
+	var err error
+	for k, s := range selectors {
+		matched = nil
+		switch s.kind {
+		case selectorName:
+			matched, err = byName(s.name)
+			if err != nil {
+				return nil, nil, err
+			}
+		case selectorIndex:
+			last := s.last
+			if last == 0 {
+				last = len(header)
+			}
+			if last > len(header) {
+				if !opt.AllowMissingColumn {
+					return nil, nil, fmt.Errorf(`field (%d) out of range (%d) in file: %s`, last, len(header), file)
+				}
+				last = len(header)
+			}
+			matched = make([]int, 0, 8)
+			for i := s.first; i <= last; i++ {
+				matched = append(matched, i)
+			}
+		case selectorRegexp:
+			matched = make([]int, 0, 8)
+			for i, col := range header {
+				if s.re.MatchString(col) {
+					matched = append(matched, i+1)
+				}
+			}
+		case selectorType:
+			matched = make([]int, 0, 8)
+			for i, t := range types {
+				if colTypeMatches(s.name, t) {
+					matched = append(matched, i+1)
+				}
+			}
+		case selectorRange:
+			name := s.name
+			if opt.IgnoreFieldCase {
+				name = strings.ToLower(name)
+			}
+			if _, ok := colnames2fields[name]; ok { // a column name containing ":"
+				matched, err = byName(s.name)
+				if err != nil {
+					return nil, nil, err
+				}
+				break
+			}
+			first, last := 1, len(header)
+			var fs []int
+			if s.start != "" {
+				if fs, err = byName(s.start); err != nil {
+					return nil, nil, err
+				}
+				if len(fs) == 0 {
+					break
+				}
+				first = fs[0]
+			}
+			if s.end != "" {
+				if fs, err = byName(s.end); err != nil {
+					return nil, nil, err
+				}
+				if len(fs) == 0 {
+					break
+				}
+				last = fs[len(fs)-1]
+			}
+			if first > last {
+				return nil, nil, fmt.Errorf("invalid field range: %s. column %s should be on the left of %s", s.raw, s.start, s.end)
+			}
+			matched = make([]int, 0, last-first+1)
+			for i := first; i <= last; i++ {
+				matched = append(matched, i)
+			}
+		}
+		selected[k] = matched
+	}
+
+	var hasPositive bool
+	for _, s := range selectors {
+		if !s.negative {
+			hasPositive = true
+			break
+		}
+	}
+
+	fields := make([]int, 0, len(header))
+	marks := make(map[int]struct{}, len(header))
+	if hasPositive {
+		for k, s := range selectors {
+			if s.negative {
+				continue
+			}
+			for _, f := range selected[k] {
+				if _, ok := marks[f]; ok {
+					continue
+				}
+				marks[f] = struct{}{}
+				fields = append(fields, f)
+			}
+		}
+	} else {
+		for i := range header {
+			fields = append(fields, i+1)
+		}
+	}
+
+	removed := make(map[int]struct{}, len(header))
+	for k, s := range selectors {
+		if !s.negative {
+			continue
+		}
+		for _, f := range selected[k] {
+			removed[f] = struct{}{}
+		}
+	}
+	if len(removed) > 0 {
+		fields2 := make([]int, 0, len(fields))
+		for _, f := range fields {
+			if _, ok := removed[f]; !ok {
+				fields2 = append(fields2, f)
+			}
+		}
+		fields = fields2
+	}
+
+	return fields, selected, nil
+}
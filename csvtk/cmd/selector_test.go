@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFieldSelectors(t *testing.T) {
+	cases := []struct {
+		s      string
+		expect []string
+	}{
+		{"a,b,c", []string{"a", "b", "c"}},
+		{`/a{1,3}/,b`, []string{`/a{1,3}/`, "b"}},
+		{`-/x,y/,/\/,/,c`, []string{`-/x,y/`, `/\/,/`, "c"}},
+		{"N/A,b", []string{"N/A", "b"}},
+	}
+	for _, c := range cases {
+		items := splitFieldSelectors(c.s, ",")
+		if !reflect.DeepEqual(items, c.expect) {
+			t.Errorf("split %s: expected %v, returned %v", c.s, c.expect, items)
+		}
+	}
+}
+
+func TestResolveFieldSelectors(t *testing.T) {
+	header := []string{"id", "name", "s1", "s2", "date", "s3"}
+	sample := [][]string{
+		{"1", "a", "0.1", "NA", "2024-01-02", "x"},
+		{"2", "b", "1e3", "3", "2024-02-03", "4"},
+	}
+	cases := []struct {
+		s      string
+		expect []int
+	}{
+		{"/^s\\d$/", []int{3, 4, 6}},
+		{"@numeric", []int{1, 3, 4}},
+		{"@string", []int{2, 6}},
+		{"@date", []int{5}},
+		{"name,@numeric,-id", []int{2, 3, 4}},
+		{"s1:date", []int{3, 4, 5}},
+		{"date:", []int{5, 6}},
+		{":name,/^s/", []int{1, 2, 3, 4, 6}},
+		{"-/^s/", []int{1, 2, 5}},
+		{"1-2,-id", []int{2}},
+	}
+	for _, c := range cases {
+		selectors, extended, err := parseFieldSelectors(c.s, ",", false)
+		if err != nil {
+			t.Errorf("parse %s: %s", c.s, err)
+			continue
+		}
+		if !extended {
+			t.Errorf("parse %s: extended selectors expected", c.s)
+			continue
+		}
+		fields, _, err := resolveFieldSelectors(selectors, header, sample, &ReadOption{}, "-")
+		if err != nil {
+			t.Errorf("resolve %s: %s", c.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, c.expect) {
+			t.Errorf("resolve %s: expected %v, returned %v", c.s, c.expect, fields)
+		}
+	}
+
+	// missing columns in ranges are ignored with -m/--allow-missing-col
+	for s, expect := range map[string][]int{
+		"name,-zz:":    {2},
+		"name,zz:date": {2},
+		"id,s1:zz":     {1},
+	} {
+		selectors, _, err := parseFieldSelectors(s, ",", false)
+		if err != nil {
+			t.Errorf("parse %s: %s", s, err)
+			continue
+		}
+		fields, _, err := resolveFieldSelectors(selectors, header, sample, &ReadOption{AllowMissingColumn: true}, "-")
+		if err != nil {
+			t.Errorf("resolve %s: %s", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, expect) {
+			t.Errorf("resolve %s: expected %v, returned %v", s, expect, fields)
+		}
+	}
+
+	for _, s := range []string{"1,3-5", "-1--3", "a,b", "-a,-b", "2-"} {
+		if _, extended, _ := parseFieldSelectors(s, ",", false); extended {
+			t.Errorf("parse %s: extended selectors not expected", s)
+		}
+	}
+}
@@ -141,7 +141,7 @@ var sortCmd = &cobra.Command{
 
 		fuzzyFields := false
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -30,7 +30,6 @@ import (
 	"sync"
 
 	"github.com/shenwei356/util/pathutil"
-	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
 )
 
@@ -45,6 +44,9 @@ var splitCmd = &cobra.Command{
 Note:
 
   1. flag -o/--out-file can specify out directory for splitted files
+  2. the compression format of output files is the same as the input file,
+     or specified by the global flag --out-compress, and the suffix is
+     changed accordingly. Flag -G/--out-gzip equals to --out-compress gzip.
 
 `,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -64,7 +66,12 @@ Note:
 		ignoreCase := getFlagBool(cmd, "ignore-case")
 		bufRowsSize := getFlagNonNegativeInt(cmd, "buf-rows")
 		bufGroupsSize := getFlagNonNegativeInt(cmd, "buf-groups")
-		gzipped := getFlagBool(cmd, "out-gzip")
+		if getFlagBool(cmd, "out-gzip") {
+			if config.OutCompress != "" && config.OutCompress != "gzip" {
+				checkError(fmt.Errorf("flag -G/--out-gzip is conflicted with --out-compress %s", config.OutCompress))
+			}
+			config.OutCompress = "gzip"
+		}
 
 		file := files[0]
 		csvReader, err := newCSVReaderByConfig(config, file)
@@ -87,9 +94,8 @@ Note:
 		} else {
 			outFilePrefix, outFileSuffix = filepathTrimExtension(file)
 		}
-		if gzipped &&
-			!strings.HasSuffix(strings.ToLower(outFileSuffix), ".gz") {
-			outFileSuffix = outFileSuffix + ".gz"
+		if config.OutCompress != "" { // replace the suffix of compression format
+			outFileSuffix = trimCompressSuffix(outFileSuffix) + compressSuffixes[config.OutCompress]
 		}
 
 		if config.OutFile != "-" { // outdir
@@ -209,7 +215,7 @@ func init() {
 	splitCmd.Flags().StringP("fields", "f", "1", `comma separated key fields, column name or index. e.g. -f 1-3 or -f id,id2 or -F -f "group*"`)
 	splitCmd.Flags().BoolP("fuzzy-fields", "F", false, `using fuzzy fields, e.g., -F -f "*name" or -F -f "id123*"`)
 	splitCmd.Flags().BoolP("ignore-case", "i", false, `ignore case`)
-	splitCmd.Flags().BoolP("out-gzip", "G", false, `force output gzipped file`)
+	splitCmd.Flags().BoolP("out-gzip", "G", false, `force output gzipped file, equals to --out-compress gzip`)
 	splitCmd.Flags().IntP("buf-rows", "b", 100000, `buffering N rows for every group before writing to file`)
 	splitCmd.Flags().IntP("buf-groups", "g", 100, `buffering N groups before writing to file`)
 
@@ -225,14 +231,14 @@ func appendRows(config Config,
 	key string,
 ) {
 
-	var outfh *xopen.Writer
+	var outfh *outWriter
 	var err error
 
 	_, written := writtenFiles.Load(key)
 	if written {
-		outfh, err = xopen.WopenFile(outFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		outfh, err = wopenFileByConfig(config, outFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	} else {
-		outfh, err = xopen.Wopen(outFile)
+		outfh, err = wopenByConfig(config, outFile)
 		writtenFiles.Store(key, true)
 	}
 	checkError(err)
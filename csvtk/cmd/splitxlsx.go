@@ -287,9 +287,9 @@ Weakness : Complicated sheet structures are not well supported, e.g.,
 		xlsx.SetActiveSheet(from)
 		if config.OutFile == "-" {
 			prefx, _ := filepathTrimExtension(files[0])
-			config.OutFile = fmt.Sprintf("%s.split.xlsx", prefx)
+			config.OutFile = fmt.Sprintf("%s.split.xlsx%s", prefx, compressSuffixes[config.OutCompress])
 		}
-		checkError(xlsx.SaveAs(config.OutFile))
+		checkError(saveXlsx(config, xlsx, config.OutFile))
 	},
 }
 
@@ -24,8 +24,11 @@ import (
 	"encoding/csv"
 	"fmt"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/shenwei356/natsort"
 	"github.com/shenwei356/util/stringutil"
 	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
@@ -42,6 +45,30 @@ var spreadCmd = &cobra.Command{
 	Short: "spread a key-value pair across multiple columns, like tidyr::spread/pivot_wider",
 	Long: `spread a key-value pair across multiple columns, like tidyr::spread/pivot_wider
 
+Columns other than the key and value columns are used to identify rows,
+you may need "csvtk cut" to remove unwanted columns first.
+
+Multiple values that share the same key are joined with -s/--separater by default,
+or aggregated with -a/--aggregate, which supports all operations of "csvtk summary":
+
+  # numeric operations
+  countn, min, max, sum, argmin, argmax, mean, stdev, variance,
+  median, q1, q2, q3, entropy, prod, mode, iqr, mad, skewness, kurtosis,
+  gmean, hmean, cv, pN (e.g., p90)
+
+  # textual/numeric operations
+  count, first, last, rand, unique/uniq, collapse, countunique
+
+Multiple value columns:
+
+  Output columns are named as "<value><names-sep><key>", ordered by value columns.
+
+Totals (-a/--aggregate needed):
+
+  --row-total adds a column of totals for each value column, and --col-total adds
+  a row of totals. Totals are computed from the original values, e.g., the row total
+  of "mean" is the mean of all values in the row, not the mean of the means.
+
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		config := getConfigs(cmd)
@@ -60,12 +87,33 @@ var spreadCmd = &cobra.Command{
 			checkError(fmt.Errorf("flag -v/--value needed"))
 		}
 		na := getFlagString(cmd, "na")
-		separater := getFlagString(cmd, "separater")
+		separater = getFlagString(cmd, "separater")
+
+		op := getFlagString(cmd, "aggregate")
+		if op != "" {
+			_, ok1 := summaryOpFunc(op) // for numbers
+			_, ok2 := allStats2[op]     // for strings
+			if !(ok1 || ok2) {
+				checkError(fmt.Errorf(`invalid operation: %s. run "csvtk spread --help" for help`, op))
+			}
+		}
+		ignore := getFlagBool(cmd, "ignore-non-numbers")
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+		rowTotal := getFlagBool(cmd, "row-total")
+		colTotal := getFlagBool(cmd, "col-total")
+		totalLabel := getFlagString(cmd, "total-label")
+		if (rowTotal || colTotal) && op == "" {
+			checkError(fmt.Errorf("flag -a/--aggregate needed for --row-total and --col-total"))
+		}
+		sortKeys := getFlagBool(cmd, "sort-keys")
+		natSort := getFlagBool(cmd, "nat-sort")
+		namesSep := getFlagString(cmd, "names-sep")
 
 		fieldStr := fieldKey + "," + fieldValue
 		fuzzyFields := false
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -90,7 +138,7 @@ var spreadCmd = &cobra.Command{
 		if err != nil {
 			if err == xopen.ErrNoContent {
 				if config.Verbose {
-					log.Warningf("csvtk gather: skipping empty input file: %s", file)
+					log.Warningf("csvtk spread: skipping empty input file: %s", file)
 				}
 
 				writer.Flush()
@@ -109,12 +157,11 @@ var spreadCmd = &cobra.Command{
 		})
 
 		var fieldsMap map[int]interface{}
-		var f int
+		var f, i int
 		var left, key, val string
-		var vals []string
 		var ok bool
 		var items []string
-		data := make(map[string]map[string][]string) // other column -> key -> []value
+		data := make(map[string]map[string][][]string) // other column -> key -> value column -> []value
 		keysMap := make(map[string]interface{}, 128)
 		keysOrder := make(map[string]int, 128)
 		var nKey int
@@ -123,6 +170,8 @@ var spreadCmd = &cobra.Command{
 		checkFirstLine := true
 		var handleHeaderRow bool
 		var HeaderRow []string
+		var valueNames []string // names of value columns
+		var nValues int
 		var nLeft int // number of coulmns except the key and value columns
 
 		for record := range csvReader.Ch {
@@ -134,11 +183,8 @@ var spreadCmd = &cobra.Command{
 				checkError(fmt.Errorf("input data should have at least two columns"))
 			}
 
-			if len(record.Fields) != 2 {
-				checkError(fmt.Errorf("only exactly one key field and one value field are allowed"))
-			}
-			if record.Fields[0] == record.Fields[1] {
-				checkError(fmt.Errorf("key field and value field should be different"))
+			if len(record.Fields) < 2 {
+				checkError(fmt.Errorf("one key field and at least one value field are needed"))
 			}
 
 			fieldsMap = make(map[int]interface{}, len(record.Selected))
@@ -146,19 +192,33 @@ var spreadCmd = &cobra.Command{
 				fieldsMap[f-1] = struct{}{}
 			}
 
-			items = make([]string, 0, len(record.All)-2)
-
 			if checkFirstLine {
 				checkFirstLine = false
 
-				nLeft = len(record.All) - 2
+				for _, f = range record.Fields[1:] {
+					if f == record.Fields[0] {
+						checkError(fmt.Errorf("key field and value field should be different"))
+					}
+				}
+				if len(fieldsMap) != len(record.Fields) {
+					checkError(fmt.Errorf("duplicated value fields given"))
+				}
+
+				nValues = len(record.Fields) - 1
+				nLeft = len(record.All) - len(record.Fields)
 
 				if !config.NoHeaderRow || record.IsHeaderRow { // do not replace head line
 					handleHeaderRow = true
+					valueNames = append([]string{}, record.Selected[1:]...)
+				} else {
+					valueNames = make([]string, nValues)
+					for i, f = range record.Fields[1:] {
+						valueNames[i] = strconv.Itoa(f)
+					}
 				}
 			}
 
-			items = items[:0]
+			items = make([]string, 0, nLeft)
 			for f, val = range record.All {
 				if _, ok = fieldsMap[f]; !ok {
 					items = append(items, val)
@@ -167,8 +227,7 @@ var spreadCmd = &cobra.Command{
 
 			if handleHeaderRow {
 				handleHeaderRow = false
-				HeaderRow = make([]string, len(items))
-				copy(HeaderRow, items)
+				HeaderRow = items
 
 				continue
 			}
@@ -179,17 +238,17 @@ var spreadCmd = &cobra.Command{
 				groupOrder[left] = record.Row
 			}
 
-			key, val = record.Selected[0], record.Selected[1]
+			key = record.Selected[0]
 
 			if _, ok = data[left]; !ok {
-				data[left] = make(map[string][]string, 8)
+				data[left] = make(map[string][][]string, 8)
 			}
 
 			if _, ok = data[left][key]; !ok {
-				data[left][key] = []string{val}
-			} else {
-				// log.Warningf("duplicated record: %s (%s) for %s at line %d", key, val, strings.Join(items, ","), record.Line)
-				data[left][key] = append(data[left][key], val)
+				data[left][key] = make([][]string, nValues)
+			}
+			for i, val = range record.Selected[1:] {
+				data[left][key][i] = append(data[left][key][i], val)
 			}
 
 			if _, ok = keysMap[key]; !ok {
@@ -204,38 +263,183 @@ var spreadCmd = &cobra.Command{
 		for _, o := range stringutil.SortCountOfString(keysOrder, false) {
 			keys = append(keys, o.Key)
 		}
+		if natSort {
+			natsort.Sort(keys)
+		} else if sortKeys {
+			sort.Strings(keys)
+		}
+
+		aggregate := func(vals []string) string {
+			if op == "" {
+				return strings.Join(vals, separater)
+			}
+			v, err := aggregateValues(op, vals, ignore, decimalFormat)
+			checkError(err)
+			return v
+		}
 
 		if HeaderRow == nil {
 			HeaderRow = make([]string, nLeft)
 		}
-		checkError(writer.Write(append(HeaderRow, keys...)))
-
-		var m map[string][]string
+		for i = 0; i < nValues; i++ {
+			for _, key = range keys {
+				if nValues == 1 {
+					HeaderRow = append(HeaderRow, key)
+				} else {
+					HeaderRow = append(HeaderRow, valueNames[i]+namesSep+key)
+				}
+			}
+		}
+		if rowTotal {
+			for i = 0; i < nValues; i++ {
+				if nValues == 1 {
+					HeaderRow = append(HeaderRow, totalLabel)
+				} else {
+					HeaderRow = append(HeaderRow, valueNames[i]+namesSep+totalLabel)
+				}
+			}
+		}
+		checkError(writer.Write(HeaderRow))
 
+		groups := make([]string, 0, len(groupOrder))
 		for _, o := range stringutil.SortCountOfString(groupOrder, false) {
-			items = strings.Split(o.Key, "_shenwei356_")
-			m = data[o.Key]
+			groups = append(groups, o.Key)
+		}
+		for _, items = range spreadRows(data, groups, keys, nLeft, nValues, na,
+			rowTotal, colTotal, totalLabel, aggregate) {
+			checkError(writer.Write(items))
+		}
 
-			for _, key = range keys {
+		readerReport(&config, csvReader, file)
+	},
+}
+
+// spreadRows returns rows of the wide table, with a column of row totals
+// and a row of column totals if needed. data is in the structure of
+// group -> key -> values of each value field, groups and keys are in order.
+func spreadRows(data map[string]map[string][][]string, groups []string, keys []string,
+	nLeft int, nValues int, na string, rowTotal bool, colTotal bool, totalLabel string,
+	aggregate func([]string) string) [][]string {
+
+	rows := make([][]string, 0, len(groups)+1)
+	var m map[string][][]string
+	var vals [][]string
+	var all []string
+	var items []string
+	var ok bool
+	var i int
+	for _, group := range groups {
+		items = strings.Split(group, "_shenwei356_")
+		if nLeft == 0 {
+			items = items[:0]
+		}
+		m = data[group]
+
+		for i = 0; i < nValues; i++ {
+			for _, key := range keys {
 				if vals, ok = m[key]; ok {
-					items = append(items, strings.Join(vals, separater))
+					items = append(items, aggregate(vals[i]))
 				} else {
 					items = append(items, na)
 				}
 			}
+		}
+		if rowTotal {
+			for i = 0; i < nValues; i++ {
+				all = all[:0]
+				for _, key := range keys {
+					if vals, ok = m[key]; ok {
+						all = append(all, vals[i]...)
+					}
+				}
+				items = append(items, aggregate(all))
+			}
+		}
+		rows = append(rows, items)
+	}
 
-			checkError(writer.Write(items))
+	if colTotal {
+		items = make([]string, nLeft, nLeft+(len(keys)+1)*nValues)
+		if nLeft > 0 {
+			items[0] = totalLabel
 		}
+		for i = 0; i < nValues; i++ {
+			for _, key := range keys {
+				all = all[:0]
+				for _, group := range groups {
+					if vals, ok = data[group][key]; ok {
+						all = append(all, vals[i]...)
+					}
+				}
+				items = append(items, aggregate(all))
+			}
+		}
+		if rowTotal {
+			for i = 0; i < nValues; i++ {
+				all = all[:0]
+				for _, group := range groups {
+					for _, key := range keys {
+						if vals, ok = data[group][key]; ok {
+							all = append(all, vals[i]...)
+						}
+					}
+				}
+				items = append(items, aggregate(all))
+			}
+		}
+		rows = append(rows, items)
+	}
+	return rows
+}
 
-		readerReport(&config, csvReader, file)
-	},
+// aggregateValues applies a summary operation on the values.
+func aggregateValues(op string, vals []string, ignore bool, decimalFormat string) (string, error) {
+	if fu2, ok := allStats2[op]; ok {
+		if len(vals) == 0 {
+			return "", nil
+		}
+		return fu2(vals), nil
+	}
+
+	nums := make([]float64, 0, len(vals))
+	for _, s := range vals {
+		if !reDigitals.MatchString(s) {
+			if ignore {
+				continue
+			}
+			return "", fmt.Errorf("non-numeric data found: %s, you can use flag -i/--ignore-non-numbers to skip these data", s)
+		}
+		v, err := strconv.ParseFloat(removeComma(s), 64)
+		if err != nil {
+			return "", err
+		}
+		nums = append(nums, v)
+	}
+	if summaryOpNeedSort(op) {
+		sort.Float64s(nums)
+	}
+	fu, _ := summaryOpFunc(op)
+	v := fu(nums)
+	if op == "countn" {
+		return fmt.Sprintf("%.0f", v), nil
+	}
+	return fmt.Sprintf(decimalFormat, v), nil
 }
 
 func init() {
 	RootCmd.AddCommand(spreadCmd)
 
 	spreadCmd.Flags().StringP("key", "k", "", `field of the key. e.g -k 1 or -k columnA`)
-	spreadCmd.Flags().StringP("value", "v", "", `field of the value. e.g -v 1 or -v columnA`)
+	spreadCmd.Flags().StringP("value", "v", "", `field(s) of the value. e.g -v 1 or -v columnA or -v columnA,columnB`)
 	spreadCmd.Flags().StringP("na", "", "", "content for filling NA data")
 	spreadCmd.Flags().StringP("separater", "s", "; ", "separater for values that share the same key")
+	spreadCmd.Flags().StringP("aggregate", "a", "", `aggregate values that share the same key with an operation of "csvtk summary", e.g., sum, mean, count, median, first`)
+	spreadCmd.Flags().BoolP("ignore-non-numbers", "i", false, `ignore non-numeric values like "NA" or "N/A" for numeric operations`)
+	spreadCmd.Flags().IntP("decimal-width", "w", 2, "limit floats to N decimal points")
+	spreadCmd.Flags().BoolP("row-total", "", false, "add a column of row totals")
+	spreadCmd.Flags().BoolP("col-total", "", false, "add a row of column totals")
+	spreadCmd.Flags().StringP("total-label", "", "Total", "label of totals")
+	spreadCmd.Flags().BoolP("sort-keys", "", false, "sort keys (new column names) in alphabetical order, rather than the order of appearance")
+	spreadCmd.Flags().BoolP("nat-sort", "S", false, "sort keys (new column names) in natural order")
+	spreadCmd.Flags().StringP("names-sep", "", "_", "separater between value column names and keys for multiple value columns")
 }
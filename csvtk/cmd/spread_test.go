@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregateValues(t *testing.T) {
+	separater = "; "
+	tests := []struct {
+		op       string
+		vals     []string
+		ignore   bool
+		expected string
+		hasError bool
+	}{
+		{"sum", []string{"1", "2.5", "1,000"}, false, "1003.50", false},
+		{"mean", []string{"1", "NA", "3"}, true, "2.00", false},
+		{"mean", []string{"1", "NA", "3"}, false, "", true},
+		{"countn", []string{"1", "NA", "3"}, true, "2", false},
+		{"count", []string{"1", "NA", "3"}, false, "3", false},
+		{"median", []string{"5", "1", "3"}, false, "3.00", false},
+		{"max", []string{"NA", "N/A"}, true, "NaN", false},
+		{"first", []string{"b", "a"}, false, "b", false},
+		{"collapse", []string{"b", "a"}, false, "b; a", false},
+		{"first", []string{}, false, "", false},
+	}
+	for i, test := range tests {
+		v, err := aggregateValues(test.op, test.vals, test.ignore, "%.2f")
+		if (err != nil) != test.hasError {
+			t.Errorf("case %d (%s): unexpected error: %v", i, test.op, err)
+			continue
+		}
+		if v != test.expected {
+			t.Errorf("case %d (%s): expected %q, returned %q", i, test.op, test.expected, v)
+		}
+	}
+}
+
+func TestSpreadRows(t *testing.T) {
+	// region -> month -> values of sales and qty
+	data := map[string]map[string][][]string{
+		"east": {
+			"Jan": {{"100", "30"}, {"1", "4"}},
+			"Feb": {{"NA"}, {"3"}},
+		},
+		"west": {
+			"Jan": {{"50"}, {"2"}},
+			"Mar": {{"5"}, {"NA"}},
+		},
+	}
+	groups := []string{"east", "west"}
+	keys := []string{"Jan", "Feb", "Mar"}
+	aggregate := func(op string) func([]string) string {
+		return func(vals []string) string {
+			v, err := aggregateValues(op, vals, true, "%.0f")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+	}
+
+	tests := []struct {
+		op                 string
+		nValues            int
+		rowTotal, colTotal bool
+		expected           [][]string
+	}{
+		{"sum", 1, false, false, [][]string{
+			{"east", "130", "NaN", "-"},
+			{"west", "50", "-", "5"},
+		}},
+		{"sum", 1, true, true, [][]string{
+			{"east", "130", "NaN", "-", "130"},
+			{"west", "50", "-", "5", "55"},
+			{"Total", "180", "NaN", "5", "185"},
+		}},
+		// NA values are ignored, and the totals are computed from all values
+		{"countn", 2, true, true, [][]string{
+			{"east", "2", "0", "-", "2", "1", "-", "2", "3"},
+			{"west", "1", "-", "1", "1", "-", "0", "2", "1"},
+			{"Total", "3", "0", "1", "3", "1", "0", "4", "4"},
+		}},
+		{"mean", 1, false, true, [][]string{
+			{"east", "65", "NaN", "-"},
+			{"west", "50", "-", "5"},
+			{"Total", "60", "NaN", "5"},
+		}},
+	}
+	for i, test := range tests {
+		rows := spreadRows(data, groups, keys, 1, test.nValues, "-",
+			test.rowTotal, test.colTotal, "Total", aggregate(test.op))
+		if !reflect.DeepEqual(rows, test.expected) {
+			t.Errorf("case %d (%s): expected %q, returned %q", i, test.op, test.expected, rows)
+		}
+	}
+
+	// no left columns
+	rows := spreadRows(map[string]map[string][][]string{"": {"a": {{"1"}}, "b": {{"2"}}}},
+		[]string{""}, []string{"a", "b"}, 0, 1, "", true, true, "Total", aggregate("sum"))
+	expected := [][]string{{"1", "2", "3"}, {"1", "2", "3"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("no left columns: expected %q, returned %q", expected, rows)
+	}
+}
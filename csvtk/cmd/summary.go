@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
@@ -49,6 +50,9 @@ var summaryCmd = &cobra.Command{
 Attention:
 
   1. Do not mix use field (column) numbers and names.
+  2. Extended field selectors are supported for both data and group fields,
+     e.g., -f @numeric:mean, -f '/^sample_/:sum', -f colB:colE:max.
+     Type "csvtk cut -h" for details.
 
 Available operations:
  
@@ -57,11 +61,38 @@ Available operations:
   countn (count numeric values), min, max, sum, argmin, argmax,
   mean, stdev, variance, median, q1, q2, q3,
   entropy (Shannon entropy), 
-  prod (product of the elements)
+  prod (product of the elements),
+  mode (the most frequent value, the smallest one for ties),
+  iqr (interquartile range, q3 - q1),
+  mad (median absolute deviation, not scaled),
+  skewness, kurtosis (excess kurtosis),
+  gmean (geometric mean), hmean (harmonic mean),
+  cv (coefficient of variation, stdev / mean)
+
+  # parameterised numeric operations
+  pN (the N-th percentile, 0 <= N <= 100), e.g., p90, p99.9
+  wmean@W (weighted mean with weights in the field W), e.g., wmean@weight
 
   # textual/numeric operations
   count, first, last, rand, unique/uniq, collapse, countunique
 
+Approximate mode (--approx):
+
+  All values of each group are kept in memory by default. In the approximate
+  mode, statistics are computed in one pass with bounded memory per group:
+
+  1. Quantiles (median, q1, q2, q3, pN, iqr) are estimated with KLL sketches,
+     with a normalized rank error of about 1.33% (99% confidence).
+     Values are exact for no more than 200 numeric values.
+  2. countunique is estimated with HyperLogLog, with a relative standard error
+     of 0.81%. Values are exact for no more than 65536 distinct values.
+  3. Other operations are computed exactly with streaming accumulators.
+  4. mode, mad, unique/uniq and collapse are not supported.
+
+  Use --approx-error to append a column of the error bound after each
+  approximate operation: the normalized rank error for quantiles, and
+  the relative standard error for countunique. 0 means the value is exact.
+
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		config := getConfigs(cmd)
@@ -81,6 +112,11 @@ Available operations:
 		}
 		seed := getFlagInt64(cmd, "rand-seed")
 		rand.Seed(seed)
+		approx := getFlagBool(cmd, "approx")
+		approxError := getFlagBool(cmd, "approx-error")
+		if approxError && !approx {
+			checkError(fmt.Errorf("flag --approx-error should be used along with --approx"))
+		}
 
 		ops := getFlagStringSlice(cmd, "fields")
 		if len(ops) == 0 {
@@ -91,6 +127,9 @@ Available operations:
 		statsList := make([][]string, 0, len(ops)) // [ [stats] ]
 		statsI := make(map[int][]string)           //  field -> [stats]
 
+		weightsIdx := make(map[string]int) // weight field -> index in fieldsStrsW
+		var fieldsStrsW []string
+
 		var fieldsStrsG []string
 		var fieldsStrsGMap map[string]struct{}
 		// var numFieldsG int
@@ -106,7 +145,7 @@ Available operations:
 		fieldsStrsD := []string{}
 		var numFieldsD int
 		for _, key := range ops {
-			items := strings.Split(key, ":")
+			items := splitSummaryOp(key)
 			if _, ok := fieldsStrsGMap[items[0]]; ok {
 				checkError(fmt.Errorf(`duplicated field in group field and data field: %s`, items[0]))
 			}
@@ -123,10 +162,23 @@ Available operations:
 				}
 				fieldsStrsD = append(fieldsStrsD, items[0])
 
-				_, ok1 := allStats[items[1]]  // for numbers
-				_, ok2 := allStats2[items[1]] // for strings
-				if !(ok1 || ok2) {
-					checkError(fmt.Errorf(`invalid operation: %s. run "csvtk summary --help" for help`, items[1]))
+				if _, ok := summaryOpsNotApprox[items[1]]; ok && approx {
+					checkError(fmt.Errorf(`operation not supported in the approximate mode: %s`, items[1]))
+				}
+				if w, ok := weightOfSummaryOp(items[1]); ok {
+					if w == "" {
+						checkError(fmt.Errorf(`weight field needed for operation: %s`, items[1]))
+					}
+					if _, ok = weightsIdx[w]; !ok {
+						weightsIdx[w] = len(fieldsStrsW)
+						fieldsStrsW = append(fieldsStrsW, w)
+					}
+				} else {
+					_, ok1 := summaryOpFunc(items[1]) // for numbers
+					_, ok2 := allStats2[items[1]]     // for strings
+					if !(ok1 || ok2) {
+						checkError(fmt.Errorf(`invalid operation: %s. run "csvtk summary --help" for help`, items[1]))
+					}
 				}
 				if _, ok := stats[items[0]]; !ok {
 					stats[items[0]] = make([]string, 0, 1)
@@ -144,16 +196,15 @@ Available operations:
 			fieldsStrsDMap[k] = struct{}{}
 		}
 
-		var tmp []string
-		if len(fieldsStrsG) > 0 {
-			tmp = append(fieldsStrsD, fieldsStrsG...)
-		} else {
-			tmp = fieldsStrsD
-		}
+		tmp := make([]string, 0, len(fieldsStrsD)+len(fieldsStrsG)+len(fieldsStrsW))
+		tmp = append(tmp, fieldsStrsD...)
+		tmp = append(tmp, fieldsStrsG...)
+		tmp = append(tmp, fieldsStrsW...)
+		numFieldsG := len(fieldsStrsG)
 
 		fieldsStr := strings.Join(tmp, ",")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -198,12 +249,31 @@ Available operations:
 		var HeaderRow []string
 
 		// group -> field -> data
-		data := make(map[string]map[int][]float64) // for numbers
-		data2 := make(map[string]map[int][]string) // for strings
+		data := make(map[string]map[int][]float64)           // for numbers
+		data2 := make(map[string]map[int][]string)           // for strings
+		dataW := make(map[string]map[[2]int]*weightedValues) // for weighted operations, group -> [field, weight field] -> data
+		dataA := make(map[string]map[int]*streamingStats)    // for the approximate mode
+		var st *streamingStats
+		newStats := func(f int) *streamingStats {
+			var needDistinct, needQuantiles bool
+			for _, op := range statsI[f] {
+				if op == "countunique" || op == "countuniq" {
+					needDistinct = true
+				} else if summaryOpApproximate(op) {
+					needQuantiles = true
+				}
+			}
+			return newStreamingStats(needDistinct, needQuantiles)
+		}
 
 		fieldsG := []int{}
 		fieldsD := []int{}
 		fieldsDUniq := []int{}
+		fieldsW := []int{}
+		var pairsW [][2]int // unique pairs of data field and weight field
+		var pairW [2]int
+		var wv *weightedValues
+		var w float64
 		var f int
 		var v float64
 		var e error
@@ -222,10 +292,48 @@ Available operations:
 			if checkFirstLine {
 				checkFirstLine = false
 
-				fieldsD = record.Fields[:numFieldsD]
-				fieldsG = record.Fields[numFieldsD:]
+				if record.SelectorFields != nil { // extended field selectors
+					fieldsD = make([]int, 0, numFieldsD)
+					statsList2 := make([][]string, 0, numFieldsD)
+					for i, ss := range statsList {
+						for _, f = range record.SelectorFields[i] {
+							fieldsD = append(fieldsD, f)
+							statsList2 = append(statsList2, ss)
+						}
+					}
+					statsList = statsList2
+					fieldsG = make([]int, 0, len(fieldsStrsG))
+					for _, fs := range record.SelectorFields[numFieldsD : numFieldsD+numFieldsG] {
+						fieldsG = append(fieldsG, fs...)
+					}
+					fieldsW = make([]int, 0, len(fieldsStrsW))
+					for i, fs := range record.SelectorFields[numFieldsD+numFieldsG:] {
+						if len(fs) != 1 {
+							checkError(fmt.Errorf("a weight field should match exactly one column: %s", fieldsStrsW[i]))
+						}
+						fieldsW = append(fieldsW, fs[0])
+					}
+					if len(fieldsD) == 0 {
+						checkError(fmt.Errorf("no columns matched by the data fields: %s", strings.Join(fieldsStrsD, ", ")))
+					}
+				} else {
+					fieldsD = record.Fields[:numFieldsD]
+					fieldsG = record.Fields[numFieldsD : len(record.Fields)-len(fieldsStrsW)]
+					fieldsW = record.Fields[len(record.Fields)-len(fieldsStrsW):]
+				}
+
+				pairsMap := make(map[[2]int]struct{}, len(fieldsStrsW))
+				for i, ss := range statsList {
+					if w, ok := weightOfSummaryOp(ss[1]); ok {
+						pairW = [2]int{fieldsD[i], fieldsW[weightsIdx[w]]}
+						if _, ok = pairsMap[pairW]; !ok {
+							pairsMap[pairW] = struct{}{}
+							pairsW = append(pairsW, pairW)
+						}
+					}
+				}
 
-				fieldsDUniq = UniqInts(fieldsD)
+				fieldsDUniq = UniqInts(append([]int{}, fieldsD...)) // UniqInts sorts the list in place
 
 				for i, f := range fieldsD {
 					if _, ok = statsI[f]; !ok {
@@ -242,23 +350,32 @@ Available operations:
 				}
 			}
 
-			group = strings.Join(record.Selected[numFieldsD:], "_shenwei356_")
-			if _, ok = data[group]; !ok {
+			group = groupKey(record.All, fieldsG)
+			if approx {
+				if _, ok = dataA[group]; !ok {
+					dataA[group] = make(map[int]*streamingStats, len(fieldsDUniq))
+				}
+			} else if _, ok = data[group]; !ok {
 				data[group] = make(map[int][]float64, 1024)
 			}
-			if _, ok = data2[group]; !ok {
+			if _, ok = data2[group]; !ok && !approx {
 				data2[group] = make(map[int][]string, 1024)
 			}
 
 			for _, f = range fieldsDUniq {
-				if _, ok = data2[group][f]; !ok {
-					data2[group][f] = []string{}
+				if approx {
+					if st, ok = dataA[group][f]; !ok {
+						st = newStats(f)
+						dataA[group][f] = st
+					}
+					st.AddText(record.All[f-1])
+				} else {
+					data2[group][f] = append(data2[group][f], record.All[f-1])
 				}
-				data2[group][f] = append(data2[group][f], record.All[f-1])
 
 				needParseDigits = false
 				for _, op := range statsI[f] {
-					if _, ok = allStats[op]; ok {
+					if _, ok = summaryOpFunc(op); ok {
 						needParseDigits = true
 						break
 					}
@@ -275,12 +392,43 @@ Available operations:
 				}
 				v, e = strconv.ParseFloat(removeComma(record.All[f-1]), 64)
 				checkError(e)
+				if approx {
+					st.AddNumber(v)
+					continue
+				}
 				if _, ok = data[group][f]; !ok {
 					data[group][f] = []float64{}
 				}
 				data[group][f] = append(data[group][f], v)
 			}
 
+			for _, pairW = range pairsW {
+				if !reDigitals.MatchString(record.All[pairW[0]-1]) || !reDigitals.MatchString(record.All[pairW[1]-1]) {
+					if ignore {
+						continue
+					}
+					checkError(fmt.Errorf("column %d or %d has non-numeric data: %s, %s, you can use flag -i/--ignore-non-numbers to skip these data",
+						pairW[0], pairW[1], record.All[pairW[0]-1], record.All[pairW[1]-1]))
+				}
+				v, e = strconv.ParseFloat(removeComma(record.All[pairW[0]-1]), 64)
+				checkError(e)
+				w, e = strconv.ParseFloat(removeComma(record.All[pairW[1]-1]), 64)
+				checkError(e)
+				if _, ok = dataW[group]; !ok {
+					dataW[group] = make(map[[2]int]*weightedValues, len(pairsW))
+				}
+				if wv, ok = dataW[group][pairW]; !ok {
+					wv = &weightedValues{}
+					dataW[group][pairW] = wv
+				}
+				if approx {
+					wv.sumWX += w * v
+					wv.sumW += w
+					continue
+				}
+				wv.values = append(wv.values, v)
+				wv.weights = append(wv.weights, w)
+			}
 		}
 
 		readerReport(&config, csvReader, file)
@@ -296,15 +444,21 @@ Available operations:
 
 			for i, ss := range statsList {
 				record = append(record, HeaderRow[fieldsD[i]-1]+":"+ss[1])
+				if approxError && summaryOpApproximate(ss[1]) {
+					record = append(record, HeaderRow[fieldsD[i]-1]+":"+ss[1]+":error")
+				}
 			}
 
 			writer.Write(record)
 		}
 
-		groups := make([]string, 0, len(data)+len(data2))
+		groups := make([]string, 0, len(data)+len(dataA))
 		for group := range data {
 			groups = append(groups, group)
 		}
+		for group := range dataA {
+			groups = append(groups, group)
+		}
 		sort.Strings(groups)
 
 		var fu func([]float64) float64
@@ -319,24 +473,33 @@ Available operations:
 				s := ss[1]
 				f := fieldsD[i]
 
-				sorted := false
-				if _, ok = allStats[s]; !ok {
+				if wname, isW := weightOfSummaryOp(s); isW {
+					wv = dataW[group][[2]int{f, fieldsW[weightsIdx[wname]]}]
+					if wv == nil {
+						record = append(record, fmt.Sprintf(decimalFormat, math.NaN()))
+					} else {
+						record = append(record, fmt.Sprintf(decimalFormat, wv.Mean()))
+					}
+				} else if approx {
+					st = dataA[group][f]
+					if _, ok = summaryOpFunc(s); !ok {
+						record = append(record, st.Text(s))
+					} else if s == "countn" {
+						record = append(record, fmt.Sprintf("%.0f", st.Number(s)))
+					} else {
+						record = append(record, fmt.Sprintf(decimalFormat, st.Number(s)))
+					}
+					if approxError && summaryOpApproximate(s) {
+						record = append(record, fmt.Sprintf("%.4f", st.Error(s)))
+					}
+				} else if fu, ok = summaryOpFunc(s); !ok {
 					fu2 = allStats2[s]
 					record = append(record, fu2(data2[group][f]))
 				} else {
-					needSort := false
-					for _, s := range statsI[f] {
-						if s == "q1" || s == "q2" || s == "q3" || s == "median" {
-							needSort = true
-							break
-						}
-					}
-					if needSort && !sorted {
+					if summaryOpNeedSort(s) {
 						sort.Float64s(data[group][f])
-						sorted = true
 					}
 
-					fu = allStats[s]
 					if s == "countn" {
 						record = append(record, fmt.Sprintf("%.0f", fu(data[group][f])))
 					} else {
@@ -416,6 +579,56 @@ func init() {
 		}
 		return percentileValue(s, 0.75)
 	}
+	allStats["mode"] = func(s []float64) float64 {
+		if len(s) == 0 {
+			return math.NaN()
+		}
+		counts := make(map[float64]int, len(s))
+		for _, v := range s {
+			counts[v]++
+		}
+		var mode float64
+		var max int
+		for v, n := range counts {
+			if n > max || (n == max && v < mode) {
+				mode, max = v, n
+			}
+		}
+		return mode
+	}
+	allStats["iqr"] = func(s []float64) float64 {
+		if len(s) == 0 {
+			return math.NaN()
+		}
+		return percentileValue(s, 0.75) - percentileValue(s, 0.25)
+	}
+	allStats["mad"] = func(s []float64) float64 {
+		if len(s) == 0 {
+			return math.NaN()
+		}
+		m := median(s)
+		d := make([]float64, len(s))
+		for i, v := range s {
+			d[i] = math.Abs(v - m)
+		}
+		sort.Float64s(d)
+		return median(d)
+	}
+	allStats["skewness"] = func(s []float64) float64 { return stat.Skew(s, nil) }
+	allStats["kurtosis"] = func(s []float64) float64 { return stat.ExKurtosis(s, nil) }
+	allStats["gmean"] = func(s []float64) float64 {
+		if len(s) == 0 {
+			return math.NaN()
+		}
+		return stat.GeometricMean(s, nil)
+	}
+	allStats["hmean"] = func(s []float64) float64 {
+		if len(s) == 0 {
+			return math.NaN()
+		}
+		return stat.HarmonicMean(s, nil)
+	}
+	allStats["cv"] = func(s []float64) float64 { return stat.StdDev(s, nil) / stat.Mean(s, nil) }
 
 	allStats2 = make(map[string]func([]string) string)
 	allStats2["count"] = func(s []string) string { return fmt.Sprintf("%d", len(s)) }
@@ -464,6 +677,76 @@ func init() {
 	summaryCmd.Flags().IntP("decimal-width", "w", 2, "limit floats to N decimal points")
 	summaryCmd.Flags().StringP("separater", "s", "; ", "separater for collapsed data")
 	summaryCmd.Flags().Int64P("rand-seed", "S", 11, `rand seed for operation "rand"`)
+	summaryCmd.Flags().BoolP("approx", "", false, `approximate mode with bounded memory, see the usage for details`)
+	summaryCmd.Flags().BoolP("approx-error", "", false, `append a column of the error bound after each approximate operation, for --approx`)
+}
+
+// splitSummaryOp splits "field:operation" by the last colon,
+// so field selectors like "colA:colC" are kept.
+func splitSummaryOp(key string) []string {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return []string{key}
+	}
+	return []string{key[:i], key[i+1:]}
+}
+
+// weightedValues stores values and their weights for weighted operations.
+type weightedValues struct {
+	values  []float64
+	weights []float64
+
+	sumWX, sumW float64 // for the approximate mode
+}
+
+// Mean returns the weighted mean, from the running sums in the approximate mode.
+func (wv *weightedValues) Mean() float64 {
+	if wv.values == nil {
+		return wv.sumWX / wv.sumW
+	}
+	return stat.Mean(wv.values, wv.weights)
+}
+
+var reSummaryPercentile = regexp.MustCompile(`^p(\d+(\.\d+)?)$`)
+
+// summaryOpFunc returns the function of a numeric operation, including
+// parameterised ones like "p90". Functions needing sorted data are
+// listed in summaryOpNeedSort.
+func summaryOpFunc(op string) (func([]float64) float64, bool) {
+	if fu, ok := allStats[op]; ok {
+		return fu, true
+	}
+	found := reSummaryPercentile.FindStringSubmatch(op)
+	if found == nil {
+		return nil, false
+	}
+	p, err := strconv.ParseFloat(found[1], 64)
+	if err != nil || p > 100 {
+		return nil, false
+	}
+	return func(s []float64) float64 {
+		if len(s) == 0 {
+			return math.NaN()
+		}
+		return percentileValue(s, p/100)
+	}, true
+}
+
+// summaryOpNeedSort tells whether a numeric operation needs sorted data.
+func summaryOpNeedSort(op string) bool {
+	switch op {
+	case "q1", "q2", "q3", "median", "iqr", "mad":
+		return true
+	}
+	return reSummaryPercentile.MatchString(op)
+}
+
+// weightOfSummaryOp returns the weight field of a weighted operation, e.g., "wmean@weight".
+func weightOfSummaryOp(op string) (string, bool) {
+	if strings.HasPrefix(op, "wmean@") {
+		return op[6:], true
+	}
+	return "", false
 }
 
 func median(sorted []float64) float64 {
@@ -494,5 +777,8 @@ func percentileValue(sorted []float64, percentile float64) float64 {
 
 	h := float64(l-1) * percentile
 	fh := math.Floor(h)
+	if int(fh) == l-1 {
+		return sorted[l-1]
+	}
 	return sorted[int(fh)] + (h-fh)*(sorted[int(fh)+1]-sorted[int(fh)])
 }
@@ -0,0 +1,225 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// operations not supported in the approximate mode, as they need all values.
+var summaryOpsNotApprox = map[string]struct{}{
+	"mode":     {},
+	"mad":      {},
+	"uniq":     {},
+	"unique":   {},
+	"collapse": {},
+}
+
+// summaryOpApproximate tells whether an operation returns an approximate value
+// in the approximate mode.
+func summaryOpApproximate(op string) bool {
+	switch op {
+	case "countunique", "countuniq", "median", "q1", "q2", "q3", "iqr":
+		return true
+	}
+	return reSummaryPercentile.MatchString(op)
+}
+
+// streamingStats computes summary statistics of a column in one pass with bounded memory.
+// Quantiles are estimated with a KLL sketch, and the number of distinct values
+// is estimated with HyperLogLog. Others are computed exactly.
+type streamingStats struct {
+	count       int64 // number of all values
+	first, last string
+	rand        string // a random value, by reservoir sampling
+	distinct    *distinctCounter
+
+	n              int64 // number of numeric values
+	sum, prod      float64
+	min, max       float64
+	argmin, argmax int64
+	mean           float64
+	m2, m3, m4     float64 // sums of powers of differences from the mean
+	sumLog, sumInv float64
+	entropy        float64
+	quantiles      *kllSketch
+}
+
+func newStreamingStats(needDistinct bool, needQuantiles bool) *streamingStats {
+	s := &streamingStats{prod: 1}
+	if needDistinct {
+		s.distinct = newDistinctCounter(maxExactDistinct)
+	}
+	if needQuantiles {
+		s.quantiles = newKLLSketch(kllK)
+	}
+	return s
+}
+
+// AddText adds a value for textual operations.
+func (s *streamingStats) AddText(v string) {
+	s.count++
+	if s.count == 1 {
+		s.first = v
+	}
+	s.last = v
+	if rand.Int63n(s.count) == 0 {
+		s.rand = v
+	}
+	if s.distinct != nil {
+		s.distinct.Add(v)
+	}
+}
+
+// AddNumber adds a value for numeric operations.
+func (s *streamingStats) AddNumber(x float64) {
+	n1 := float64(s.n)
+	s.n++
+	n := float64(s.n)
+
+	if s.n == 1 || x < s.min {
+		s.min, s.argmin = x, s.n
+	}
+	if s.n == 1 || x > s.max {
+		s.max, s.argmax = x, s.n
+	}
+	s.sum += x
+	s.prod *= x
+	s.sumLog += math.Log(x)
+	s.sumInv += 1 / x
+	if x != 0 {
+		s.entropy -= x * math.Log(x)
+	}
+
+	// online updates of central moments, Terriberry (2007)
+	delta := x - s.mean
+	deltaN := delta / n
+	deltaN2 := deltaN * deltaN
+	term1 := delta * deltaN * n1
+	s.mean += deltaN
+	s.m4 += term1*deltaN2*(n*n-3*n+3) + 6*deltaN2*s.m2 - 4*deltaN*s.m3
+	s.m3 += term1*deltaN*(n-2) - 3*deltaN*s.m2
+	s.m2 += term1
+
+	if s.quantiles != nil {
+		s.quantiles.Add(x)
+	}
+}
+
+// Number returns the value of a numeric operation.
+func (s *streamingStats) Number(op string) float64 {
+	if op == "countn" {
+		return float64(s.n)
+	}
+	if op == "prod" {
+		return s.prod
+	}
+	if op == "entropy" {
+		return s.entropy
+	}
+	if s.n == 0 {
+		return math.NaN()
+	}
+
+	n := float64(s.n)
+	variance := s.m2 / (n - 1)
+	std := math.Sqrt(variance)
+	switch op {
+	case "sum":
+		return s.sum
+	case "min":
+		return s.min
+	case "max":
+		return s.max
+	case "argmin":
+		return float64(s.argmin)
+	case "argmax":
+		return float64(s.argmax)
+	case "mean":
+		return s.mean
+	case "variance":
+		return variance
+	case "stdev":
+		return std
+	case "cv":
+		return std / s.mean
+	case "skewness":
+		return s.m3 / (std * std * std) * (n / (n - 1)) / (n - 2)
+	case "kurtosis":
+		e := s.m4 / (variance * variance)
+		return e*((n+1)/(n-1))*(n/(n-2))/(n-3) - 3*((n-1)/(n-2))*((n-1)/(n-3))
+	case "gmean":
+		return math.Exp(s.sumLog / n)
+	case "hmean":
+		return n / s.sumInv
+	case "median", "q2":
+		return s.quantiles.Quantile(0.5)
+	case "q1":
+		return s.quantiles.Quantile(0.25)
+	case "q3":
+		return s.quantiles.Quantile(0.75)
+	case "iqr":
+		return s.quantiles.Quantile(0.75) - s.quantiles.Quantile(0.25)
+	}
+	if found := reSummaryPercentile.FindStringSubmatch(op); found != nil {
+		p, _ := strconv.ParseFloat(found[1], 64)
+		return s.quantiles.Quantile(p / 100)
+	}
+	return math.NaN()
+}
+
+// Text returns the value of a textual operation.
+func (s *streamingStats) Text(op string) string {
+	switch op {
+	case "count":
+		return fmt.Sprintf("%d", s.count)
+	case "first":
+		return s.first
+	case "last":
+		return s.last
+	case "rand":
+		return s.rand
+	case "countunique", "countuniq":
+		n, _ := s.distinct.Count()
+		return fmt.Sprintf("%d", n)
+	}
+	return ""
+}
+
+// Error returns the error bound of an approximate operation:
+// the relative standard error for countunique, and
+// the normalized rank error for quantiles. 0 means the value is exact.
+func (s *streamingStats) Error(op string) float64 {
+	switch op {
+	case "countunique", "countuniq":
+		if _, exact := s.distinct.Count(); exact {
+			return 0
+		}
+		return s.distinct.hll.RelativeError()
+	}
+	if s.quantiles == nil {
+		return 0
+	}
+	return s.quantiles.RankError()
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestSummaryOps(t *testing.T) {
+	data := []float64{7, 1, 3, 3, 10, 2, 3, 8}
+	tests := []struct {
+		op       string
+		expected float64
+	}{
+		{"p0", 1},
+		{"p25", 2.75},
+		{"p50", 3},
+		{"p90", 8.6},
+		{"p99.5", 9.93},
+		{"p100", 10},
+		{"iqr", 4.5},
+		{"mad", 1.5},
+		{"mode", 3},
+		{"median", 3},
+	}
+	for _, test := range tests {
+		fu, ok := summaryOpFunc(test.op)
+		if !ok {
+			t.Errorf("operation %s: not recognised", test.op)
+			continue
+		}
+		s := append([]float64{}, data...)
+		if summaryOpNeedSort(test.op) {
+			sort.Float64s(s)
+		}
+		if v := fu(s); math.Abs(v-test.expected) > 1e-9 {
+			t.Errorf("operation %s: expected %v, returned %v", test.op, test.expected, v)
+		}
+		if v := fu(nil); !math.IsNaN(v) {
+			t.Errorf("operation %s on empty data: expected NaN, returned %v", test.op, v)
+		}
+	}
+
+	for _, op := range []string{"p101", "p", "px", "p-1"} {
+		if _, ok := summaryOpFunc(op); ok {
+			t.Errorf("operation %s: should be invalid", op)
+		}
+	}
+
+	// ties of mode are broken by the smaller value
+	fu, _ := summaryOpFunc("mode")
+	if v := fu([]float64{5, 2, 5, 2, 9}); v != 2 {
+		t.Errorf("mode with ties: expected 2, returned %v", v)
+	}
+}
+
+func TestSummaryWeightedMean(t *testing.T) {
+	if w, ok := weightOfSummaryOp("wmean@weight"); !ok || w != "weight" {
+		t.Errorf("weight field of wmean@weight: returned %q, %v", w, ok)
+	}
+	if _, ok := weightOfSummaryOp("mean"); ok {
+		t.Errorf("mean should not be a weighted operation")
+	}
+
+	values, weights := []float64{1, 2, 4}, []float64{3, 1, 1}
+	wv := &weightedValues{values: values, weights: weights}
+	wvA := &weightedValues{}
+	for i, v := range values {
+		wvA.sumWX += weights[i] * v
+		wvA.sumW += weights[i]
+	}
+	for _, w := range []*weightedValues{wv, wvA} {
+		if v := w.Mean(); math.Abs(v-1.8) > 1e-9 {
+			t.Errorf("weighted mean: expected 1.8, returned %v", v)
+		}
+	}
+}
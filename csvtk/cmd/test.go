@@ -0,0 +1,727 @@
+// Copyright © 2019 Oxford Nanopore Technologies.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	GroupID: "info",
+
+	Use:   "test",
+	Short: "statistical tests comparing groups",
+	Long: `statistical tests comparing groups
+
+Values of the data fields (-f/--fields) are compared between groups
+defined by the group field (-g/--group-field). Each data field is tested
+separately, and two-sample tests are performed for all pairs of groups
+if there are more than two groups.
+
+Methods (-m/--method):
+
+  Numeric data, non-numeric values are ignored:
+    welch        Welch's t-test (unequal variances)
+    student      Student's t-test (equal variances)
+    mannwhitney  Mann-Whitney U test (Wilcoxon rank-sum test), normal
+                 approximation with tie and continuity corrections
+    anova        one-way ANOVA, for all groups
+    kruskal      Kruskal-Wallis H test, for all groups
+
+  Categorical data, a contingency table of the data field and the group field:
+    chisq        Pearson's chi-squared test, with Yates' continuity correction
+                 for 2x2 tables
+    fisher       Fisher's exact test, for 2x2 tables only
+
+Output columns:
+  field       data field
+  groups      groups compared, separated by ";"
+  method      method
+  n           number of values
+  statistic   t for t-tests, W (U of the first group) for mannwhitney,
+              F for anova, H for kruskal, X-squared for chisq,
+              and empty for fisher
+  df          degrees of freedom, between groups for anova
+  pvalue      two-sided p-value
+  estimate    difference of means for t-tests, odds ratio for fisher
+  ci_lower    lower bound of the confidence interval of the estimate
+  ci_upper    upper bound of the confidence interval of the estimate
+  effect      type of effect size:
+                cohen_d (pooled standard deviation) for t-tests,
+                rank_biserial for mannwhitney, eta_squared for anova,
+                epsilon_squared for kruskal, cramer_v for chisq and fisher
+  effect_size value of the effect size
+  padj        adjusted p-value, with -a/--adjust
+
+Empty cells mean not applicable or not computable.
+
+Multiple-testing correction (-a/--adjust), across all tests of the output:
+  bonferroni  Bonferroni correction
+  bh          Benjamini-Hochberg procedure (false discovery rate)
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		if len(files) > 1 {
+			checkError(fmt.Errorf("no more than one file should be given"))
+		}
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		fieldStr := getFlagString(cmd, "fields")
+		if fieldStr == "" {
+			checkError(fmt.Errorf("flag -f/--fields needed"))
+		}
+		groupStr := getFlagString(cmd, "group-field")
+		if groupStr == "" {
+			checkError(fmt.Errorf("flag -g/--group-field needed"))
+		}
+
+		method := strings.ToLower(getFlagString(cmd, "method"))
+		var categorical bool
+		switch method {
+		case "welch", "student", "anova", "kruskal":
+		case "mannwhitney", "mwu", "wilcoxon":
+			method = "mannwhitney"
+		case "chisq", "fisher":
+			categorical = true
+		default:
+			checkError(fmt.Errorf("invalid value of flag -m/--method: %s, available: welch, student, mannwhitney, anova, kruskal, chisq, fisher", method))
+		}
+
+		adjust := strings.ToLower(getFlagString(cmd, "adjust"))
+		switch adjust {
+		case "none", "bonferroni", "bh":
+		case "fdr":
+			adjust = "bh"
+		default:
+			checkError(fmt.Errorf("invalid value of flag -a/--adjust: %s, available: none, bonferroni, bh", adjust))
+		}
+
+		confLevel := getFlagPositiveFloat64(cmd, "conf-level")
+		if confLevel >= 1 {
+			checkError(fmt.Errorf("the value of flag --conf-level should be in range of (0, 1)"))
+		}
+
+		decimalWidth := getFlagNonNegativeInt(cmd, "decimal-width")
+		decimalFormat := fmt.Sprintf("%%.%df", decimalWidth)
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+		defer outfh.Close()
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+		defer func() {
+			writer.Flush()
+			checkError(writer.Error())
+		}()
+
+		file := files[0]
+
+		csvReader, err := newCSVReaderByConfig(config, file)
+
+		if err != nil {
+			if err == xopen.ErrNoContent {
+				if config.Verbose {
+					log.Warningf("csvtk test: skipping empty input file: %s", file)
+				}
+				return
+			}
+			checkError(err)
+		}
+
+		csvReader.Read(ReadOption{
+			FieldStr: fieldStr + "," + groupStr,
+
+			DoNotAllowDuplicatedColumnName: true,
+		})
+
+		var fields []int
+		var fieldG int
+		var names []string
+
+		// for numeric data: field -> group -> values
+		var numbers []map[string][]float64
+		// for categorical data: field -> value -> group -> count
+		var counts []map[string]map[string]float64
+		var values []*categoryOrder // values of data fields, in order of appearance
+		groupOrder := newCategoryOrder()
+
+		var i, f int
+		var g, v string
+		var val float64
+		var ok bool
+		checkFirstLine := true
+		for record := range csvReader.Ch {
+			if record.Err != nil {
+				checkError(record.Err)
+			}
+
+			if checkFirstLine {
+				checkFirstLine = false
+
+				if len(record.Fields) < 2 {
+					checkError(fmt.Errorf("data fields and a group field needed"))
+				}
+				fields = record.Fields[:len(record.Fields)-1]
+				fieldG = record.Fields[len(record.Fields)-1]
+
+				names = make([]string, len(fields))
+				numbers = make([]map[string][]float64, len(fields))
+				counts = make([]map[string]map[string]float64, len(fields))
+				values = make([]*categoryOrder, len(fields))
+				for i = range fields {
+					numbers[i] = make(map[string][]float64)
+					counts[i] = make(map[string]map[string]float64)
+					values[i] = newCategoryOrder()
+				}
+
+				if !config.NoHeaderRow || record.IsHeaderRow { // do not replace head line
+					for i, f = range fields {
+						names[i] = record.All[f-1]
+					}
+					continue
+				}
+				for i, f = range fields {
+					names[i] = strconv.Itoa(f)
+				}
+			}
+
+			g = record.All[fieldG-1]
+			groupOrder.Add(g)
+			for i, f = range fields {
+				v = record.All[f-1]
+				if categorical {
+					values[i].Add(v)
+					if _, ok = counts[i][v]; !ok {
+						counts[i][v] = make(map[string]float64)
+					}
+					counts[i][v][g]++
+					continue
+				}
+
+				val, err = strconv.ParseFloat(removeComma(v), 64)
+				if err != nil || math.IsNaN(val) {
+					continue
+				}
+				numbers[i][g] = append(numbers[i][g], val)
+			}
+		}
+
+		readerReport(&config, csvReader, file)
+
+		groups := groupOrder.Keys()
+		if len(groups) < 2 {
+			checkError(fmt.Errorf("at least two groups needed, %d found", len(groups)))
+		}
+
+		results := make([]*testResult, 0, len(fields))
+		var r *testResult
+		for i = range fields {
+			switch method {
+			case "welch", "student", "mannwhitney":
+				for a := 0; a < len(groups); a++ {
+					for b := a + 1; b < len(groups); b++ {
+						x, y := numbers[i][groups[a]], numbers[i][groups[b]]
+						if method == "mannwhitney" {
+							r = mannWhitneyTest(x, y)
+						} else {
+							r = tTest(x, y, method == "welch", confLevel)
+						}
+						r.field, r.method = names[i], method
+						r.groups = []string{groups[a], groups[b]}
+						results = append(results, r)
+					}
+				}
+				continue
+			case "anova", "kruskal":
+				samples := make([][]float64, len(groups))
+				for j, group := range groups {
+					samples[j] = numbers[i][group]
+				}
+				if method == "anova" {
+					r = anovaTest(samples)
+				} else {
+					r = kruskalTest(samples)
+				}
+			case "chisq", "fisher":
+				rows := values[i].Keys()
+				table := make([][]float64, len(rows))
+				for j, row := range rows {
+					table[j] = make([]float64, len(groups))
+					for k, group := range groups {
+						table[j][k] = counts[i][row][group]
+					}
+				}
+				if method == "chisq" {
+					r = chisqTest(table)
+				} else {
+					if len(rows) != 2 || len(groups) != 2 {
+						checkError(fmt.Errorf("fisher's exact test only supports 2x2 tables, but the table of field %s is %dx%d", names[i], len(rows), len(groups)))
+					}
+					r = fisherTest(table, confLevel)
+				}
+			}
+			r.field, r.method = names[i], method
+			r.groups = groups
+			results = append(results, r)
+		}
+
+		var padj []float64
+		if adjust != "none" {
+			pvalues := make([]float64, len(results))
+			for j, r := range results {
+				pvalues[j] = r.pvalue
+			}
+			padj = adjustPValues(pvalues, adjust)
+		}
+
+		// output
+		formatValue := func(v float64) string {
+			if math.IsNaN(v) {
+				return ""
+			}
+			return fmt.Sprintf(decimalFormat, v)
+		}
+
+		if !config.NoOutHeader {
+			header := []string{"field", "groups", "method", "n", "statistic", "df", "pvalue",
+				"estimate", "ci_lower", "ci_upper", "effect", "effect_size"}
+			if adjust != "none" {
+				header = append(header, "padj")
+			}
+			checkError(writer.Write(header))
+		}
+		row := make([]string, 0, 13)
+		for j, r := range results {
+			row = row[:0]
+			row = append(row, r.field, strings.Join(r.groups, ";"), r.method,
+				strconv.Itoa(r.n), formatValue(r.statistic), formatValue(r.df),
+				formatPValue(r.pvalue), formatValue(r.estimate),
+				formatValue(r.ciLower), formatValue(r.ciUpper),
+				r.effect, formatValue(r.effectSize))
+			if adjust != "none" {
+				row = append(row, formatPValue(padj[j]))
+			}
+			checkError(writer.Write(row))
+		}
+	},
+}
+
+// categoryOrder records categories in order of appearance.
+type categoryOrder struct {
+	keys []string
+	m    map[string]struct{}
+}
+
+func newCategoryOrder() *categoryOrder {
+	return &categoryOrder{m: make(map[string]struct{})}
+}
+
+// Add adds a category.
+func (c *categoryOrder) Add(key string) {
+	if _, ok := c.m[key]; ok {
+		return
+	}
+	c.m[key] = struct{}{}
+	c.keys = append(c.keys, key)
+}
+
+// Keys returns categories in order of appearance.
+func (c *categoryOrder) Keys() []string {
+	return c.keys
+}
+
+// testResult is the result of a statistical test,
+// NaN means not applicable.
+type testResult struct {
+	field  string
+	groups []string
+	method string
+
+	n          int
+	statistic  float64
+	df         float64
+	pvalue     float64
+	estimate   float64
+	ciLower    float64
+	ciUpper    float64
+	effect     string
+	effectSize float64
+}
+
+func newTestResult(n int) *testResult {
+	nan := math.NaN()
+	return &testResult{n: n, statistic: nan, df: nan, pvalue: nan,
+		estimate: nan, ciLower: nan, ciUpper: nan, effectSize: nan}
+}
+
+// tTest performs Welch's or Student's t-test.
+func tTest(x, y []float64, welch bool, confLevel float64) *testResult {
+	n1, n2 := float64(len(x)), float64(len(y))
+	r := newTestResult(len(x) + len(y))
+	r.effect = "cohen_d"
+	if len(x) < 2 || len(y) < 2 {
+		return r
+	}
+
+	m1, v1 := stat.MeanVariance(x, nil)
+	m2, v2 := stat.MeanVariance(y, nil)
+	sp := math.Sqrt(((n1-1)*v1 + (n2-1)*v2) / (n1 + n2 - 2)) // pooled standard deviation
+
+	var se, df float64
+	if welch {
+		s1, s2 := v1/n1, v2/n2
+		se = math.Sqrt(s1 + s2)
+		df = (s1 + s2) * (s1 + s2) / (s1*s1/(n1-1) + s2*s2/(n2-1))
+	} else {
+		se = sp * math.Sqrt(1/n1+1/n2)
+		df = n1 + n2 - 2
+	}
+
+	diff := m1 - m2
+	t := diff / se
+	dist := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}
+	q := dist.Quantile(1 - (1-confLevel)/2)
+
+	r.statistic = t
+	r.df = df
+	r.pvalue = 2 * dist.Survival(math.Abs(t))
+	r.estimate = diff
+	r.ciLower = diff - q*se
+	r.ciUpper = diff + q*se
+	r.effectSize = diff / sp
+	return r
+}
+
+// tieCorrection returns the sum of (t^3 - t) of all ties in ranked values.
+func tieCorrection(x []float64) float64 {
+	sorted := append([]float64{}, x...)
+	sort.Float64s(sorted)
+	var s, t float64
+	var j int
+	for i := 0; i < len(sorted); i = j {
+		for j = i + 1; j < len(sorted) && sorted[j] == sorted[i]; j++ {
+		}
+		t = float64(j - i)
+		s += t*t*t - t
+	}
+	return s
+}
+
+// mannWhitneyTest performs the Mann-Whitney U test.
+func mannWhitneyTest(x, y []float64) *testResult {
+	n1, n2 := float64(len(x)), float64(len(y))
+	r := newTestResult(len(x) + len(y))
+	r.effect = "rank_biserial"
+	if len(x) == 0 || len(y) == 0 {
+		return r
+	}
+
+	all := make([]float64, 0, len(x)+len(y))
+	all = append(all, x...)
+	all = append(all, y...)
+	ranks := fractionalRanks(all)
+	var r1 float64
+	for _, v := range ranks[:len(x)] {
+		r1 += v
+	}
+	u := r1 - n1*(n1+1)/2
+
+	n := n1 + n2
+	mu := n1 * n2 / 2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - tieCorrection(all)/(n*(n-1))))
+	z := u - mu
+	if z > 0 {
+		z -= 0.5
+	} else if z < 0 {
+		z += 0.5
+	}
+	z /= sigma
+
+	r.statistic = u
+	if sigma > 0 {
+		r.pvalue = 2 * distuv.UnitNormal.Survival(math.Abs(z))
+	}
+	r.effectSize = 2*u/(n1*n2) - 1
+	return r
+}
+
+// anovaTest performs one-way analysis of variance.
+func anovaTest(samples [][]float64) *testResult {
+	var n int
+	var sum float64
+	for _, s := range samples {
+		n += len(s)
+		for _, v := range s {
+			sum += v
+		}
+	}
+	r := newTestResult(n)
+	r.effect = "eta_squared"
+
+	k := 0
+	for _, s := range samples {
+		if len(s) > 0 {
+			k++
+		}
+	}
+	if k < 2 || n <= k {
+		return r
+	}
+	mean := sum / float64(n)
+
+	var ssb, ssw float64
+	var m, d float64
+	for _, s := range samples {
+		if len(s) == 0 {
+			continue
+		}
+		m = stat.Mean(s, nil)
+		ssb += float64(len(s)) * (m - mean) * (m - mean)
+		for _, v := range s {
+			d = v - m
+			ssw += d * d
+		}
+	}
+	df1, df2 := float64(k-1), float64(n-k)
+	f := (ssb / df1) / (ssw / df2)
+
+	r.statistic = f
+	r.df = df1
+	r.pvalue = distuv.F{D1: df1, D2: df2}.Survival(f)
+	r.effectSize = ssb / (ssb + ssw)
+	return r
+}
+
+// kruskalTest performs the Kruskal-Wallis H test.
+func kruskalTest(samples [][]float64) *testResult {
+	all := make([]float64, 0, 1024)
+	k := 0
+	for _, s := range samples {
+		all = append(all, s...)
+		if len(s) > 0 {
+			k++
+		}
+	}
+	r := newTestResult(len(all))
+	r.effect = "epsilon_squared"
+	if k < 2 {
+		return r
+	}
+	n := float64(len(all))
+
+	ranks := fractionalRanks(all)
+	var h, sum float64
+	var start int
+	for _, s := range samples {
+		if len(s) == 0 {
+			continue
+		}
+		sum = 0
+		for _, v := range ranks[start : start+len(s)] {
+			sum += v
+		}
+		h += sum * sum / float64(len(s))
+		start += len(s)
+	}
+	h = 12/(n*(n+1))*h - 3*(n+1)
+	h /= 1 - tieCorrection(all)/(n*n*n-n)
+
+	df := float64(k - 1)
+	r.statistic = h
+	r.df = df
+	r.pvalue = distuv.ChiSquared{K: df}.Survival(h)
+	r.effectSize = h / (n - 1)
+	return r
+}
+
+// contingencyMargins returns row sums, column sums and the total of a table.
+func contingencyMargins(table [][]float64) ([]float64, []float64, float64) {
+	rows := make([]float64, len(table))
+	cols := make([]float64, len(table[0]))
+	var n float64
+	for i, row := range table {
+		for j, v := range row {
+			rows[i] += v
+			cols[j] += v
+			n += v
+		}
+	}
+	return rows, cols, n
+}
+
+// cramerV computes Cramér's V from the chi-squared statistic without correction.
+func cramerV(table [][]float64) float64 {
+	rows, cols, n := contingencyMargins(table)
+	var x2, e, d float64
+	for i, row := range table {
+		for j, v := range row {
+			e = rows[i] * cols[j] / n
+			d = v - e
+			x2 += d * d / e
+		}
+	}
+	k := math.Min(float64(len(rows)), float64(len(cols)))
+	return math.Sqrt(x2 / (n * (k - 1)))
+}
+
+// chisqTest performs Pearson's chi-squared test of independence,
+// Yates' continuity correction is applied for 2x2 tables.
+func chisqTest(table [][]float64) *testResult {
+	rows, cols, n := contingencyMargins(table)
+	r := newTestResult(int(n))
+	r.effect = "cramer_v"
+	if len(rows) < 2 {
+		return r
+	}
+
+	yates := len(rows) == 2 && len(cols) == 2
+	var x2, e, d float64
+	for i, row := range table {
+		for j, v := range row {
+			e = rows[i] * cols[j] / n
+			d = math.Abs(v - e)
+			if yates {
+				d -= math.Min(0.5, d)
+			}
+			x2 += d * d / e
+		}
+	}
+	df := float64((len(rows) - 1) * (len(cols) - 1))
+
+	r.statistic = x2
+	r.df = df
+	r.pvalue = distuv.ChiSquared{K: df}.Survival(x2)
+	r.effectSize = cramerV(table)
+	return r
+}
+
+// fisherTest performs Fisher's exact test on a 2x2 table.
+// The sample odds ratio is reported, with Woolf's confidence interval,
+// where 0.5 is added to all cells if any cell is zero.
+func fisherTest(table [][]float64, confLevel float64) *testResult {
+	a, b, c, d := table[0][0], table[0][1], table[1][0], table[1][1]
+	n := a + b + c + d
+	r := newTestResult(int(n))
+	r.effect = "cramer_v"
+
+	lchoose := func(n, k float64) float64 {
+		x, _ := math.Lgamma(n + 1)
+		y, _ := math.Lgamma(k + 1)
+		z, _ := math.Lgamma(n - k + 1)
+		return x - y - z
+	}
+	r1, c1 := a+b, a+c
+	lp := func(x float64) float64 {
+		return lchoose(c1, x) + lchoose(n-c1, r1-x) - lchoose(n, r1)
+	}
+
+	// sum of probabilities of tables no more likely than the observed one
+	pObs := lp(a)
+	var p float64
+	for x := math.Max(0, r1+c1-n); x <= math.Min(r1, c1); x++ {
+		if v := lp(x); v <= pObs+1e-7 {
+			p += math.Exp(v)
+		}
+	}
+	r.pvalue = math.Min(1, p)
+
+	if a == 0 || b == 0 || c == 0 || d == 0 {
+		a, b, c, d = a+0.5, b+0.5, c+0.5, d+0.5
+	}
+	or := a * d / (b * c)
+	se := math.Sqrt(1/a + 1/b + 1/c + 1/d)
+	z := distuv.UnitNormal.Quantile(1 - (1-confLevel)/2)
+	r.estimate = or
+	r.ciLower = math.Exp(math.Log(or) - z*se)
+	r.ciUpper = math.Exp(math.Log(or) + z*se)
+	if n > 0 {
+		r.effectSize = cramerV(table)
+	}
+	return r
+}
+
+// adjustPValues adjusts p-values for multiple testing,
+// NaNs are kept and not counted.
+func adjustPValues(pvalues []float64, method string) []float64 {
+	adjusted := make([]float64, len(pvalues))
+	idx := make([]int, 0, len(pvalues))
+	for i, p := range pvalues {
+		adjusted[i] = math.NaN()
+		if !math.IsNaN(p) {
+			idx = append(idx, i)
+		}
+	}
+	m := float64(len(idx))
+
+	switch method {
+	case "bonferroni":
+		for _, i := range idx {
+			adjusted[i] = math.Min(1, pvalues[i]*m)
+		}
+	case "bh":
+		sort.Slice(idx, func(a, b int) bool { return pvalues[idx[a]] > pvalues[idx[b]] })
+		min := 1.0
+		var p float64
+		for k, i := range idx {
+			p = pvalues[i] * m / (m - float64(k))
+			if p < min {
+				min = p
+			}
+			adjusted[i] = min
+		}
+	}
+	return adjusted
+}
+
+func init() {
+	RootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().StringP("fields", "f", "", "data fields, multiple values are tested separately")
+	testCmd.Flags().StringP("group-field", "g", "", "group field")
+	testCmd.Flags().StringP("method", "m", "welch", "method: welch, student, mannwhitney, anova, kruskal, chisq, or fisher")
+	testCmd.Flags().StringP("adjust", "a", "none", "p-value adjustment for multiple testing: none, bonferroni, or bh")
+	testCmd.Flags().Float64P("conf-level", "", 0.95, "confidence level of confidence intervals")
+	testCmd.Flags().IntP("decimal-width", "w", 4, "limit floats to N decimal points")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAdjustPValues(t *testing.T) {
+	pvalues := []float64{0.01, 0.04, math.NaN(), 0.03, 0.5}
+	tests := []struct {
+		method string
+		want   []float64
+	}{
+		{"bonferroni", []float64{0.04, 0.16, math.NaN(), 0.12, 1}},
+		{"bh", []float64{0.04, 0.04 * 4 / 3, math.NaN(), 0.04 * 4 / 3, 0.5}},
+	}
+	for _, test := range tests {
+		got := adjustPValues(pvalues, test.method)
+		for i, v := range got {
+			if math.IsNaN(test.want[i]) {
+				if !math.IsNaN(v) {
+					t.Errorf("%s: %d: expected NaN, returned %f", test.method, i, v)
+				}
+				continue
+			}
+			if math.Abs(v-test.want[i]) > 1e-9 {
+				t.Errorf("%s: %d: expected %f, returned %f", test.method, i, test.want[i], v)
+			}
+		}
+	}
+}
+
+func TestStatTests(t *testing.T) {
+	// the sleep data in R
+	x := []float64{0.7, -1.6, -0.2, -1.2, -0.1, 3.4, 3.7, 0.8, 0.0, 2.0}
+	y := []float64{1.9, 0.8, 1.1, 0.1, -0.1, 4.4, 5.5, 1.6, 4.6, 3.4}
+
+	tests := []struct {
+		name   string
+		result *testResult
+		pvalue float64
+	}{
+		{"welch", tTest(x, y, true, 0.95), 0.07939},
+		{"student", tTest(x, y, false, 0.95), 0.07919},
+		{"mannwhitney", mannWhitneyTest(x, y), 0.06933},
+		{"anova", anovaTest([][]float64{x, y}), 0.07919},
+		{"fisher", fisherTest([][]float64{{3, 1}, {1, 3}}, 0.95), 0.4857},
+		{"chisq", chisqTest([][]float64{{3, 1}, {1, 3}}), 0.4795},
+	}
+	for _, test := range tests {
+		if math.Abs(test.result.pvalue-test.pvalue) > 1e-4 {
+			t.Errorf("%s: expected p-value %f, returned %f", test.name, test.pvalue, test.result.pvalue)
+		}
+	}
+}
@@ -78,7 +78,7 @@ Example:
 			checkError(fmt.Errorf("flag -s (--separater) needed"))
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
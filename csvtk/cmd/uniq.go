@@ -57,7 +57,7 @@ var uniqCmd = &cobra.Command{
 		ignoreCase := getFlagBool(cmd, "ignore-case")
 		keepN := getFlagPositiveInt(cmd, "keep-n")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
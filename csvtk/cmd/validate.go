@@ -0,0 +1,897 @@
+// Copyright © 2016-2023 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/shenwei356/xopen"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	GroupID: "info",
+
+	Use:   "validate",
+	Short: "validate CSV/TSV files with a table schema",
+	Long: `validate CSV/TSV files with a table schema
+
+The schema follows the Frictionless Table Schema (https://specs.frictionlessdata.io/table-schema/),
+in JSON (.json) or the equivalent YAML format (other suffixes).
+
+    {
+      "fields": [
+        {"name": "id", "type": "integer", "constraints": {"required": true, "unique": true}},
+        {"name": "email", "type": "string", "constraints": {"pattern": "[^@]+@[^@]+"}},
+        {"name": "age", "type": "integer", "constraints": {"minimum": 0, "maximum": 150}},
+        {"name": "level", "type": "string", "constraints": {"enum": ["low", "high"]}},
+        {"name": "joined", "type": "date", "format": "%Y-%m-%d"},
+        {"name": "team", "type": "string"}
+      ],
+      "missingValues": ["", "NA"],
+      "primaryKey": ["id"],
+      "foreignKeys": [
+        {"fields": ["team"], "reference": {"resource": "teams.csv", "fields": ["name"]}}
+      ]
+    }
+
+Supported field types:
+
+  string, integer, number, boolean, date, datetime, time, year, any
+
+  Formats of date/datetime/time: "default" (ISO 8601), "any" (any layout
+  supported by https://github.com/araddon/dateparse), strptime-like patterns
+  (e.g., "%Y-%m-%d %H:%M:%S"), or Go layouts reported by "csvtk schema".
+
+Supported constraints:
+
+  required, unique, pattern, enum, minimum, maximum, minLength, maxLength
+
+Checks of the header row:
+
+  1. All columns in the schema should exist, in the same order.
+     Use --ignore-order to allow different orders.
+     An empty file fails with an error of missing-label for each column.
+  2. Extra columns are not allowed, unless --allow-extra-cols is given.
+
+Primary keys:
+
+  Values of all fields of the primary key are required, and the
+  combinations should be unique.
+
+Foreign keys:
+
+  The resource is a CSV/TSV file, relative paths are relative to the schema file.
+  An empty resource ("") refers to the input file itself.
+  Referenced files are read with the same global flags, e.g., -t, -d.
+
+Output:
+
+  Each error is reported as a record with the columns: file, row, column,
+  value, error, and message. The row is 0 for errors in the header row.
+  Error types: missing-label, extra-label, incorrect-label, type-error,
+  constraint-error, unique-error, primary-key-error, foreign-key-error.
+
+  The exit status is 1 if any error is found.
+
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := getConfigs(cmd)
+		files := getFileListFromArgsAndFile(cmd, args, true, "infile-list", true)
+		runtime.GOMAXPROCS(config.NumCPUs)
+
+		schemaFile := getFlagString(cmd, "schema")
+		if schemaFile == "" {
+			checkError(fmt.Errorf("flag --schema needed"))
+		}
+		ignoreOrder := getFlagBool(cmd, "ignore-order")
+		allowExtra := getFlagBool(cmd, "allow-extra-cols")
+		maxErrors := getFlagNonNegativeInt(cmd, "max-errors")
+		format := strings.ToLower(getFlagString(cmd, "format"))
+		switch format {
+		case "csv", "json":
+		default:
+			checkError(fmt.Errorf("invalid value of flag --format: %s. available: csv, json", format))
+		}
+
+		schema, err := readTableSchema(schemaFile)
+		checkError(err)
+
+		outfh, err := wopenByConfig(config, config.OutFile)
+		checkError(err)
+
+		writer := csv.NewWriter(outfh)
+		if config.OutTabs || config.Tabs {
+			if config.OutDelimiter == ',' {
+				writer.Comma = '\t'
+			} else {
+				writer.Comma = config.OutDelimiter
+			}
+		} else {
+			writer.Comma = config.OutDelimiter
+		}
+		if format == "csv" && !config.NoOutHeader {
+			checkError(writer.Write([]string{"file", "row", "column", "value", "error", "message"}))
+		}
+
+		var nErrors int
+		report := func(e validationError) bool {
+			nErrors++
+			if format == "json" {
+				data, err := json.Marshal(e)
+				checkError(err)
+				outfh.Write(data)
+				outfh.WriteString("\n")
+			} else {
+				checkError(writer.Write([]string{e.File, strconv.Itoa(e.Row), e.Column, e.Value, e.Error, e.Message}))
+			}
+			return maxErrors > 0 && nErrors >= maxErrors
+		}
+
+		// values of referenced fields in other files
+		refValues := make([]map[string]struct{}, len(schema.ForeignKeys))
+		for i, fk := range schema.ForeignKeys {
+			if fk.Reference.Resource == "" {
+				continue
+			}
+			refValues[i], err = readReferencedValues(config, fk.Reference.resourcePath, fk.Reference.Fields)
+			checkError(err)
+		}
+
+		for _, file := range files {
+			v := newTableValidator(schema, file, ignoreOrder, allowExtra)
+			if v.validate(config, refValues, report) {
+				break
+			}
+		}
+
+		writer.Flush()
+		checkError(writer.Error())
+		checkError(outfh.Close())
+
+		if nErrors > 0 {
+			if config.Verbose {
+				log.Warningf("%d errors found", nErrors)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+// validationError is an error found in validation
+type validationError struct {
+	File    string `json:"file"`
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Value   string `json:"value"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// tableSchema is a Frictionless Table Schema
+type tableSchema struct {
+	Fields        []*schemaField      `json:"fields" yaml:"fields"`
+	MissingValues []string            `json:"missingValues" yaml:"missingValues"`
+	PrimaryKey    stringOrList        `json:"primaryKey" yaml:"primaryKey"`
+	ForeignKeys   []*schemaForeignKey `json:"foreignKeys" yaml:"foreignKeys"`
+
+	missingValues map[string]struct{}
+}
+
+type schemaField struct {
+	Name        string             `json:"name" yaml:"name"`
+	Type        string             `json:"type" yaml:"type"`
+	Format      string             `json:"format" yaml:"format"`
+	TrueValues  []string           `json:"trueValues" yaml:"trueValues"`
+	FalseValues []string           `json:"falseValues" yaml:"falseValues"`
+	Constraints *schemaConstraints `json:"constraints" yaml:"constraints"`
+
+	layout      string // Go time layout, "" for default ISO 8601, "any" for any layout
+	re          *regexp.Regexp
+	enum        map[string]struct{}
+	min, max    *schemaValue
+	trueValues  map[string]struct{}
+	falseValues map[string]struct{}
+}
+
+type schemaConstraints struct {
+	Required  bool          `json:"required" yaml:"required"`
+	Unique    bool          `json:"unique" yaml:"unique"`
+	Pattern   string        `json:"pattern" yaml:"pattern"`
+	Enum      []interface{} `json:"enum" yaml:"enum"`
+	Minimum   interface{}   `json:"minimum" yaml:"minimum"`
+	Maximum   interface{}   `json:"maximum" yaml:"maximum"`
+	MinLength *int          `json:"minLength" yaml:"minLength"`
+	MaxLength *int          `json:"maxLength" yaml:"maxLength"`
+}
+
+type schemaForeignKey struct {
+	Fields    stringOrList `json:"fields" yaml:"fields"`
+	Reference struct {
+		Resource string       `json:"resource" yaml:"resource"`
+		Fields   stringOrList `json:"fields" yaml:"fields"`
+
+		resourcePath string
+	} `json:"reference" yaml:"reference"`
+}
+
+// stringOrList is a string or a list of strings
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err == nil {
+		*s = []string{v}
+		return nil
+	}
+	var vs []string
+	if err := json.Unmarshal(data, &vs); err != nil {
+		return err
+	}
+	*s = vs
+	return nil
+}
+
+func (s *stringOrList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = []string{node.Value}
+		return nil
+	}
+	var vs []string
+	if err := node.Decode(&vs); err != nil {
+		return err
+	}
+	*s = vs
+	return nil
+}
+
+// schemaValue is a parsed value for comparison
+type schemaValue struct {
+	num float64
+	t   time.Time
+}
+
+func readTableSchema(file string) (*tableSchema, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read schema file: %s", err)
+	}
+	schema := new(tableSchema)
+	if strings.HasSuffix(strings.ToLower(file), ".json") {
+		err = json.Unmarshal(data, schema)
+	} else {
+		err = yaml.Unmarshal(data, schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse schema file %s: %s", file, err)
+	}
+	if len(schema.Fields) == 0 {
+		return nil, fmt.Errorf("no fields found in schema file: %s", file)
+	}
+
+	if schema.MissingValues == nil {
+		schema.MissingValues = []string{""}
+	}
+	schema.missingValues = make(map[string]struct{}, len(schema.MissingValues))
+	for _, v := range schema.MissingValues {
+		schema.missingValues[v] = struct{}{}
+	}
+
+	names := make(map[string]struct{}, len(schema.Fields))
+	for _, f := range schema.Fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("field name missing in schema file: %s", file)
+		}
+		if _, ok := names[f.Name]; ok {
+			return nil, fmt.Errorf("duplicated field in schema file: %s", f.Name)
+		}
+		names[f.Name] = struct{}{}
+		if err = f.prepare(); err != nil {
+			return nil, fmt.Errorf("field %s: %s", f.Name, err)
+		}
+	}
+
+	for _, k := range schema.PrimaryKey {
+		if _, ok := names[k]; !ok {
+			return nil, fmt.Errorf("primary key field not defined in schema: %s", k)
+		}
+	}
+	dir := filepath.Dir(file)
+	for _, fk := range schema.ForeignKeys {
+		if len(fk.Fields) == 0 || len(fk.Fields) != len(fk.Reference.Fields) {
+			return nil, fmt.Errorf("foreign key fields and reference fields should have the same length")
+		}
+		for _, k := range fk.Fields {
+			if _, ok := names[k]; !ok {
+				return nil, fmt.Errorf("foreign key field not defined in schema: %s", k)
+			}
+		}
+		if fk.Reference.Resource == "" {
+			for _, k := range fk.Reference.Fields {
+				if _, ok := names[k]; !ok {
+					return nil, fmt.Errorf("foreign key reference field not defined in schema: %s", k)
+				}
+			}
+		} else if filepath.IsAbs(fk.Reference.Resource) || isStdin(fk.Reference.Resource) {
+			fk.Reference.resourcePath = fk.Reference.Resource
+		} else {
+			fk.Reference.resourcePath = filepath.Join(dir, fk.Reference.Resource)
+		}
+	}
+	return schema, nil
+}
+
+var strptimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'b': "Jan", 'B': "January",
+	'a': "Mon", 'A': "Monday", 'H': "15", 'I': "03", 'p': "PM", 'M': "04",
+	'S': "05", 'f': "000000", 'z': "-0700", 'Z': "MST", 'j': "002", '%': "%",
+}
+
+// strptime2layout converts a strptime-like pattern to a Go time layout.
+func strptime2layout(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		if i == len(pattern) {
+			return "", fmt.Errorf("invalid format: %s", pattern)
+		}
+		s, ok := strptimeDirectives[pattern[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c in format: %s", pattern[i], pattern)
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+func (f *schemaField) prepare() error {
+	if f.Type == "" {
+		f.Type = "string"
+	}
+	switch f.Type {
+	case "string", "integer", "number", "boolean", "date", "datetime", "time", "year", "any":
+	default:
+		return fmt.Errorf("unsupported type: %s", f.Type)
+	}
+
+	switch f.Type {
+	case "date", "datetime", "time":
+		var err error
+		switch {
+		case f.Format == "" || f.Format == "default":
+			switch f.Type {
+			case "date":
+				f.layout = "2006-01-02"
+			case "datetime":
+				f.layout = time.RFC3339
+			case "time":
+				f.layout = "15:04:05"
+			}
+		case f.Format == "any":
+			f.layout = "any"
+		case strings.Contains(f.Format, "%"):
+			if f.layout, err = strptime2layout(f.Format); err != nil {
+				return err
+			}
+		default:
+			f.layout = f.Format
+		}
+	case "boolean":
+		if f.TrueValues == nil {
+			f.TrueValues = []string{"true", "True", "TRUE", "1"}
+		}
+		if f.FalseValues == nil {
+			f.FalseValues = []string{"false", "False", "FALSE", "0"}
+		}
+		f.trueValues = make(map[string]struct{}, len(f.TrueValues))
+		for _, v := range f.TrueValues {
+			f.trueValues[v] = struct{}{}
+		}
+		f.falseValues = make(map[string]struct{}, len(f.FalseValues))
+		for _, v := range f.FalseValues {
+			f.falseValues[v] = struct{}{}
+		}
+	}
+
+	c := f.Constraints
+	if c == nil {
+		f.Constraints = &schemaConstraints{}
+		return nil
+	}
+	var err error
+	if c.Pattern != "" {
+		if f.re, err = regexp.Compile("^(?:" + c.Pattern + ")$"); err != nil {
+			return fmt.Errorf("invalid pattern: %s", err)
+		}
+	}
+	if c.Enum != nil {
+		f.enum = make(map[string]struct{}, len(c.Enum))
+		for _, v := range c.Enum {
+			f.enum[constraintString(v)] = struct{}{}
+		}
+	}
+	if c.Minimum != nil {
+		if f.min, err = f.parse(constraintString(c.Minimum)); err != nil {
+			return fmt.Errorf("invalid minimum: %v", c.Minimum)
+		}
+	}
+	if c.Maximum != nil {
+		if f.max, err = f.parse(constraintString(c.Maximum)); err != nil {
+			return fmt.Errorf("invalid maximum: %v", c.Maximum)
+		}
+	}
+	return nil
+}
+
+// constraintString formats a value of constraints decoded from JSON/YAML.
+// Numbers are not formatted in the exponent format, e.g., 1000000 rather than 1e+06.
+func constraintString(v interface{}) string {
+	switch x := v.(type) {
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case json.Number:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// parse checks the type of a value, and returns the parsed value for comparison.
+func (f *schemaField) parse(s string) (*schemaValue, error) {
+	switch f.Type {
+	case "integer", "year":
+		if !reInteger.MatchString(s) || strings.Contains(s, ",") {
+			return nil, fmt.Errorf("not an integer")
+		}
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not an integer")
+		}
+		if f.Type == "year" && (len(strings.TrimLeft(s, "+-")) != 4) {
+			return nil, fmt.Errorf("not a year")
+		}
+		return &schemaValue{num: float64(v)}, nil
+	case "number":
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number")
+		}
+		return &schemaValue{num: v}, nil
+	case "boolean":
+		if _, ok := f.trueValues[s]; ok {
+			return &schemaValue{num: 1}, nil
+		}
+		if _, ok := f.falseValues[s]; ok {
+			return &schemaValue{num: 0}, nil
+		}
+		return nil, fmt.Errorf("not a boolean")
+	case "date", "datetime", "time":
+		var t time.Time
+		var err error
+		if f.layout == "any" {
+			t, err = dateparse.ParseAny(s)
+		} else {
+			t, err = time.Parse(f.layout, s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a %s in format %s", f.Type, f.layout)
+		}
+		return &schemaValue{t: t}, nil
+	}
+	return nil, nil
+}
+
+func (f *schemaField) compare(a, b *schemaValue) int {
+	switch f.Type {
+	case "date", "datetime", "time":
+		if a.t.Before(b.t) {
+			return -1
+		} else if a.t.After(b.t) {
+			return 1
+		}
+		return 0
+	}
+	if a.num < b.num {
+		return -1
+	} else if a.num > b.num {
+		return 1
+	}
+	return 0
+}
+
+// readReferencedValues reads values of the referenced fields in a file,
+// values of multiple fields are joined.
+func readReferencedValues(config Config, file string, fields []string) (map[string]struct{}, error) {
+	headerRow, data, csvReader, err := readCSV(config, file)
+	if err != nil {
+		return nil, fmt.Errorf("read referenced file %s: %s", file, err)
+	}
+	readerReport(&config, csvReader, file)
+
+	idx := make([]int, len(fields))
+	for i, k := range fields {
+		idx[i] = -1
+		for j, col := range headerRow {
+			if col == k {
+				idx[i] = j
+				break
+			}
+		}
+		if idx[i] < 0 {
+			return nil, fmt.Errorf(`column "%s" not existed in referenced file: %s`, k, file)
+		}
+	}
+
+	values := make(map[string]struct{}, len(data))
+	items := make([]string, len(idx))
+	for _, row := range data {
+		for i, j := range idx {
+			items[i] = row[j]
+		}
+		values[strings.Join(items, "\x00")] = struct{}{}
+	}
+	return values, nil
+}
+
+type tableValidator struct {
+	schema      *tableSchema
+	file        string
+	ignoreOrder bool
+	allowExtra  bool
+}
+
+func newTableValidator(schema *tableSchema, file string, ignoreOrder bool, allowExtra bool) *tableValidator {
+	return &tableValidator{schema: schema, file: file, ignoreOrder: ignoreOrder, allowExtra: allowExtra}
+}
+
+// validate validates a file, and returns true if the maximum number of errors is reached.
+func (v *tableValidator) validate(config Config, refValues []map[string]struct{},
+	report func(validationError) bool) bool {
+
+	schema := v.schema
+	file := v.file
+
+	csvReader, err := newCSVReaderByConfig(config, file)
+	if err != nil {
+		if err == xopen.ErrNoContent {
+			return v.reportNoHeader(report)
+		}
+		checkError(err)
+	}
+	if config.NoHeaderRow && config.Verbose {
+		log.Warningf("flag -H (--no-header-row) ignored, the header row is needed for validation")
+	}
+	csvReader.NoHeaderRow = false
+
+	csvReader.Read(ReadOption{
+		FieldStr: "1-",
+	})
+	defer readerReport(&config, csvReader, file)
+
+	// index of schema fields in the file
+	idx := make([]int, len(schema.Fields))
+	name2idx := make(map[string]int, len(schema.Fields))
+
+	uniques := make([]map[string]int, len(schema.Fields))
+	for i, f := range schema.Fields {
+		if f.Constraints.Unique {
+			uniques[i] = make(map[string]int, 1024)
+		}
+	}
+	var pkIdx []int
+	var pkValues map[string]int
+	if len(schema.PrimaryKey) > 0 {
+		pkValues = make(map[string]int, 1024)
+	}
+	// values of self-referenced fields, and values to check at the end
+	selfRefs := make(map[int]map[string]struct{}, len(schema.ForeignKeys))
+	type pendingValue struct {
+		row   int
+		value string
+	}
+	selfRefPending := make(map[int][]pendingValue, len(schema.ForeignKeys))
+	fkIdx := make([][]int, len(schema.ForeignKeys))
+	refIdx := make([][]int, len(schema.ForeignKeys))
+
+	var f *schemaField
+	var val string
+	var i, j int
+	var ok, stop bool
+	var pv *schemaValue
+	items := make([]string, 0, 8)
+	missing := make([]string, 0, 8) // missing fields of the primary key
+	joinValues := func(record []string, fields []int) (string, bool) {
+		items = items[:0]
+		allMissing := true
+		for _, j := range fields {
+			if j < 0 || j >= len(record) {
+				items = append(items, "")
+				continue
+			}
+			if _, ok := schema.missingValues[record[j]]; !ok {
+				allMissing = false
+			}
+			items = append(items, record[j])
+		}
+		return strings.Join(items, "\x00"), allMissing
+	}
+
+	newError := func(row int, column, value, errType, msg string) bool {
+		return report(validationError{File: file, Row: row, Column: column, Value: value, Error: errType, Message: msg})
+	}
+
+	checkFirstLine := true
+	for record := range csvReader.Ch {
+		if record.Err != nil {
+			checkError(record.Err)
+		}
+
+		if checkFirstLine {
+			checkFirstLine = false
+
+			colnames := make(map[string]int, len(record.All))
+			for j, col := range record.All {
+				if _, ok = colnames[col]; !ok {
+					colnames[col] = j
+				}
+			}
+			for i, f = range schema.Fields {
+				if j, ok = colnames[f.Name]; !ok {
+					idx[i] = -1
+					if newError(0, f.Name, "", "missing-label", "column not found") {
+						return true
+					}
+				} else {
+					idx[i] = j
+				}
+				name2idx[f.Name] = idx[i]
+			}
+			if !v.allowExtra {
+				for _, col := range record.All {
+					if _, ok = name2idx[col]; !ok {
+						if newError(0, col, "", "extra-label", "column not defined in schema") {
+							return true
+						}
+					}
+				}
+			}
+			if !v.ignoreOrder {
+				last := -1
+				for i, f = range schema.Fields {
+					if idx[i] < 0 {
+						continue
+					}
+					if idx[i] < last {
+						if newError(0, f.Name, "", "incorrect-label",
+							fmt.Sprintf("column should be in the position %d", i+1)) {
+							return true
+						}
+					}
+					last = idx[i]
+				}
+			}
+
+			pkIdx = make([]int, len(schema.PrimaryKey))
+			for i, k := range schema.PrimaryKey {
+				pkIdx[i] = name2idx[k]
+			}
+			for i, fk := range schema.ForeignKeys {
+				fkIdx[i] = make([]int, len(fk.Fields))
+				for j, k := range fk.Fields {
+					fkIdx[i][j] = name2idx[k]
+				}
+				if fk.Reference.Resource == "" {
+					refIdx[i] = make([]int, len(fk.Reference.Fields))
+					for j, k := range fk.Reference.Fields {
+						refIdx[i][j] = name2idx[k]
+					}
+					selfRefs[i] = make(map[string]struct{}, 1024)
+				}
+			}
+			continue
+		}
+
+		for i, f = range schema.Fields {
+			if idx[i] < 0 {
+				continue
+			}
+			if idx[i] < len(record.All) {
+				val = record.All[idx[i]]
+			} else {
+				val = ""
+			}
+
+			if _, ok = schema.missingValues[val]; ok {
+				if f.Constraints.Required {
+					if newError(record.Row, f.Name, val, "constraint-error", "required value missing") {
+						return true
+					}
+				}
+				continue
+			}
+
+			if pv, err = f.parse(val); err != nil {
+				if newError(record.Row, f.Name, val, "type-error", fmt.Sprintf("type %s expected: %s", f.Type, err)) {
+					return true
+				}
+				continue
+			}
+
+			if stop = v.checkConstraints(f, val, pv, record.Row, newError); stop {
+				return true
+			}
+
+			if uniques[i] != nil {
+				if j, ok = uniques[i][val]; ok {
+					if newError(record.Row, f.Name, val, "unique-error", fmt.Sprintf("duplicated value, first seen in row %d", j)) {
+						return true
+					}
+				} else {
+					uniques[i][val] = record.Row
+				}
+			}
+		}
+
+		if pkValues != nil {
+			key, _ := joinValues(record.All, pkIdx)
+			column := strings.Join(schema.PrimaryKey, ",")
+			value := strings.ReplaceAll(key, "\x00", ",")
+			missing = missing[:0]
+			for i, j = range pkIdx {
+				if j < 0 { // the column is reported as missing-label
+					continue
+				}
+				if j >= len(record.All) {
+					missing = append(missing, schema.PrimaryKey[i])
+				} else if _, ok = schema.missingValues[record.All[j]]; ok {
+					missing = append(missing, schema.PrimaryKey[i])
+				}
+			}
+			if len(missing) > 0 {
+				if newError(record.Row, strings.Join(missing, ","), value, "primary-key-error", "primary key missing") {
+					return true
+				}
+			} else if j, ok = pkValues[key]; ok {
+				if newError(record.Row, column, value, "primary-key-error", fmt.Sprintf("duplicated primary key, first seen in row %d", j)) {
+					return true
+				}
+			} else {
+				pkValues[key] = record.Row
+			}
+		}
+
+		for i, fk := range schema.ForeignKeys {
+			if selfRefs[i] != nil {
+				key, allMissing := joinValues(record.All, refIdx[i])
+				if !allMissing {
+					selfRefs[i][key] = struct{}{}
+				}
+			}
+			key, allMissing := joinValues(record.All, fkIdx[i])
+			if allMissing {
+				continue
+			}
+			if selfRefs[i] != nil {
+				selfRefPending[i] = append(selfRefPending[i], pendingValue{row: record.Row, value: key})
+				continue
+			}
+			if _, ok = refValues[i][key]; !ok {
+				if newError(record.Row, strings.Join(fk.Fields, ","), strings.ReplaceAll(key, "\x00", ","), "foreign-key-error",
+					fmt.Sprintf("value not found in %s of %s", strings.Join(fk.Reference.Fields, ","), fk.Reference.Resource)) {
+					return true
+				}
+			}
+		}
+	}
+
+	if checkFirstLine { // no header row, e.g., only comment lines
+		return v.reportNoHeader(report)
+	}
+
+	for i, fk := range schema.ForeignKeys {
+		for _, p := range selfRefPending[i] {
+			if _, ok = selfRefs[i][p.value]; !ok {
+				if newError(p.row, strings.Join(fk.Fields, ","), strings.ReplaceAll(p.value, "\x00", ","), "foreign-key-error",
+					fmt.Sprintf("value not found in %s", strings.Join(fk.Reference.Fields, ","))) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// reportNoHeader reports all columns in the schema as missing for a file
+// without the header row, e.g., an empty file.
+func (v *tableValidator) reportNoHeader(report func(validationError) bool) bool {
+	for _, f := range v.schema.Fields {
+		if report(validationError{File: v.file, Column: f.Name, Error: "missing-label", Message: "column not found, no header row"}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *tableValidator) checkConstraints(f *schemaField, val string, pv *schemaValue, row int,
+	newError func(int, string, string, string, string) bool) bool {
+
+	c := f.Constraints
+	if f.re != nil && !f.re.MatchString(val) {
+		if newError(row, f.Name, val, "constraint-error", fmt.Sprintf("value not matching pattern: %s", c.Pattern)) {
+			return true
+		}
+	}
+	if f.enum != nil {
+		if _, ok := f.enum[val]; !ok {
+			if newError(row, f.Name, val, "constraint-error", "value not in enum") {
+				return true
+			}
+		}
+	}
+	if f.min != nil && pv != nil && f.compare(pv, f.min) < 0 {
+		if newError(row, f.Name, val, "constraint-error", fmt.Sprintf("value less than minimum: %v", c.Minimum)) {
+			return true
+		}
+	}
+	if f.max != nil && pv != nil && f.compare(pv, f.max) > 0 {
+		if newError(row, f.Name, val, "constraint-error", fmt.Sprintf("value greater than maximum: %v", c.Maximum)) {
+			return true
+		}
+	}
+	if c.MinLength != nil || c.MaxLength != nil {
+		n := len([]rune(val))
+		if c.MinLength != nil && n < *c.MinLength {
+			if newError(row, f.Name, val, "constraint-error", fmt.Sprintf("length less than minLength: %d", *c.MinLength)) {
+				return true
+			}
+		}
+		if c.MaxLength != nil && n > *c.MaxLength {
+			if newError(row, f.Name, val, "constraint-error", fmt.Sprintf("length greater than maxLength: %d", *c.MaxLength)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringP("schema", "s", "", "table schema file, in JSON (.json) or YAML format")
+	validateCmd.Flags().BoolP("ignore-order", "", false, "allow columns in different orders from the schema")
+	validateCmd.Flags().BoolP("allow-extra-cols", "", false, "allow columns not defined in the schema")
+	validateCmd.Flags().IntP("max-errors", "m", 0, "stop after N errors, 0 for no limit")
+	validateCmd.Flags().StringP("format", "", "csv", `output format: csv, json (one JSON object per line)`)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStrptime2layout(t *testing.T) {
+	cases := []struct {
+		s      string
+		expect string
+	}{
+		{"%Y-%m-%d", "2006-01-02"},
+		{"%d/%b/%Y %H:%M:%S", "02/Jan/2006 15:04:05"},
+		{"%Y%%", "2006%"},
+	}
+	for _, c := range cases {
+		layout, err := strptime2layout(c.s)
+		if err != nil {
+			t.Errorf("convert %s: %s", c.s, err)
+			continue
+		}
+		if layout != c.expect {
+			t.Errorf("convert %s: expected %s, returned %s", c.s, c.expect, layout)
+		}
+	}
+	if _, err := strptime2layout("%Q"); err == nil {
+		t.Errorf("convert %%Q: error expected")
+	}
+}
+
+func TestSchemaFieldParse(t *testing.T) {
+	cases := []struct {
+		field *schemaField
+		value string
+		ok    bool
+	}{
+		{&schemaField{Type: "integer"}, "-12", true},
+		{&schemaField{Type: "integer"}, "1,200", false},
+		{&schemaField{Type: "integer"}, "1.5", false},
+		{&schemaField{Type: "number"}, "1e-3", true},
+		{&schemaField{Type: "number"}, "abc", false},
+		{&schemaField{Type: "boolean"}, "TRUE", true},
+		{&schemaField{Type: "boolean"}, "yes", false},
+		{&schemaField{Type: "boolean", TrueValues: []string{"yes"}}, "yes", true},
+		{&schemaField{Type: "date"}, "2024-02-29", true},
+		{&schemaField{Type: "date"}, "2023-02-29", false},
+		{&schemaField{Type: "date", Format: "%d/%m/%Y"}, "29/02/2024", true},
+		{&schemaField{Type: "datetime", Format: "any"}, "2024-02-29 10:00", true},
+		{&schemaField{Type: "year"}, "2024", true},
+		{&schemaField{Type: "year"}, "24", false},
+		{&schemaField{Type: "string"}, "anything", true},
+	}
+	for _, c := range cases {
+		if err := c.field.prepare(); err != nil {
+			t.Errorf("prepare field of type %s: %s", c.field.Type, err)
+			continue
+		}
+		_, err := c.field.parse(c.value)
+		if (err == nil) != c.ok {
+			t.Errorf("parse %s as %s (%s): expected %v, returned %v", c.value, c.field.Type, c.field.Format, c.ok, err == nil)
+		}
+	}
+}
+
+func TestSchemaFieldConstraints(t *testing.T) {
+	var f schemaField
+	data := `{"name": "n", "type": "integer",
+		"constraints": {"minimum": -5, "maximum": 1000000, "enum": [1, 1000000, 25000000]}}`
+	if err := json.Unmarshal([]byte(data), &f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.prepare(); err != nil {
+		t.Fatalf("prepare: %s", err)
+	}
+	for _, v := range []string{"1", "1000000", "25000000"} {
+		if _, ok := f.enum[v]; !ok {
+			t.Errorf("enum value %s expected", v)
+		}
+	}
+	for v, expect := range map[string]int{"999999": -1, "1000000": 0, "1000001": 1} {
+		pv, err := f.parse(v)
+		if err != nil {
+			t.Errorf("parse %s: %s", v, err)
+			continue
+		}
+		if c := f.compare(pv, f.max); c != expect {
+			t.Errorf("compare %s with maximum: expected %d, returned %d", v, expect, c)
+		}
+	}
+}
+
+func TestTableValidator(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.json")
+	err := os.WriteFile(schemaFile, []byte(`{"fields": [{"name": "id", "type": "integer"}, {"name": "k", "type": "string"}],
+		"primaryKey": ["id", "k"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := readTableSchema(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Config{Delimiter: ',', CommentChar: '#'}
+
+	tests := []struct {
+		data   string
+		errors []string // row, column and error type
+	}{
+		// empty files and files without header row fail
+		{"", []string{"0 id missing-label", "0 k missing-label"}},
+		{"#comment\n", []string{"0 id missing-label", "0 k missing-label"}},
+		// every field of the primary key is required
+		{"id,k\n1,a\n2,\n,b\n,\n1,a\n", []string{
+			"2 k primary-key-error",
+			"3 id primary-key-error",
+			"4 id,k primary-key-error",
+			"5 id,k primary-key-error",
+		}},
+	}
+	for i, test := range tests {
+		file := filepath.Join(dir, fmt.Sprintf("%d.csv", i))
+		if err = os.WriteFile(file, []byte(test.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var errors []string
+		newTableValidator(schema, file, false, false).validate(config, nil, func(e validationError) bool {
+			errors = append(errors, fmt.Sprintf("%d %s %s", e.Row, e.Column, e.Error))
+			return false
+		})
+		if !reflect.DeepEqual(errors, test.errors) {
+			t.Errorf("case %d: expected %q, returned %q", i, test.errors, errors)
+		}
+	}
+}
@@ -25,13 +25,23 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/botond-sipos/thist"
+	"github.com/mattn/go-runewidth"
+	"github.com/shenwei356/util/stringutil"
 	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg/draw"
 )
 
 // watchCmd represents the seq command
@@ -40,7 +50,26 @@ var watchCmd = &cobra.Command{
 
 	Use:   "watch",
 	Short: "monitor the specified fields",
-	Long:  "monitor the specified fields",
+	Long: `monitor the specified fields
+
+Notes:
+  1. Multiple fields can be monitored, each one is shown in a panel.
+  2. A field is numeric if its first non-NA value is a number, unless
+     it's given in --categorical. Numeric fields are shown in histograms,
+     non-numeric values of them are counted as NA values.
+     Categorical fields are shown in tables of the top-k (-k) frequent values.
+  3. Running statistics are shown along with panels: the number of values,
+     the rate of NA values, and the mean, minimum, quartiles and maximum of
+     numeric fields (quartiles are approximate for large data), or the number
+     of distinct values of categorical fields. They're computed from values
+     before the log transform (-L).
+  4. Use -x/--pass to forward all input records to the output, so it can be
+     used in the middle of a pipeline.
+  5. With the global flag --follow, the input file is monitored continuously
+     as it grows, and new records are also reported when no more records
+     arrive in --follow-interval seconds.
+
+`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		config := getConfigs(cmd)
@@ -63,12 +92,22 @@ var watchCmd = &cobra.Command{
 		}
 		printBins := getFlagInt(cmd, "bins")
 		printPass := getFlagBool(cmd, "pass")
+		topK := getFlagPositiveInt(cmd, "top-k")
+		categoricals := make(map[string]struct{})
+		for _, f := range getFlagStringSlice(cmd, "categorical") {
+			categoricals[f] = struct{}{}
+		}
+		naValues := getFlagStringSlice(cmd, "na-values")
+		naMap := make(map[string]struct{}, len(naValues))
+		for _, na := range naValues {
+			naMap[strings.ToLower(na)] = struct{}{}
+		}
 
 		if config.Tabs {
 			config.OutDelimiter = rune('\t')
 		}
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 
@@ -91,7 +130,6 @@ var watchCmd = &cobra.Command{
 		if printBins > 0 {
 			binMode = "fixed"
 		}
-		h := thist.NewHist([]float64{}, printField, binMode, printBins, true)
 
 		transform := func(x float64) float64 { return x }
 		if printLog {
@@ -100,8 +138,56 @@ var watchCmd = &cobra.Command{
 			}
 		}
 
-		var count int
-		var p float64
+		var fields []*watchField
+		newFields := func(indexes []int, names []string) {
+			fields = make([]*watchField, len(indexes))
+			for i, f := range indexes {
+				name := printField
+				if len(names) > 0 {
+					name = names[i]
+				} else if len(indexes) > 1 {
+					name = fmt.Sprintf("column %d", f)
+				}
+				_, ok1 := categoricals[strconv.Itoa(f)]
+				_, ok2 := categoricals[name]
+				fields[i] = &watchField{
+					name:        name,
+					categorical: ok1 || ok2,
+					decided:     ok1 || ok2,
+					binMode:     binMode,
+					bins:        printBins,
+					transform:   transform,
+					naMap:       naMap,
+				}
+				fields[i].reset()
+			}
+		}
+
+		colored := term.IsTerminal(int(os.Stderr.Fd())) && os.Getenv("NO_COLOR") == ""
+		report := func(final bool) {
+			if printDump {
+				os.Stderr.Write([]byte(watchDump(fields, topK)))
+				if !final {
+					return
+				}
+			} else if !printQuiet {
+				os.Stderr.Write([]byte(thist.ClearScreenString()))
+				os.Stderr.Write([]byte(watchDraw(fields, topK, colored)))
+			}
+			if printPdf != "" {
+				watchSaveImages(fields, printPdf)
+			}
+		}
+
+		var count, reported int
+
+		// in follow mode, new records are also reported when the input is idle
+		var tick <-chan time.Time
+		if config.Follow {
+			ticker := time.NewTicker(config.FollowInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
 
 		for _, file := range files {
 			csvReader, err := newCSVReaderByConfig(config, file)
@@ -123,7 +209,23 @@ var watchCmd = &cobra.Command{
 			})
 
 			checkFirstLine := true
-			for record := range csvReader.Ch {
+			var record Record
+			var ok bool
+		READ:
+			for {
+				select {
+				case record, ok = <-csvReader.Ch:
+					if !ok {
+						break READ
+					}
+				case <-tick:
+					if fields != nil && count > reported {
+						reported = count
+						report(false)
+					}
+					continue
+				}
+
 				if record.Err != nil {
 					checkError(record.Err)
 				}
@@ -131,73 +233,302 @@ var watchCmd = &cobra.Command{
 				if checkFirstLine {
 					checkFirstLine = false
 
-					if len(record.Fields) > 1 {
-						checkError(fmt.Errorf("only a single field allowed"))
-					}
-
 					if !config.NoHeaderRow || record.IsHeaderRow {
+						if fields == nil {
+							newFields(record.Fields, record.Selected)
+						}
 						if printPass {
 							checkError(writer.Write(record.All))
 						}
 						continue
 					}
+					if fields == nil {
+						newFields(record.Fields, nil)
+					}
 				}
 
-				p, err = strconv.ParseFloat(record.Selected[0], 64)
-				if err != nil {
-					continue
+				for i, f := range fields {
+					f.add(record.Selected[i])
 				}
 
 				count++
-				h.Update(transform(p))
 				if printPass {
 					checkError(writer.Write(record.All))
+					if config.Follow {
+						writer.Flush()
+						checkError(outfh.Flush())
+					}
 				}
 
 				if printFreq > 0 && count%printFreq == 0 {
-					if printDump {
-						os.Stderr.Write([]byte(h.Dump()))
-					} else {
-						if !printQuiet {
-							os.Stderr.Write([]byte(thist.ClearScreenString()))
-							os.Stderr.Write([]byte(h.Draw()))
-						}
-						if printPdf != "" {
-							h.SaveImage(printPdf)
-						}
-					}
+					reported = count
+					report(false)
+					writer.Flush()
 					outfh.Flush()
 					if printReset {
-						h = thist.NewHist([]float64{}, printField, binMode, printBins, true)
+						for _, f := range fields {
+							f.reset()
+						}
 					}
 					time.Sleep(time.Duration(printDelay) * time.Second)
 				}
 			}
 		}
 
-		if printFreq < 0 || count%printFreq != 0 {
-			if printDump {
-				os.Stderr.Write([]byte(h.Dump()))
-			} else {
-				if !printQuiet {
-					os.Stderr.Write([]byte(thist.ClearScreenString()))
-					os.Stderr.Write([]byte(h.Draw()))
-				}
-			}
+		if fields != nil && (printFreq < 0 || count%printFreq != 0) {
+			report(true)
 			outfh.Flush()
-			if printPdf != "" {
-				h.SaveImage(printPdf)
-			}
 		}
 	},
 }
 
+// watchField contains the histogram or value counts, and running statistics of a field.
+type watchField struct {
+	name        string
+	categorical bool
+	decided     bool // whether the type is decided
+
+	n, na  int64 // numbers of values and NA values
+	hist   *thist.Hist
+	stats  *streamingStats
+	counts map[string]int64
+
+	binMode   string
+	bins      int
+	transform func(float64) float64
+	naMap     map[string]struct{}
+}
+
+func (f *watchField) reset() {
+	f.n, f.na = 0, 0
+	f.hist = thist.NewHist([]float64{}, f.name, f.binMode, f.bins, true)
+	f.stats = newStreamingStats(false, true)
+	f.counts = make(map[string]int64)
+}
+
+// add adds a value, the type of the field is decided by the first non-NA value.
+func (f *watchField) add(v string) {
+	if _, ok := f.naMap[strings.ToLower(v)]; ok {
+		f.na++
+		return
+	}
+	if f.categorical {
+		f.n++
+		f.counts[v]++
+		return
+	}
+	x, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		if !f.decided {
+			f.categorical, f.decided = true, true
+			f.add(v)
+			return
+		}
+		f.na++
+		return
+	}
+	f.decided = true
+	f.n++
+	f.hist.Update(f.transform(x))
+	f.stats.AddNumber(x)
+}
+
+// summary returns running statistics in a line.
+func (f *watchField) summary() string {
+	var naRate float64
+	if f.n+f.na > 0 {
+		naRate = float64(f.na) / float64(f.n+f.na) * 100
+	}
+	s := fmt.Sprintf("%s  n: %d  NA: %.2f%%", f.name, f.n, naRate)
+	if f.categorical {
+		return s + fmt.Sprintf("  distinct: %d", len(f.counts))
+	}
+	if f.n == 0 {
+		return s
+	}
+	for _, op := range []string{"mean", "min", "q1", "median", "q3", "max"} {
+		s += fmt.Sprintf("  %s: %s", op, strconv.FormatFloat(f.stats.Number(op), 'g', 6, 64))
+	}
+	return s
+}
+
+// top returns the k most frequent values.
+func (f *watchField) top(k int) []stringutil.StringCount {
+	counts := make([]stringutil.StringCount, 0, len(f.counts))
+	for v, n := range f.counts {
+		counts = append(counts, stringutil.StringCount{Key: v, Count: int(n)})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].Key < counts[j].Key
+		}
+		return counts[i].Count > counts[j].Count
+	})
+	if len(counts) > k {
+		counts = counts[:k]
+	}
+	return counts
+}
+
+// watchDump returns histogram data of numeric fields, and top-k values of categorical fields.
+// The output of a single numeric field is kept as before.
+func watchDump(fields []*watchField, k int) string {
+	if len(fields) == 1 && !fields[0].categorical {
+		return fields[0].hist.Dump()
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString("# " + f.summary() + "\n")
+		if !f.categorical {
+			b.WriteString(f.hist.Dump())
+			continue
+		}
+		b.WriteString("Value\tCount\n")
+		for _, c := range f.top(k) {
+			fmt.Fprintf(&b, "%s\t%d\n", c.Key, c.Count)
+		}
+	}
+	return b.String()
+}
+
+// watchDraw draws a panel for each field to fit the terminal.
+// A single numeric field is drawn with thist as before, with statistics in the information.
+func watchDraw(fields []*watchField, k int, colored bool) string {
+	if len(fields) == 1 && !fields[0].categorical {
+		h := fields[0].hist
+		info := h.Info
+		h.Info = strings.TrimRight(h.Info, "\n") + "\n" + fields[0].summary() + "\n"
+		s := h.Draw()
+		h.Info = info
+		return s
+	}
+
+	width, height := termSize()
+	height = (height - 1) / len(fields) // leave a line for the prompt
+	if height < 5 {
+		height = 5
+	}
+	var b strings.Builder
+	for i, f := range fields {
+		b.WriteString(watchTruncate(f.summary(), width) + "\n")
+		if f.categorical {
+			b.WriteString(watchTable(f, k, width, height-1))
+		} else {
+			b.WriteString(watchHistogram(f, i, width, height-1, colored))
+		}
+	}
+	return b.String()
+}
+
+// watchTable returns the top-k values, their counts and percentages, and bars of counts.
+func watchTable(f *watchField, k int, width, height int) string {
+	if k > height {
+		k = height
+	}
+	counts := f.top(k)
+	if len(counts) == 0 {
+		return "\n"
+	}
+	var wValue, wCount int
+	for _, c := range counts {
+		if w := runewidth.StringWidth(c.Key); w > wValue {
+			wValue = w
+		}
+		if w := len(strconv.Itoa(c.Count)); w > wCount {
+			wCount = w
+		}
+	}
+	if wValue > width/3 {
+		wValue = width / 3
+	}
+	wBar := width - wValue - wCount - 12
+	var b strings.Builder
+	for _, c := range counts {
+		value := runewidth.FillRight(runewidth.Truncate(c.Key, wValue, "…"), wValue)
+		fmt.Fprintf(&b, "%s  %*d  %5.1f%%", value, wCount, c.Count, float64(c.Count)/float64(f.n)*100)
+		if wBar > 0 {
+			b.WriteString("  " + strings.Repeat("█", int(math.Round(float64(wBar)*float64(c.Count)/float64(counts[0].Count)))))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// watchHistogram draws the histogram of a numeric field with Unicode characters.
+func watchHistogram(f *watchField, i int, width, height int, colored bool) string {
+	h := f.hist
+	if len(h.Counts) == 0 {
+		return "\n"
+	}
+	bins := make([]plotter.HistogramBin, 0, len(h.Counts))
+	for j, c := range h.Counts {
+		if c > 0 { // empty bins would be drawn as dots
+			bins = append(bins, plotter.HistogramBin{Min: h.BinStart[j], Max: h.BinEnd[j], Weight: c})
+		}
+	}
+	p := plot.New()
+	p.Add(&plotter.Histogram{
+		Bins:      bins,
+		Width:     h.BinWidth,
+		FillColor: plotutil.Color(i),
+	})
+	termStyle(p)
+	// minor ticks are too dense for small panels
+	majorTicks := plot.TickerFunc(func(min, max float64) []plot.Tick {
+		var ticks []plot.Tick
+		for _, t := range (plot.DefaultTicks{}).Ticks(min, max) {
+			if !t.IsMinor() {
+				ticks = append(ticks, t)
+			}
+		}
+		return ticks
+	})
+	p.X.Tick.Marker = majorTicks
+	p.Y.Tick.Marker = majorTicks
+
+	c := newTermCanvas(width, height)
+	p.Draw(draw.New(c))
+	var b strings.Builder
+	c.write(&b, colored)
+	return b.String()
+}
+
+// watchTruncate truncates a line to fit the width of the terminal.
+func watchTruncate(s string, width int) string {
+	return runewidth.Truncate(s, width, "…")
+}
+
+// watchSaveImages saves histograms of numeric fields. For multiple fields,
+// names of fields are added to the file name, e.g., hist.pdf -> hist.length.pdf.
+func watchSaveImages(fields []*watchField, file string) {
+	var numerics []*watchField
+	for _, f := range fields {
+		if !f.categorical && len(f.hist.Counts) > 0 {
+			numerics = append(numerics, f)
+		}
+	}
+	if len(numerics) == 1 && len(fields) == 1 {
+		numerics[0].hist.SaveImage(file)
+		return
+	}
+	ext := filepath.Ext(file)
+	for _, f := range numerics {
+		name := strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r == ' ' {
+				return '_'
+			}
+			return r
+		}, f.name)
+		f.hist.SaveImage(strings.TrimSuffix(file, ext) + "." + name + ext)
+	}
+}
+
 func init() {
 	RootCmd.AddCommand(watchCmd)
 
-	watchCmd.Flags().StringP("field", "f", "", "field to watch")
+	watchCmd.Flags().StringP("field", "f", "", "fields to watch")
 	watchCmd.Flags().IntP("print-freq", "p", -1, "print/report after this many records (-1 for print after EOF)")
-	watchCmd.Flags().StringP("image", "O", "", "save histogram to this PDF/image file")
+	watchCmd.Flags().StringP("image", "O", "", "save histogram to this PDF/image file, names of fields are added for multiple fields")
 	watchCmd.Flags().IntP("delay", "W", 1, "sleep this many seconds after plotting")
 	watchCmd.Flags().IntP("bins", "B", -1, "number of histogram bins")
 	watchCmd.Flags().BoolP("dump", "y", false, "print histogram data to stderr instead of plotting")
@@ -205,4 +536,7 @@ func init() {
 	watchCmd.Flags().BoolP("reset", "R", false, "reset histogram after every report")
 	watchCmd.Flags().BoolP("pass", "x", false, "passthrough mode (forward input to output)")
 	watchCmd.Flags().BoolP("quiet", "Q", false, "supress all plotting to stderr")
+	watchCmd.Flags().StringSliceP("categorical", "", []string{}, "column names or indexes of fields to be treated as categorical, e.g., numeric codes")
+	watchCmd.Flags().IntP("top-k", "k", 10, "number of most frequent values to show for categorical fields")
+	watchCmd.Flags().StringSliceP("na-values", "", []string{"", "NA", "N/A"}, `NA values, case ignored`)
 }
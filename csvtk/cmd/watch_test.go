@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestWatchField(t *testing.T) {
+	newField := func() *watchField {
+		f := &watchField{
+			name:      "f",
+			binMode:   "fixed",
+			bins:      5,
+			transform: func(x float64) float64 { return x },
+			naMap:     map[string]struct{}{"": {}, "na": {}},
+		}
+		f.reset()
+		return f
+	}
+
+	// numeric, non-numeric values are counted as NA
+	f := newField()
+	for _, v := range []string{"NA", "1", "2", "x", "3", ""} {
+		f.add(v)
+	}
+	if f.categorical || f.n != 3 || f.na != 3 {
+		t.Errorf("numeric field: unexpected categorical: %v, n: %d, na: %d", f.categorical, f.n, f.na)
+	}
+	if m := f.stats.Number("mean"); m != 2 {
+		t.Errorf("numeric field: expected mean 2, returned %g", m)
+	}
+
+	// categorical, decided by the first non-NA value
+	f = newField()
+	for _, v := range []string{"", "b", "1", "a", "b", "c", "b", "a"} {
+		f.add(v)
+	}
+	if !f.categorical || f.n != 7 || f.na != 1 {
+		t.Errorf("categorical field: unexpected categorical: %v, n: %d, na: %d", f.categorical, f.n, f.na)
+	}
+	top := f.top(2)
+	if len(top) != 2 || top[0].Key != "b" || top[0].Count != 3 || top[1].Key != "a" || top[1].Count != 2 {
+		t.Errorf("categorical field: unexpected top values: %v", top)
+	}
+}
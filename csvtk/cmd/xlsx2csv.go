@@ -26,7 +26,6 @@ import (
 	"runtime"
 	"sort"
 
-	"github.com/shenwei356/xopen"
 	"github.com/spf13/cobra"
 	"github.com/xuri/excelize/v2"
 )
@@ -56,7 +55,7 @@ var xlsx2csvCmd = &cobra.Command{
 		sheetName := getFlagString(cmd, "sheet-name")
 		sheetIndex := getFlagPositiveInt(cmd, "sheet-index")
 
-		outfh, err := xopen.Wopen(config.OutFile)
+		outfh, err := wopenByConfig(config, config.OutFile)
 		checkError(err)
 		defer outfh.Close()
 